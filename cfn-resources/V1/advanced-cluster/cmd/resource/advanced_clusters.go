@@ -0,0 +1,140 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"go.mongodb.org/atlas/mongodbatlas"
+)
+
+// the advanced cluster endpoints live in version 1.5 of the API, which the mongodbatlas client does not wrap,
+// paths are resolved relative to the client base url (https://cloud.mongodb.com/api/atlas/v1.0/)
+const advancedClustersPath = "../v1.5/groups/%s/clusters"
+
+type advancedCluster struct {
+	ID                       string                          `json:"id,omitempty"`
+	GroupID                  string                          `json:"groupId,omitempty"`
+	Name                     string                          `json:"name,omitempty"`
+	ClusterType              string                          `json:"clusterType,omitempty"`
+	BackupEnabled            *bool                           `json:"backupEnabled,omitempty"`
+	PitEnabled               *bool                           `json:"pitEnabled,omitempty"`
+	BiConnector              *mongodbatlas.BiConnector       `json:"biConnector,omitempty"`
+	DiskSizeGB               *float64                        `json:"diskSizeGB,omitempty"`
+	EncryptionAtRestProvider string                          `json:"encryptionAtRestProvider,omitempty"`
+	Labels                   []mongodbatlas.Label            `json:"labels,omitempty"`
+	MongoDBMajorVersion      string                          `json:"mongoDBMajorVersion,omitempty"`
+	MongoDBVersion           string                          `json:"mongoDBVersion,omitempty"`
+	CreateDate               string                          `json:"createDate,omitempty"`
+	StateName                string                          `json:"stateName,omitempty"`
+	Paused                   *bool                           `json:"paused,omitempty"`
+	ConnectionStrings        *mongodbatlas.ConnectionStrings `json:"connectionStrings,omitempty"`
+	ReplicationSpecs         []advancedReplicationSpec       `json:"replicationSpecs,omitempty"`
+}
+
+type advancedReplicationSpec struct {
+	ID            string                 `json:"id,omitempty"`
+	NumShards     *int64                 `json:"numShards,omitempty"`
+	ZoneName      string                 `json:"zoneName,omitempty"`
+	RegionConfigs []advancedRegionConfig `json:"regionConfigs,omitempty"`
+}
+
+type advancedRegionConfig struct {
+	ProviderName        string               `json:"providerName,omitempty"`
+	BackingProviderName string               `json:"backingProviderName,omitempty"`
+	RegionName          string               `json:"regionName,omitempty"`
+	Priority            *int64               `json:"priority,omitempty"`
+	ElectableSpecs      *advancedHardware    `json:"electableSpecs,omitempty"`
+	ReadOnlySpecs       *advancedHardware    `json:"readOnlySpecs,omitempty"`
+	AnalyticsSpecs      *advancedHardware    `json:"analyticsSpecs,omitempty"`
+	AutoScaling         *advancedAutoScaling `json:"autoScaling,omitempty"`
+}
+
+type advancedHardware struct {
+	InstanceSize  string `json:"instanceSize,omitempty"`
+	NodeCount     *int64 `json:"nodeCount,omitempty"`
+	DiskIOPS      *int64 `json:"diskIOPS,omitempty"`
+	EbsVolumeType string `json:"ebsVolumeType,omitempty"`
+}
+
+type advancedAutoScaling struct {
+	DiskGB  *advancedDiskGB       `json:"diskGB,omitempty"`
+	Compute *mongodbatlas.Compute `json:"compute,omitempty"`
+}
+
+type advancedDiskGB struct {
+	Enabled *bool `json:"enabled,omitempty"`
+}
+
+type advancedClustersResponse struct {
+	Links      []*mongodbatlas.Link `json:"links,omitempty"`
+	Results    []advancedCluster    `json:"results,omitempty"`
+	TotalCount int                  `json:"totalCount,omitempty"`
+}
+
+func advancedClusterPath(projectID, clusterName string) string {
+	return fmt.Sprintf("%s/%s", fmt.Sprintf(advancedClustersPath, projectID), url.PathEscape(clusterName))
+}
+
+func getAdvancedCluster(client *mongodbatlas.Client, projectID, clusterName string) (*advancedCluster, *mongodbatlas.Response, error) {
+	return doAdvancedCluster(client, http.MethodGet, advancedClusterPath(projectID, clusterName), nil)
+}
+
+func createAdvancedCluster(client *mongodbatlas.Client, projectID string, cluster *advancedCluster) (*advancedCluster, *mongodbatlas.Response, error) {
+	return doAdvancedCluster(client, http.MethodPost, fmt.Sprintf(advancedClustersPath, projectID), cluster)
+}
+
+func updateAdvancedCluster(client *mongodbatlas.Client, projectID, clusterName string, cluster *advancedCluster) (*advancedCluster, *mongodbatlas.Response, error) {
+	return doAdvancedCluster(client, http.MethodPatch, advancedClusterPath(projectID, clusterName), cluster)
+}
+
+func deleteAdvancedCluster(client *mongodbatlas.Client, projectID, clusterName string) (*mongodbatlas.Response, error) {
+	req, err := client.NewRequest(context.Background(), http.MethodDelete, advancedClusterPath(projectID, clusterName), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.Do(context.Background(), req, nil)
+}
+
+func listAdvancedClusters(client *mongodbatlas.Client, projectID string, pageNum, itemsPerPage int) (*advancedClustersResponse, *mongodbatlas.Response, error) {
+	path := fmt.Sprintf("%s?pageNum=%d&itemsPerPage=%d", fmt.Sprintf(advancedClustersPath, projectID), pageNum, itemsPerPage)
+
+	req, err := client.NewRequest(context.Background(), http.MethodGet, path, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	root := new(advancedClustersResponse)
+	resp, err := client.Do(context.Background(), req, root)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	if l := root.Links; l != nil {
+		resp.Links = l
+	}
+
+	return root, resp, nil
+}
+
+func doAdvancedCluster(client *mongodbatlas.Client, method, path string, body *advancedCluster) (*advancedCluster, *mongodbatlas.Response, error) {
+	var reqBody interface{}
+	if body != nil {
+		reqBody = body
+	}
+
+	req, err := client.NewRequest(context.Background(), method, path, reqBody)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	root := new(advancedCluster)
+	resp, err := client.Do(context.Background(), req, root)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return root, resp, nil
+}
@@ -0,0 +1,695 @@
+package resource
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/422158/mongodbstpatlas-cloudformation-resources/cfn-resources/V1/advanced-cluster/cmd/util"
+	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/spf13/cast"
+	"go.mongodb.org/atlas/mongodbatlas"
+)
+
+func castNO64(i *int64) *int {
+	x := cast.ToInt(&i)
+	return &x
+}
+func cast64(i *int) *int64 {
+	x := cast.ToInt64(&i)
+	return &x
+}
+
+// Create handles the Create event from the Cloudformation service.
+func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	client, err := util.CreateMongoDBClient(*currentModel.ApiKeys.PublicKey, *currentModel.ApiKeys.PrivateKey)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	if _, ok := req.CallbackContext["stateName"]; ok {
+		return validateProgress(client, req, currentModel, "IDLE", "CREATING", "UPDATING", "REPAIRING")
+	}
+
+	projectID := *currentModel.ProjectId
+
+	cluster, _, err := createAdvancedCluster(client, projectID, expandAdvancedCluster(currentModel))
+	if err != nil {
+		return handler.ProgressEvent{}, fmt.Errorf("error creating advanced cluster: %s", err)
+	}
+
+	currentModel.Id = &cluster.ID
+	currentModel.StateName = &cluster.StateName
+
+	cfnid := buildClusterCfnIdentifier(currentModel.ProjectId, currentModel.Name)
+
+	currentModel.ClusterCfnIdentifier = &cfnid
+
+	// putting required parameters into parameter store (needed for read operation)
+	_, err = putParameterIntoParameterStore(currentModel.ClusterCfnIdentifier, &ParameterToBePersistedSpec{ApiKeys: currentModel.ApiKeys, ProjectId: currentModel.ProjectId, ClusterName: currentModel.Name}, req.Session)
+	if err != nil {
+		return handler.ProgressEvent{}, fmt.Errorf("error when putting api keys into parameter store: %s", err)
+	}
+
+	return handler.ProgressEvent{
+		OperationStatus:      handler.InProgress,
+		Message:              fmt.Sprintf("Create Advanced Cluster `%s`", cluster.StateName),
+		ResourceModel:        currentModel,
+		CallbackDelaySeconds: 65,
+		CallbackContext: map[string]interface{}{
+			"stateName": cluster.StateName,
+		},
+	}, nil
+}
+
+// Read handles the Read event from the Cloudformation service.
+func Read(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	params, err := getParameterFromParameterStore(currentModel.ClusterCfnIdentifier, req.Session)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	client, err := util.CreateMongoDBClient(*params.ApiKeys.PublicKey, *params.ApiKeys.PrivateKey)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	cluster, _, err := getAdvancedCluster(client, *params.ProjectId, *params.ClusterName)
+	if err != nil {
+		return handler.ProgressEvent{}, fmt.Errorf("error fetching advanced cluster info (%s): %s", *params.ClusterName, err)
+	}
+
+	currentModel.ProjectId = params.ProjectId
+	flattenAdvancedCluster(currentModel, cluster)
+
+	return handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		Message:         "Read Complete",
+		ResourceModel:   currentModel,
+	}, nil
+}
+
+// Update handles the Update event from the Cloudformation service.
+func Update(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	client, err := util.CreateMongoDBClient(*currentModel.ApiKeys.PublicKey, *currentModel.ApiKeys.PrivateKey)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	if _, ok := req.CallbackContext["stateName"]; ok {
+		return validateProgress(client, req, currentModel, "IDLE", "UPDATING", "REPAIRING")
+	}
+
+	projectID := *currentModel.ProjectId
+	clusterName := *currentModel.Name
+
+	clusterRequest := expandAdvancedCluster(currentModel)
+	// the name is part of the path and cannot be changed
+	clusterRequest.Name = ""
+
+	cluster, _, err := updateAdvancedCluster(client, projectID, clusterName, clusterRequest)
+	if err != nil {
+		return handler.ProgressEvent{}, fmt.Errorf("error updating advanced cluster (%s): %s", clusterName, err)
+	}
+
+	currentModel.Id = &cluster.ID
+
+	cfnid := buildClusterCfnIdentifier(currentModel.ProjectId, currentModel.Name)
+
+	currentModel.ClusterCfnIdentifier = &cfnid
+
+	// putting required parameter into parameter store
+	// the api keys might have been updated therefore we need to do this here
+	_, err = putParameterIntoParameterStore(currentModel.ClusterCfnIdentifier, &ParameterToBePersistedSpec{ApiKeys: currentModel.ApiKeys, ProjectId: currentModel.ProjectId, ClusterName: currentModel.Name}, req.Session)
+	if err != nil {
+		return handler.ProgressEvent{}, fmt.Errorf("error when putting api keys into parameter store: %s", err)
+	}
+
+	return handler.ProgressEvent{
+		OperationStatus:      handler.InProgress,
+		Message:              fmt.Sprintf("Update Advanced Cluster `%s`", cluster.StateName),
+		ResourceModel:        currentModel,
+		CallbackDelaySeconds: 65,
+		CallbackContext: map[string]interface{}{
+			"stateName": cluster.StateName,
+		},
+	}, nil
+}
+
+// Delete handles the Delete event from the Cloudformation service.
+func Delete(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	client, err := util.CreateMongoDBClient(*currentModel.ApiKeys.PublicKey, *currentModel.ApiKeys.PrivateKey)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	if _, ok := req.CallbackContext["stateName"]; ok {
+		return validateProgress(client, req, currentModel, "DELETED", "DELETING", "IDLE")
+	}
+
+	projectID := *currentModel.ProjectId
+	clusterName := *currentModel.Name
+
+	_, err = deleteAdvancedCluster(client, projectID, clusterName)
+	if err != nil {
+		// even when error occurs when deleting, we still want to delete parameter from parameter store
+		_, errParams := deleteParameterFromParameterStore(currentModel.ClusterCfnIdentifier, req.Session)
+		if errParams != nil {
+			return handler.ProgressEvent{
+				OperationStatus:  handler.Failed,
+				Message:          "Delete Failed",
+				HandlerErrorCode: "GeneralServiceException",
+			}, fmt.Errorf("Error deleting advanced cluster with name(%s): %s.\nError deleting api keys from parameter store: %s", clusterName, err, errParams)
+		}
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          "Delete Failed",
+			HandlerErrorCode: "GeneralServiceException",
+		}, fmt.Errorf("error deleting advanced cluster with name (%s): %s", clusterName, err)
+	}
+
+	_, err = deleteParameterFromParameterStore(currentModel.ClusterCfnIdentifier, req.Session)
+	if err != nil {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          "Delete Failed",
+			HandlerErrorCode: "GeneralServiceException",
+		}, fmt.Errorf("error deleting parameters for advanced cluster %s: %s", clusterName, err)
+	}
+
+	return handler.ProgressEvent{
+		OperationStatus:      handler.InProgress,
+		Message:              "Delete In Progress",
+		ResourceModel:        currentModel,
+		CallbackDelaySeconds: 65,
+		CallbackContext: map[string]interface{}{
+			"stateName": "DELETING",
+		},
+	}, nil
+}
+
+// List handles the List event from the Cloudformation service.
+func List(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	client, err := util.CreateMongoDBClient(*currentModel.ApiKeys.PublicKey, *currentModel.ApiKeys.PrivateKey)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	projectID := *currentModel.ProjectId
+
+	const itemsPerPage = 100
+
+	models := make([]interface{}, 0)
+	for pageNum := 1; ; pageNum++ {
+		page, resp, err := listAdvancedClusters(client, projectID, pageNum, itemsPerPage)
+		if err != nil {
+			return handler.ProgressEvent{}, fmt.Errorf("error listing advanced clusters for project (%s): %s", projectID, err)
+		}
+
+		for i := range page.Results {
+			var model Model
+			model.ProjectId = currentModel.ProjectId
+			flattenAdvancedCluster(&model, &page.Results[i])
+			models = append(models, model)
+		}
+
+		if len(page.Results) < itemsPerPage || resp.IsLastPage() {
+			break
+		}
+	}
+
+	return handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		Message:         "List Complete",
+		ResourceModels:  models,
+	}, nil
+}
+
+func expandAdvancedCluster(model *Model) *advancedCluster {
+	cluster := &advancedCluster{
+		Name:                     cast.ToString(model.Name),
+		ClusterType:              cast.ToString(model.ClusterType),
+		BackupEnabled:            model.BackupEnabled,
+		PitEnabled:               model.PitEnabled,
+		DiskSizeGB:               model.DiskSizeGB,
+		EncryptionAtRestProvider: cast.ToString(model.EncryptionAtRestProvider),
+		ReplicationSpecs:         expandReplicationSpecs(model.ReplicationSpecs),
+	}
+
+	if model.MongoDBMajorVersion != nil {
+		cluster.MongoDBMajorVersion = formatMongoDBMajorVersion(*model.MongoDBMajorVersion)
+	}
+
+	if model.BiConnector != nil {
+		cluster.BiConnector = &mongodbatlas.BiConnector{
+			Enabled:        model.BiConnector.Enabled,
+			ReadPreference: cast.ToString(model.BiConnector.ReadPreference),
+		}
+	}
+
+	for _, l := range model.Labels {
+		cluster.Labels = append(cluster.Labels, mongodbatlas.Label{
+			Key:   cast.ToString(l.Key),
+			Value: cast.ToString(l.Value),
+		})
+	}
+
+	return cluster
+}
+
+func expandReplicationSpecs(replicationSpecs []AdvancedReplicationSpec) []advancedReplicationSpec {
+	rSpecs := make([]advancedReplicationSpec, 0)
+
+	for _, s := range replicationSpecs {
+		rSpec := advancedReplicationSpec{
+			ID:       cast.ToString(s.ID),
+			ZoneName: cast.ToString(s.ZoneName),
+		}
+		if s.NumShards != nil {
+			rSpec.NumShards = cast64(s.NumShards)
+		}
+
+		for i := range s.RegionConfigs {
+			rSpec.RegionConfigs = append(rSpec.RegionConfigs, expandRegionConfig(&s.RegionConfigs[i]))
+		}
+
+		rSpecs = append(rSpecs, rSpec)
+	}
+	return rSpecs
+}
+
+func expandRegionConfig(region *AdvancedRegionConfig) advancedRegionConfig {
+	regionConfig := advancedRegionConfig{
+		ProviderName:        cast.ToString(region.ProviderName),
+		BackingProviderName: cast.ToString(region.BackingProviderName),
+		RegionName:          cast.ToString(region.RegionName),
+		ElectableSpecs:      expandSpecs(region.ElectableSpecs),
+		ReadOnlySpecs:       expandSpecs(region.ReadOnlySpecs),
+		AnalyticsSpecs:      expandSpecs(region.AnalyticsSpecs),
+	}
+
+	if region.Priority != nil {
+		regionConfig.Priority = cast64(region.Priority)
+	}
+
+	if region.AutoScaling != nil {
+		autoScaling := &advancedAutoScaling{}
+		if region.AutoScaling.DiskGB != nil {
+			autoScaling.DiskGB = &advancedDiskGB{Enabled: region.AutoScaling.DiskGB.Enabled}
+		}
+		if region.AutoScaling.Compute != nil {
+			autoScaling.Compute = &mongodbatlas.Compute{
+				Enabled:          region.AutoScaling.Compute.Enabled,
+				ScaleDownEnabled: region.AutoScaling.Compute.ScaleDownEnabled,
+				MinInstanceSize:  cast.ToString(region.AutoScaling.Compute.MinInstanceSize),
+				MaxInstanceSize:  cast.ToString(region.AutoScaling.Compute.MaxInstanceSize),
+			}
+		}
+		regionConfig.AutoScaling = autoScaling
+	}
+
+	return regionConfig
+}
+
+func expandSpecs(specs *Specs) *advancedHardware {
+	if specs == nil {
+		return nil
+	}
+
+	hardware := &advancedHardware{
+		InstanceSize:  cast.ToString(specs.InstanceSize),
+		EbsVolumeType: cast.ToString(specs.EbsVolumeType),
+	}
+	if specs.NodeCount != nil {
+		hardware.NodeCount = cast64(specs.NodeCount)
+	}
+	if specs.DiskIOPS != nil {
+		hardware.DiskIOPS = cast64(specs.DiskIOPS)
+	}
+	return hardware
+}
+
+// flattenAdvancedCluster copies the state Atlas reports for an advanced cluster into the model. Labels are sorted,
+// or kept in the order of the model when it holds the same ones, and the replication spec ids Atlas assigns and its
+// default zone name are only reported when the model sets them, so a Read matches the template.
+func flattenAdvancedCluster(currentModel *Model, cluster *advancedCluster) {
+	currentModel.Name = &cluster.Name
+	currentModel.Id = &cluster.ID
+	currentModel.StateName = &cluster.StateName
+	currentModel.CreatedDate = stringOrNil(cluster.CreateDate)
+	currentModel.MongoDBVersion = stringOrNil(cluster.MongoDBVersion)
+	currentModel.ClusterType = stringOrNil(cluster.ClusterType)
+	currentModel.BackupEnabled = cluster.BackupEnabled
+	currentModel.PitEnabled = cluster.PitEnabled
+	currentModel.DiskSizeGB = cluster.DiskSizeGB
+	currentModel.EncryptionAtRestProvider = stringOrNil(cluster.EncryptionAtRestProvider)
+
+	if cluster.MongoDBMajorVersion != "" {
+		version := formatMongoDBMajorVersion(cluster.MongoDBMajorVersion)
+		currentModel.MongoDBMajorVersion = &version
+	}
+
+	if cluster.BiConnector != nil {
+		currentModel.BiConnector = &BiConnector{
+			ReadPreference: stringOrNil(cluster.BiConnector.ReadPreference),
+			Enabled:        cluster.BiConnector.Enabled,
+		}
+	}
+
+	if cluster.ConnectionStrings != nil {
+		currentModel.ConnectionString = stringOrNil(cluster.ConnectionStrings.Standard)
+		currentModel.SrvConnectionString = stringOrNil(cluster.ConnectionStrings.StandardSrv)
+	}
+
+	currentModel.Labels = orderLabelsLike(currentModel.Labels, flattenLabels(cluster.Labels))
+	currentModel.ReplicationSpecs = flattenReplicationSpecs(currentModel.ReplicationSpecs, cluster.ReplicationSpecs)
+
+	cfnid := buildClusterCfnIdentifier(currentModel.ProjectId, currentModel.Name)
+	currentModel.ClusterCfnIdentifier = &cfnid
+}
+
+// flattenLabels returns the labels sorted by key and value, Atlas does not guarantee their order
+func flattenLabels(clusterLabels []mongodbatlas.Label) []LabelDefinition {
+	sorted := make([]mongodbatlas.Label, len(clusterLabels))
+	copy(sorted, clusterLabels)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Key != sorted[j].Key {
+			return sorted[i].Key < sorted[j].Key
+		}
+		return sorted[i].Value < sorted[j].Value
+	})
+
+	var labels []LabelDefinition
+	for i := range sorted {
+		labels = append(labels, LabelDefinition{
+			Key:   &sorted[i].Key,
+			Value: &sorted[i].Value,
+		})
+	}
+	return labels
+}
+
+// orderLabelsLike returns the labels in the order of prior when both hold the same labels, otherwise unchanged
+func orderLabelsLike(prior []LabelDefinition, labels []LabelDefinition) []LabelDefinition {
+	if len(prior) != len(labels) {
+		return labels
+	}
+
+	remaining := make([]LabelDefinition, len(labels))
+	copy(remaining, labels)
+
+	ordered := make([]LabelDefinition, 0, len(labels))
+	for _, p := range prior {
+		found := false
+		for j, l := range remaining {
+			if cast.ToString(l.Key) == cast.ToString(p.Key) && cast.ToString(l.Value) == cast.ToString(p.Value) {
+				ordered = append(ordered, l)
+				remaining = append(remaining[:j], remaining[j+1:]...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return labels
+		}
+	}
+	return ordered
+}
+
+// defaultZoneName is the zone name Atlas gives a replication spec created without one
+const defaultZoneName = "Zone 1"
+
+// flattenReplicationSpecs maps the replication specs Atlas reports, prior are the specs of the model at the same
+// positions. The spec id is only set when prior sets it, the zone name when prior sets it or it is not the default.
+func flattenReplicationSpecs(prior []AdvancedReplicationSpec, rSpecs []advancedReplicationSpec) []AdvancedReplicationSpec {
+	specs := make([]AdvancedReplicationSpec, 0)
+	for i := range rSpecs {
+		rSpec := &rSpecs[i]
+		var priorSpec AdvancedReplicationSpec
+		if i < len(prior) {
+			priorSpec = prior[i]
+		}
+
+		spec := AdvancedReplicationSpec{}
+		if priorSpec.ID != nil {
+			spec.ID = stringOrNil(rSpec.ID)
+		}
+		if priorSpec.ZoneName != nil || rSpec.ZoneName != defaultZoneName {
+			spec.ZoneName = stringOrNil(rSpec.ZoneName)
+		}
+		if rSpec.NumShards != nil {
+			spec.NumShards = castNO64(rSpec.NumShards)
+		}
+		for j := range rSpec.RegionConfigs {
+			spec.RegionConfigs = append(spec.RegionConfigs, flattenRegionConfig(&rSpec.RegionConfigs[j]))
+		}
+		specs = append(specs, spec)
+	}
+	return specs
+}
+
+func flattenRegionConfig(regionConfig *advancedRegionConfig) AdvancedRegionConfig {
+	region := AdvancedRegionConfig{
+		ProviderName:   &regionConfig.ProviderName,
+		RegionName:     &regionConfig.RegionName,
+		ElectableSpecs: flattenSpecs(regionConfig.ElectableSpecs),
+		ReadOnlySpecs:  flattenSpecs(regionConfig.ReadOnlySpecs),
+		AnalyticsSpecs: flattenSpecs(regionConfig.AnalyticsSpecs),
+	}
+
+	if regionConfig.BackingProviderName != "" {
+		region.BackingProviderName = &regionConfig.BackingProviderName
+	}
+
+	if regionConfig.Priority != nil {
+		region.Priority = castNO64(regionConfig.Priority)
+	}
+
+	if regionConfig.AutoScaling != nil {
+		autoScaling := &AdvancedAutoScaling{}
+		if regionConfig.AutoScaling.DiskGB != nil {
+			autoScaling.DiskGB = &DiskGB{Enabled: regionConfig.AutoScaling.DiskGB.Enabled}
+		}
+		if compute := regionConfig.AutoScaling.Compute; compute != nil {
+			autoScaling.Compute = &AdvancedCompute{
+				Enabled:          compute.Enabled,
+				ScaleDownEnabled: compute.ScaleDownEnabled,
+			}
+			if compute.MinInstanceSize != "" {
+				autoScaling.Compute.MinInstanceSize = &compute.MinInstanceSize
+			}
+			if compute.MaxInstanceSize != "" {
+				autoScaling.Compute.MaxInstanceSize = &compute.MaxInstanceSize
+			}
+		}
+		region.AutoScaling = autoScaling
+	}
+
+	return region
+}
+
+func flattenSpecs(hardware *advancedHardware) *Specs {
+	// Atlas reports empty specs for node kinds that are not deployed in a region
+	if hardware == nil || hardware.NodeCount == nil || *hardware.NodeCount == 0 {
+		return nil
+	}
+
+	specs := &Specs{
+		InstanceSize: &hardware.InstanceSize,
+		NodeCount:    castNO64(hardware.NodeCount),
+	}
+	if hardware.DiskIOPS != nil {
+		specs.DiskIOPS = castNO64(hardware.DiskIOPS)
+	}
+	if hardware.EbsVolumeType != "" {
+		specs.EbsVolumeType = &hardware.EbsVolumeType
+	}
+	return specs
+}
+
+func stringOrNil(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func formatMongoDBMajorVersion(val interface{}) string {
+	if strings.Contains(val.(string), ".") {
+		return val.(string)
+	}
+	return fmt.Sprintf("%.1f", cast.ToFloat32(val))
+}
+
+// defaultStabilizationTimeoutMinutes is how long the callbacks poll a cluster when StabilizationTimeoutMinutes is not set
+const defaultStabilizationTimeoutMinutes = 180
+
+// validateProgress polls the cluster until it reaches targetState, see progressOfState
+func validateProgress(client *mongodbatlas.Client, req handler.Request, currentModel *Model, targetState string, pendingStates ...string) (handler.ProgressEvent, error) {
+	isReady, state, err := isClusterInTargetState(client, *currentModel.ProjectId, *currentModel.Name, targetState)
+	if err != nil {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          fmt.Sprintf("error waiting for advanced cluster (%s) to reach state %s: %s", *currentModel.Name, targetState, err),
+			HandlerErrorCode: "GeneralServiceException",
+		}, nil
+	}
+
+	return progressOfState(req, currentModel, isReady, state, targetState, pendingStates), nil
+}
+
+// progressOfState turns the polled state into the next progress event, any state that is neither the target nor one
+// of pendingStates fails the operation, as does waiting longer than the stabilization timeout
+func progressOfState(req handler.Request, currentModel *Model, isReady bool, state string, targetState string, pendingStates []string) handler.ProgressEvent {
+	clusterName := *currentModel.Name
+
+	if isReady {
+		p := handler.NewProgressEvent()
+		p.ResourceModel = currentModel
+		p.OperationStatus = handler.Success
+		p.Message = "Complete"
+		return p
+	}
+
+	if state != targetState && !contains(pendingStates, state) {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          fmt.Sprintf("error waiting for advanced cluster (%s) to reach state %s: unexpected state %s", clusterName, targetState, state),
+			HandlerErrorCode: "GeneralServiceException",
+		}
+	}
+
+	startTime := time.Now().UTC()
+	if started, ok := req.CallbackContext["startTime"]; ok {
+		if t, err := time.Parse(time.RFC3339, cast.ToString(started)); err == nil {
+			startTime = t
+		}
+	}
+	pollCount := cast.ToInt(req.CallbackContext["pollCount"]) + 1
+
+	timeout := stabilizationTimeout(currentModel)
+	if time.Since(startTime) > timeout {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          fmt.Sprintf("advanced cluster (%s) did not reach state %s within %s, still %s after %d polls", clusterName, targetState, timeout, state, pollCount),
+			HandlerErrorCode: "NotStabilized",
+		}
+	}
+
+	p := handler.NewProgressEvent()
+	p.ResourceModel = currentModel
+	p.OperationStatus = handler.InProgress
+	p.CallbackDelaySeconds = 60
+	p.Message = "Pending"
+	p.CallbackContext = map[string]interface{}{
+		"stateName": state,
+		"startTime": startTime.Format(time.RFC3339),
+		"pollCount": pollCount,
+	}
+	return p
+}
+
+func stabilizationTimeout(currentModel *Model) time.Duration {
+	if currentModel.StabilizationTimeoutMinutes != nil && *currentModel.StabilizationTimeoutMinutes > 0 {
+		return time.Duration(*currentModel.StabilizationTimeoutMinutes) * time.Minute
+	}
+	return time.Duration(defaultStabilizationTimeoutMinutes) * time.Minute
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func isClusterInTargetState(client *mongodbatlas.Client, projectID, clusterName, targetState string) (bool, string, error) {
+	cluster, resp, err := getAdvancedCluster(client, projectID, clusterName)
+	if err != nil {
+		if resp != nil && resp.StatusCode == 404 {
+			return "DELETED" == targetState, "DELETED", nil
+		}
+		return false, "ERROR", fmt.Errorf("error fetching advanced cluster info (%s): %s", clusterName, err)
+	}
+	return cluster.StateName == targetState, cluster.StateName, nil
+}
+
+type ParameterToBePersistedSpec struct {
+	ApiKeys     *ApiKeyDefinition
+	ProjectId   *string
+	ClusterName *string
+}
+
+func putParameterIntoParameterStore(resourcePrimaryIdentifier *string, params *ParameterToBePersistedSpec, session *session.Session) (*ssm.PutParameterOutput, error) {
+	ssmClient, err := util.CreateSSMClient(session)
+	if err != nil {
+		return nil, err
+	}
+	// transform api keys to json string
+	parameterName := buildApiKeyParameterName(*resourcePrimaryIdentifier)
+	byteParams, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	stringifiedParams := string(byteParams)
+	parameterType := "SecureString"
+	overwrite := true
+	putParamOutput, err := ssmClient.PutParameter(&ssm.PutParameterInput{Name: &parameterName, Value: &stringifiedParams, Type: &parameterType, Overwrite: &overwrite})
+	if err != nil {
+		return nil, err
+	}
+
+	return putParamOutput, nil
+}
+
+func deleteParameterFromParameterStore(resourcePrimaryIdentifier *string, session *session.Session) (*ssm.DeleteParameterOutput, error) {
+	ssmClient, err := util.CreateSSMClient(session)
+	if err != nil {
+		return nil, err
+	}
+	parameterName := buildApiKeyParameterName(*resourcePrimaryIdentifier)
+
+	deleteParamOutput, err := ssmClient.DeleteParameter(&ssm.DeleteParameterInput{Name: &parameterName})
+	if err != nil {
+		return nil, err
+	}
+
+	return deleteParamOutput, nil
+}
+
+func getParameterFromParameterStore(resourcePrimaryIdentifier *string, session *session.Session) (*ParameterToBePersistedSpec, error) {
+	ssmClient, err := util.CreateSSMClient(session)
+	if err != nil {
+		return nil, err
+	}
+	parameterName := buildApiKeyParameterName(*resourcePrimaryIdentifier)
+	decrypt := true
+	getParamOutput, err := ssmClient.GetParameter(&ssm.GetParameterInput{Name: &parameterName, WithDecryption: &decrypt})
+	if err != nil {
+		return nil, err
+	}
+
+	var params ParameterToBePersistedSpec
+	err = json.Unmarshal([]byte(*getParamOutput.Parameter.Value), &params)
+	if err != nil {
+		return nil, err
+	}
+	return &params, nil
+}
+
+func buildClusterCfnIdentifier(projectId *string, clusterName *string) string {
+	return fmt.Sprintf("%s-%s", *projectId, *clusterName)
+}
+
+func buildApiKeyParameterName(resourcePrimaryIdentifier string) string {
+	// this is strictly coupled with permissions for handlers, changing this means changing permissions in handler
+	// moreover changing this might cause polution in parameter store -  be sure you know what you are doing
+	parameterStorePrefix := "mongodbstpatlasv1advancedcluster"
+	return fmt.Sprintf("%s-%s", parameterStorePrefix, resourcePrimaryIdentifier)
+}
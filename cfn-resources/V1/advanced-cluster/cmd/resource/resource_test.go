@@ -0,0 +1,130 @@
+package resource
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
+	"go.mongodb.org/atlas/mongodbatlas"
+)
+
+func label(key, value string) LabelDefinition {
+	return LabelDefinition{Key: &key, Value: &value}
+}
+
+func TestProgressOfState(t *testing.T) {
+	name := "multicloud"
+	timeoutMinutes := 30
+	startedLongAgo := time.Now().UTC().Add(-4 * time.Hour).Format(time.RFC3339)
+	startedRecently := time.Now().UTC().Add(-20 * time.Minute).Format(time.RFC3339)
+
+	testCases := []struct {
+		name       string
+		model      *Model
+		context    map[string]interface{}
+		isReady    bool
+		state      string
+		wantStatus handler.Status
+		wantCode   string
+	}{
+		{"ready", &Model{Name: &name}, map[string]interface{}{"stateName": "CREATING"}, true, "IDLE", handler.Success, ""},
+		{"first poll", &Model{Name: &name}, map[string]interface{}{"stateName": "CREATING"}, false, "CREATING", handler.InProgress, ""},
+		{"repairing while created", &Model{Name: &name}, map[string]interface{}{"stateName": "CREATING"}, false, "REPAIRING", handler.InProgress, ""},
+		{"unexpected state", &Model{Name: &name}, map[string]interface{}{"stateName": "CREATING"}, false, "DELETING", handler.Failed, "GeneralServiceException"},
+		{"default timeout exceeded", &Model{Name: &name}, map[string]interface{}{"stateName": "CREATING", "startTime": startedLongAgo}, false, "CREATING", handler.Failed, "NotStabilized"},
+		{"within the default timeout", &Model{Name: &name}, map[string]interface{}{"stateName": "CREATING", "startTime": startedRecently}, false, "CREATING", handler.InProgress, ""},
+		{"custom timeout exceeded", &Model{Name: &name, StabilizationTimeoutMinutes: &timeoutMinutes}, map[string]interface{}{"stateName": "CREATING", "startTime": startedLongAgo}, false, "CREATING", handler.Failed, "NotStabilized"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := handler.Request{CallbackContext: tc.context}
+			p := progressOfState(req, tc.model, tc.isReady, tc.state, "IDLE", []string{"CREATING", "UPDATING", "REPAIRING"})
+
+			if p.OperationStatus != tc.wantStatus || p.HandlerErrorCode != tc.wantCode {
+				t.Errorf("want %s %s, got %s %s: %s", tc.wantStatus, tc.wantCode, p.OperationStatus, p.HandlerErrorCode, p.Message)
+			}
+		})
+	}
+}
+
+func TestProgressOfStateKeepsStartTime(t *testing.T) {
+	name := "multicloud"
+	started := time.Now().UTC().Add(-10 * time.Minute).Format(time.RFC3339)
+	req := handler.Request{CallbackContext: map[string]interface{}{"stateName": "UPDATING", "startTime": started, "pollCount": 3}}
+
+	p := progressOfState(req, &Model{Name: &name}, false, "UPDATING", "IDLE", []string{"UPDATING"})
+
+	if p.CallbackContext["startTime"] != started || p.CallbackContext["pollCount"] != 4 {
+		t.Errorf("want the start time kept and the poll counted, got %v", p.CallbackContext)
+	}
+}
+
+func TestFlattenAdvancedClusterMatchesTemplate(t *testing.T) {
+	projectID := "5f1ea7d9ab3c1c2f3e9b0a00"
+	name := "multicloud"
+	numShards := 1
+	providerName := "AWS"
+	regionName := "US_EAST_1"
+	instanceSize := "M10"
+	nodeCount := 3
+	template := &Model{
+		ProjectId: &projectID,
+		Name:      &name,
+		Labels:    []LabelDefinition{label("team", "db"), label("env", "prod")},
+		ReplicationSpecs: []AdvancedReplicationSpec{{
+			NumShards: &numShards,
+			RegionConfigs: []AdvancedRegionConfig{{
+				ProviderName:   &providerName,
+				RegionName:     &regionName,
+				ElectableSpecs: &Specs{InstanceSize: &instanceSize, NodeCount: &nodeCount},
+			}},
+		}},
+	}
+
+	cluster := expandAdvancedCluster(template)
+	cluster.ID = "5f1ea7d9ab3c1c2f3e9b0a11"
+	cluster.StateName = "IDLE"
+	cluster.ClusterType = "REPLICASET"
+	cluster.Labels = []mongodbatlas.Label{{Key: "env", Value: "prod"}, {Key: "team", Value: "db"}}
+	cluster.ReplicationSpecs[0].ID = "5f1ea7d9ab3c1c2f3e9b0a20"
+	cluster.ReplicationSpecs[0].ZoneName = defaultZoneName
+
+	flattenAdvancedCluster(template, cluster)
+
+	if want := []LabelDefinition{label("team", "db"), label("env", "prod")}; !reflect.DeepEqual(want, template.Labels) {
+		t.Errorf("want the labels in template order, got %v", template.Labels)
+	}
+	spec := template.ReplicationSpecs[0]
+	if spec.ID != nil || spec.ZoneName != nil {
+		t.Errorf("want the spec id and the default zone name left out, got %v and %v", spec.ID, spec.ZoneName)
+	}
+	if *template.ClusterType != "REPLICASET" || *template.ClusterCfnIdentifier != projectID+"-"+name {
+		t.Errorf("want the cluster type and identifier, got %v and %v", template.ClusterType, template.ClusterCfnIdentifier)
+	}
+}
+
+func TestFlattenAdvancedClusterWithoutTemplate(t *testing.T) {
+	projectID := "5f1ea7d9ab3c1c2f3e9b0a00"
+	model := &Model{ProjectId: &projectID}
+
+	flattenAdvancedCluster(model, &advancedCluster{
+		Name:   "listed",
+		Labels: []mongodbatlas.Label{{Key: "team", Value: "db"}, {Key: "env", Value: "prod"}},
+		ReplicationSpecs: []advancedReplicationSpec{
+			{ID: "5f1ea7d9ab3c1c2f3e9b0a20", ZoneName: defaultZoneName},
+			{ID: "5f1ea7d9ab3c1c2f3e9b0a21", ZoneName: "Zone EU"},
+		},
+	})
+
+	if want := []LabelDefinition{label("env", "prod"), label("team", "db")}; !reflect.DeepEqual(want, model.Labels) {
+		t.Errorf("want the labels sorted, got %v", model.Labels)
+	}
+	if model.ReplicationSpecs[0].ZoneName != nil || *model.ReplicationSpecs[1].ZoneName != "Zone EU" {
+		t.Errorf("want only the named zone, got %v and %v", model.ReplicationSpecs[0].ZoneName, model.ReplicationSpecs[1].ZoneName)
+	}
+	if model.EncryptionAtRestProvider != nil || model.MongoDBVersion != nil {
+		t.Errorf("want empty values left unset, got %v and %v", model.EncryptionAtRestProvider, model.MongoDBVersion)
+	}
+}
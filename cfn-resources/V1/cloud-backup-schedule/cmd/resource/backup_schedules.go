@@ -0,0 +1,86 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"go.mongodb.org/atlas/mongodbatlas"
+)
+
+// the backup policy type of the mongodbatlas client has neither the copy settings nor the export settings, the
+// endpoints are called directly instead, paths are resolved relative to the client base url
+// (https://cloud.mongodb.com/api/atlas/v1.0/)
+const backupSchedulePath = "groups/%s/clusters/%s/backup/schedule"
+
+type backupSchedule struct {
+	ClusterID                         string                `json:"clusterId,omitempty"`
+	ClusterName                       string                `json:"clusterName,omitempty"`
+	ReferenceHourOfDay                *int64                `json:"referenceHourOfDay,omitempty"`
+	ReferenceMinuteOfHour             *int64                `json:"referenceMinuteOfHour,omitempty"`
+	RestoreWindowDays                 *int64                `json:"restoreWindowDays,omitempty"`
+	UpdateSnapshots                   *bool                 `json:"updateSnapshots,omitempty"`
+	NextSnapshot                      string                `json:"nextSnapshot,omitempty"`
+	Policies                          []mongodbatlas.Policy `json:"policies,omitempty"`
+	AutoExportEnabled                 *bool                 `json:"autoExportEnabled,omitempty"`
+	Export                            *backupExport         `json:"export,omitempty"`
+	UseOrgAndGroupNamesInExportPrefix *bool                 `json:"useOrgAndGroupNamesInExportPrefix,omitempty"`
+	// a pointer so an empty list, which removes every copy setting, is still sent
+	CopySettings *[]backupCopySetting `json:"copySettings,omitempty"`
+}
+
+type backupExport struct {
+	ExportBucketID string `json:"exportBucketId,omitempty"`
+	FrequencyType  string `json:"frequencyType,omitempty"`
+}
+
+type backupCopySetting struct {
+	CloudProvider     string   `json:"cloudProvider,omitempty"`
+	RegionName        string   `json:"regionName,omitempty"`
+	ReplicationSpecID string   `json:"replicationSpecId,omitempty"`
+	ShouldCopyOplogs  *bool    `json:"shouldCopyOplogs,omitempty"`
+	Frequencies       []string `json:"frequencies"`
+}
+
+func backupSchedulePathOf(projectID, clusterName string) string {
+	return fmt.Sprintf(backupSchedulePath, projectID, url.PathEscape(clusterName))
+}
+
+func getBackupSchedule(client *mongodbatlas.Client, projectID, clusterName string) (*backupSchedule, *mongodbatlas.Response, error) {
+	return doBackupSchedule(client, http.MethodGet, backupSchedulePathOf(projectID, clusterName), nil)
+}
+
+func updateBackupSchedule(client *mongodbatlas.Client, projectID, clusterName string, schedule *backupSchedule) (*backupSchedule, *mongodbatlas.Response, error) {
+	return doBackupSchedule(client, http.MethodPatch, backupSchedulePathOf(projectID, clusterName), schedule)
+}
+
+// deleteBackupSchedule removes every policy item of the schedule, Atlas takes no more snapshots until new ones are set
+func deleteBackupSchedule(client *mongodbatlas.Client, projectID, clusterName string) (*mongodbatlas.Response, error) {
+	req, err := client.NewRequest(context.Background(), http.MethodDelete, backupSchedulePathOf(projectID, clusterName), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.Do(context.Background(), req, nil)
+}
+
+func doBackupSchedule(client *mongodbatlas.Client, method, path string, body *backupSchedule) (*backupSchedule, *mongodbatlas.Response, error) {
+	var reqBody interface{}
+	if body != nil {
+		reqBody = body
+	}
+
+	req, err := client.NewRequest(context.Background(), method, path, reqBody)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	root := new(backupSchedule)
+	resp, err := client.Do(context.Background(), req, root)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return root, resp, nil
+}
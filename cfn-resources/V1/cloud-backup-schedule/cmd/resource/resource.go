@@ -0,0 +1,553 @@
+package resource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/422158/mongodbstpatlas-cloudformation-resources/cfn-resources/V1/cloud-backup-schedule/cmd/util"
+	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/spf13/cast"
+	"go.mongodb.org/atlas/mongodbatlas"
+)
+
+func castNO64(i *int64) *int {
+	x := cast.ToInt(&i)
+	return &x
+}
+func cast64(i *int) *int64 {
+	x := cast.ToInt64(&i)
+	return &x
+}
+
+// frequencyOrder orders policy items the way Atlas presents them
+var frequencyOrder = map[string]int{
+	"hourly":  0,
+	"daily":   1,
+	"weekly":  2,
+	"monthly": 3,
+}
+
+// Create handles the Create event from the Cloudformation service.
+// Atlas gives every cluster with backups a schedule, Create applies the settings of the model to it.
+func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	client, err := util.CreateMongoDBClient(*currentModel.ApiKeys.PublicKey, *currentModel.ApiKeys.PrivateKey)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	if err := validateSchedule(currentModel); err != nil {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          err.Error(),
+			HandlerErrorCode: "InvalidRequest",
+		}, nil
+	}
+
+	err = applySchedule(client, nil, currentModel)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	cfnid := buildCloudBackupScheduleCfnIdentifier(currentModel.ProjectId, currentModel.ClusterName)
+
+	currentModel.CloudBackupScheduleCfnIdentifier = &cfnid
+
+	// putting required parameters into parameter store (needed for read operation)
+	_, err = putParameterIntoParameterStore(currentModel.CloudBackupScheduleCfnIdentifier, &ParameterToBePersistedSpec{ApiKeys: currentModel.ApiKeys, ProjectId: currentModel.ProjectId, ClusterName: currentModel.ClusterName}, req.Session)
+	if err != nil {
+		return handler.ProgressEvent{}, fmt.Errorf("error when putting api keys into parameter store: %s", err)
+	}
+
+	return handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		Message:         "Create Complete",
+		ResourceModel:   currentModel,
+	}, nil
+}
+
+// Read handles the Read event from the Cloudformation service.
+func Read(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	params, err := getParameterFromParameterStore(currentModel.CloudBackupScheduleCfnIdentifier, req.Session)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	client, err := util.CreateMongoDBClient(*params.ApiKeys.PublicKey, *params.ApiKeys.PrivateKey)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	schedule, _, err := getBackupSchedule(client, *params.ProjectId, *params.ClusterName)
+	if err != nil {
+		return handler.ProgressEvent{}, fmt.Errorf("error fetching cloud backup schedule of cluster (%s): %s", *params.ClusterName, err)
+	}
+
+	currentModel.ProjectId = params.ProjectId
+	currentModel.ClusterName = params.ClusterName
+	flattenBackupSchedule(currentModel, schedule)
+
+	return handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		Message:         "Read Complete",
+		ResourceModel:   currentModel,
+	}, nil
+}
+
+// Update handles the Update event from the Cloudformation service.
+func Update(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	client, err := util.CreateMongoDBClient(*currentModel.ApiKeys.PublicKey, *currentModel.ApiKeys.PrivateKey)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	if err := validateSchedule(currentModel); err != nil {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          err.Error(),
+			HandlerErrorCode: "InvalidRequest",
+		}, nil
+	}
+
+	err = applySchedule(client, prevModel, currentModel)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	cfnid := buildCloudBackupScheduleCfnIdentifier(currentModel.ProjectId, currentModel.ClusterName)
+
+	currentModel.CloudBackupScheduleCfnIdentifier = &cfnid
+
+	// putting required parameter into parameter store
+	// the api keys might have been updated therefore we need to do this here
+	_, err = putParameterIntoParameterStore(currentModel.CloudBackupScheduleCfnIdentifier, &ParameterToBePersistedSpec{ApiKeys: currentModel.ApiKeys, ProjectId: currentModel.ProjectId, ClusterName: currentModel.ClusterName}, req.Session)
+	if err != nil {
+		return handler.ProgressEvent{}, fmt.Errorf("error when putting api keys into parameter store: %s", err)
+	}
+
+	return handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		Message:         "Update Complete",
+		ResourceModel:   currentModel,
+	}, nil
+}
+
+// Delete handles the Delete event from the Cloudformation service.
+func Delete(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	client, err := util.CreateMongoDBClient(*currentModel.ApiKeys.PublicKey, *currentModel.ApiKeys.PrivateKey)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	projectID := *currentModel.ProjectId
+	clusterName := *currentModel.ClusterName
+
+	resp, err := deleteBackupSchedule(client, projectID, clusterName)
+	// a cluster deleted first takes its schedule with it
+	if err != nil && (resp == nil || resp.StatusCode != 404) {
+		// even when error occurs when deleting, we still want to delete parameter from parameter store
+		_, errParams := deleteParameterFromParameterStore(currentModel.CloudBackupScheduleCfnIdentifier, req.Session)
+		if errParams != nil {
+			return handler.ProgressEvent{
+				OperationStatus:  handler.Failed,
+				Message:          "Delete Failed",
+				HandlerErrorCode: "GeneralServiceException",
+			}, fmt.Errorf("Error deleting cloud backup schedule of cluster (%s): %s.\nError deleting api keys from parameter store: %s", clusterName, err, errParams)
+		}
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          "Delete Failed",
+			HandlerErrorCode: "GeneralServiceException",
+		}, fmt.Errorf("error deleting cloud backup schedule of cluster (%s): %s", clusterName, err)
+	}
+
+	_, err = deleteParameterFromParameterStore(currentModel.CloudBackupScheduleCfnIdentifier, req.Session)
+	if err != nil {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          "Delete Failed",
+			HandlerErrorCode: "GeneralServiceException",
+		}, fmt.Errorf("error deleting parameters for cloud backup schedule of cluster %s: %s", clusterName, err)
+	}
+
+	return handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		Message:         "Delete Complete",
+		ResourceModel:   currentModel,
+	}, nil
+}
+
+// List handles the List event from the Cloudformation service.
+// Every cluster of the project with cloud backups enabled is reported with its schedule.
+func List(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	client, err := util.CreateMongoDBClient(*currentModel.ApiKeys.PublicKey, *currentModel.ApiKeys.PrivateKey)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	projectID := *currentModel.ProjectId
+
+	const itemsPerPage = 100
+
+	models := make([]interface{}, 0)
+	for pageNum := 1; ; pageNum++ {
+		clusters, resp, err := client.Clusters.List(context.Background(), projectID, &mongodbatlas.ListOptions{PageNum: pageNum, ItemsPerPage: itemsPerPage})
+		if err != nil {
+			return handler.ProgressEvent{}, fmt.Errorf("error listing clusters for project (%s): %s", projectID, err)
+		}
+
+		for i := range clusters {
+			if clusters[i].ProviderBackupEnabled == nil || !*clusters[i].ProviderBackupEnabled {
+				continue
+			}
+
+			schedule, _, err := getBackupSchedule(client, projectID, clusters[i].Name)
+			if err != nil {
+				return handler.ProgressEvent{}, fmt.Errorf("error fetching cloud backup schedule of cluster (%s): %s", clusters[i].Name, err)
+			}
+
+			var model Model
+			model.ProjectId = currentModel.ProjectId
+			model.ClusterName = &clusters[i].Name
+			flattenBackupSchedule(&model, schedule)
+			cfnid := buildCloudBackupScheduleCfnIdentifier(model.ProjectId, model.ClusterName)
+			model.CloudBackupScheduleCfnIdentifier = &cfnid
+			models = append(models, model)
+		}
+
+		if len(clusters) < itemsPerPage || resp.IsLastPage() {
+			break
+		}
+	}
+
+	return handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		Message:         "List Complete",
+		ResourceModels:  models,
+	}, nil
+}
+
+// applySchedule sends the settings of currentModel to Atlas and copies what Atlas reports back onto it.
+// The policy items replace those of the existing policy, and policy items, an export or copy settings prevModel
+// had are removed when currentModel has none.
+func applySchedule(client *mongodbatlas.Client, prevModel *Model, currentModel *Model) error {
+	projectID := *currentModel.ProjectId
+	clusterName := *currentModel.ClusterName
+
+	existing, _, err := getBackupSchedule(client, projectID, clusterName)
+	if err != nil {
+		return fmt.Errorf("error fetching cloud backup schedule of cluster (%s): %s", clusterName, err)
+	}
+
+	policyID := ""
+	if len(existing.Policies) > 0 {
+		policyID = existing.Policies[0].ID
+	}
+
+	// an empty policy is left out of the update, the policy items are removed the way Delete removes them
+	if len(currentModel.PolicyItems) == 0 && prevModel != nil && len(prevModel.PolicyItems) > 0 {
+		if _, err := deleteBackupSchedule(client, projectID, clusterName); err != nil {
+			return fmt.Errorf("error removing the policy items of cluster (%s): %s", clusterName, err)
+		}
+	}
+
+	schedule, _, err := updateBackupSchedule(client, projectID, clusterName, expandBackupSchedule(prevModel, currentModel, policyID))
+	if err != nil {
+		return fmt.Errorf("error updating cloud backup schedule of cluster (%s): %s", clusterName, err)
+	}
+
+	if schedule.ClusterID != "" {
+		currentModel.ClusterId = &schedule.ClusterID
+	}
+	if schedule.NextSnapshot != "" {
+		currentModel.NextSnapshot = &schedule.NextSnapshot
+	}
+	return nil
+}
+
+// validateSchedule checks the frequency intervals of the policy items and that an export has a bucket
+func validateSchedule(model *Model) error {
+	for i, item := range model.PolicyItems {
+		frequencyType := cast.ToString(item.FrequencyType)
+		interval := cast.ToInt(item.FrequencyInterval)
+
+		valid := true
+		switch frequencyType {
+		case "hourly":
+			valid = interval == 1 || interval == 2 || interval == 4 || interval == 6 || interval == 8 || interval == 12
+		case "daily":
+			valid = interval == 1
+		case "weekly":
+			valid = interval >= 1 && interval <= 7
+		case "monthly":
+			valid = (interval >= 1 && interval <= 28) || interval == 40
+		}
+		if !valid {
+			return fmt.Errorf("PolicyItems[%d].FrequencyInterval: %d is not a valid interval for %s policy items", i, interval, frequencyType)
+		}
+	}
+
+	if model.AutoExportEnabled != nil && *model.AutoExportEnabled && model.Export == nil {
+		return fmt.Errorf("Export must be set when AutoExportEnabled is true")
+	}
+	return nil
+}
+
+// expandBackupSchedule builds the update for model. prevModel is nil on create, otherwise an export or copy
+// settings it had are cleared when model has none, as Atlas keeps whatever the update leaves out.
+func expandBackupSchedule(prevModel *Model, model *Model, policyID string) *backupSchedule {
+	schedule := &backupSchedule{
+		UpdateSnapshots:                   model.UpdateSnapshots,
+		AutoExportEnabled:                 model.AutoExportEnabled,
+		UseOrgAndGroupNamesInExportPrefix: model.UseOrgAndGroupNamesInExportPrefix,
+	}
+
+	if model.ReferenceHourOfDay != nil {
+		schedule.ReferenceHourOfDay = cast64(model.ReferenceHourOfDay)
+	}
+	if model.ReferenceMinuteOfHour != nil {
+		schedule.ReferenceMinuteOfHour = cast64(model.ReferenceMinuteOfHour)
+	}
+	if model.RestoreWindowDays != nil {
+		schedule.RestoreWindowDays = cast64(model.RestoreWindowDays)
+	}
+
+	if len(model.PolicyItems) > 0 {
+		policy := mongodbatlas.Policy{ID: policyID}
+		for _, item := range model.PolicyItems {
+			policy.PolicyItems = append(policy.PolicyItems, mongodbatlas.PolicyItem{
+				FrequencyType:     cast.ToString(item.FrequencyType),
+				FrequencyInterval: cast.ToInt(item.FrequencyInterval),
+				RetentionUnit:     cast.ToString(item.RetentionUnit),
+				RetentionValue:    cast.ToInt(item.RetentionValue),
+			})
+		}
+		schedule.Policies = []mongodbatlas.Policy{policy}
+	}
+
+	if model.Export != nil {
+		schedule.Export = &backupExport{
+			ExportBucketID: cast.ToString(model.Export.ExportBucketId),
+			FrequencyType:  cast.ToString(model.Export.FrequencyType),
+		}
+	} else if prevModel != nil && prevModel.Export != nil {
+		schedule.Export = &backupExport{}
+		if schedule.AutoExportEnabled == nil {
+			autoExportEnabled := false
+			schedule.AutoExportEnabled = &autoExportEnabled
+		}
+	}
+
+	if len(model.CopySettings) > 0 {
+		copySettings := make([]backupCopySetting, 0, len(model.CopySettings))
+		for _, setting := range model.CopySettings {
+			copySettings = append(copySettings, backupCopySetting{
+				CloudProvider:     cast.ToString(setting.CloudProvider),
+				RegionName:        cast.ToString(setting.RegionName),
+				ReplicationSpecID: cast.ToString(setting.ReplicationSpecId),
+				ShouldCopyOplogs:  setting.ShouldCopyOplogs,
+				Frequencies:       setting.Frequencies,
+			})
+		}
+		schedule.CopySettings = &copySettings
+	} else if prevModel != nil && len(prevModel.CopySettings) > 0 {
+		schedule.CopySettings = &[]backupCopySetting{}
+	}
+
+	return schedule
+}
+
+// flattenBackupSchedule maps the schedule Atlas reports onto the model. Policy items, copy settings and their
+// frequencies are sorted so the result does not depend on the order Atlas returns them in, and flags Atlas
+// reports as false are only kept when the model sets them.
+func flattenBackupSchedule(currentModel *Model, schedule *backupSchedule) {
+	prior := *currentModel
+
+	if schedule.ClusterID != "" {
+		currentModel.ClusterId = &schedule.ClusterID
+	}
+	currentModel.NextSnapshot = nil
+	if schedule.NextSnapshot != "" {
+		currentModel.NextSnapshot = &schedule.NextSnapshot
+	}
+
+	currentModel.ReferenceHourOfDay = nil
+	if schedule.ReferenceHourOfDay != nil {
+		currentModel.ReferenceHourOfDay = castNO64(schedule.ReferenceHourOfDay)
+	}
+	currentModel.ReferenceMinuteOfHour = nil
+	if schedule.ReferenceMinuteOfHour != nil {
+		currentModel.ReferenceMinuteOfHour = castNO64(schedule.ReferenceMinuteOfHour)
+	}
+	currentModel.RestoreWindowDays = nil
+	if schedule.RestoreWindowDays != nil {
+		currentModel.RestoreWindowDays = castNO64(schedule.RestoreWindowDays)
+	}
+
+	currentModel.PolicyItems = flattenPolicyItems(schedule.Policies)
+	currentModel.CopySettings = flattenCopySettings(schedule.CopySettings, prior.CopySettings)
+
+	currentModel.AutoExportEnabled = flagOrNil(schedule.AutoExportEnabled, prior.AutoExportEnabled)
+	currentModel.UseOrgAndGroupNamesInExportPrefix = flagOrNil(schedule.UseOrgAndGroupNamesInExportPrefix, prior.UseOrgAndGroupNamesInExportPrefix)
+
+	currentModel.Export = nil
+	if schedule.Export != nil && schedule.Export.ExportBucketID != "" {
+		currentModel.Export = &Export{
+			ExportBucketId: &schedule.Export.ExportBucketID,
+		}
+		if schedule.Export.FrequencyType != "" {
+			currentModel.Export.FrequencyType = &schedule.Export.FrequencyType
+		}
+	}
+}
+
+// flattenPolicyItems returns the items of every policy sorted by frequency type and interval
+func flattenPolicyItems(policies []mongodbatlas.Policy) []PolicyItem {
+	var items []mongodbatlas.PolicyItem
+	for _, policy := range policies {
+		items = append(items, policy.PolicyItems...)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].FrequencyType != items[j].FrequencyType {
+			return frequencyOrder[items[i].FrequencyType] < frequencyOrder[items[j].FrequencyType]
+		}
+		return items[i].FrequencyInterval < items[j].FrequencyInterval
+	})
+
+	var policyItems []PolicyItem
+	for i := range items {
+		policyItems = append(policyItems, PolicyItem{
+			FrequencyType:     &items[i].FrequencyType,
+			FrequencyInterval: &items[i].FrequencyInterval,
+			RetentionUnit:     &items[i].RetentionUnit,
+			RetentionValue:    &items[i].RetentionValue,
+		})
+	}
+	return policyItems
+}
+
+// flattenCopySettings returns the copy settings sorted by provider, region and replication spec, ShouldCopyOplogs
+// is left out when Atlas reports it false and the setting of prior for the same region does not set it
+func flattenCopySettings(copySettings *[]backupCopySetting, prior []CopySetting) []CopySetting {
+	if copySettings == nil || len(*copySettings) == 0 {
+		return nil
+	}
+
+	sorted := make([]backupCopySetting, len(*copySettings))
+	copy(sorted, *copySettings)
+	sort.Slice(sorted, func(i, j int) bool {
+		return copySettingKey(sorted[i].CloudProvider, sorted[i].RegionName, sorted[i].ReplicationSpecID) <
+			copySettingKey(sorted[j].CloudProvider, sorted[j].RegionName, sorted[j].ReplicationSpecID)
+	})
+
+	priorOplogs := make(map[string]*bool, len(prior))
+	for _, setting := range prior {
+		priorOplogs[copySettingKey(cast.ToString(setting.CloudProvider), cast.ToString(setting.RegionName), cast.ToString(setting.ReplicationSpecId))] = setting.ShouldCopyOplogs
+	}
+
+	var settings []CopySetting
+	for i := range sorted {
+		frequencies := make([]string, len(sorted[i].Frequencies))
+		copy(frequencies, sorted[i].Frequencies)
+		sort.Strings(frequencies)
+
+		key := copySettingKey(sorted[i].CloudProvider, sorted[i].RegionName, sorted[i].ReplicationSpecID)
+		settings = append(settings, CopySetting{
+			CloudProvider:     &sorted[i].CloudProvider,
+			RegionName:        &sorted[i].RegionName,
+			ReplicationSpecId: &sorted[i].ReplicationSpecID,
+			ShouldCopyOplogs:  flagOrNil(sorted[i].ShouldCopyOplogs, priorOplogs[key]),
+			Frequencies:       frequencies,
+		})
+	}
+	return settings
+}
+
+// flagOrNil returns the flag Atlas reports, or nil when it is false and prior does not set it
+func flagOrNil(actual *bool, prior *bool) *bool {
+	if actual == nil || (!*actual && prior == nil) {
+		return nil
+	}
+	return actual
+}
+
+func copySettingKey(cloudProvider, regionName, replicationSpecID string) string {
+	return fmt.Sprintf("%s/%s/%s", cloudProvider, regionName, replicationSpecID)
+}
+
+type ParameterToBePersistedSpec struct {
+	ApiKeys     *ApiKeyDefinition
+	ProjectId   *string
+	ClusterName *string
+}
+
+func putParameterIntoParameterStore(resourcePrimaryIdentifier *string, params *ParameterToBePersistedSpec, session *session.Session) (*ssm.PutParameterOutput, error) {
+	ssmClient, err := util.CreateSSMClient(session)
+	if err != nil {
+		return nil, err
+	}
+	// transform api keys to json string
+	parameterName := buildApiKeyParameterName(*resourcePrimaryIdentifier)
+	byteParams, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	stringifiedParams := string(byteParams)
+	parameterType := "SecureString"
+	overwrite := true
+	putParamOutput, err := ssmClient.PutParameter(&ssm.PutParameterInput{Name: &parameterName, Value: &stringifiedParams, Type: &parameterType, Overwrite: &overwrite})
+	if err != nil {
+		return nil, err
+	}
+
+	return putParamOutput, nil
+}
+
+func deleteParameterFromParameterStore(resourcePrimaryIdentifier *string, session *session.Session) (*ssm.DeleteParameterOutput, error) {
+	ssmClient, err := util.CreateSSMClient(session)
+	if err != nil {
+		return nil, err
+	}
+	parameterName := buildApiKeyParameterName(*resourcePrimaryIdentifier)
+
+	deleteParamOutput, err := ssmClient.DeleteParameter(&ssm.DeleteParameterInput{Name: &parameterName})
+	if err != nil {
+		return nil, err
+	}
+
+	return deleteParamOutput, nil
+}
+
+func getParameterFromParameterStore(resourcePrimaryIdentifier *string, session *session.Session) (*ParameterToBePersistedSpec, error) {
+	ssmClient, err := util.CreateSSMClient(session)
+	if err != nil {
+		return nil, err
+	}
+	parameterName := buildApiKeyParameterName(*resourcePrimaryIdentifier)
+	decrypt := true
+	getParamOutput, err := ssmClient.GetParameter(&ssm.GetParameterInput{Name: &parameterName, WithDecryption: &decrypt})
+	if err != nil {
+		return nil, err
+	}
+
+	var params ParameterToBePersistedSpec
+	err = json.Unmarshal([]byte(*getParamOutput.Parameter.Value), &params)
+	if err != nil {
+		return nil, err
+	}
+	return &params, nil
+}
+
+func buildCloudBackupScheduleCfnIdentifier(projectId *string, clusterName *string) string {
+	return fmt.Sprintf("%s-%s", *projectId, *clusterName)
+}
+
+func buildApiKeyParameterName(resourcePrimaryIdentifier string) string {
+	// this is strictly coupled with permissions for handlers, changing this means changing permissions in handler
+	// moreover changing this might cause polution in parameter store -  be sure you know what you are doing
+	parameterStorePrefix := "mongodbstpatlasv1cloudbackupschedule"
+	return fmt.Sprintf("%s-%s", parameterStorePrefix, resourcePrimaryIdentifier)
+}
@@ -0,0 +1,173 @@
+package resource
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"go.mongodb.org/atlas/mongodbatlas"
+)
+
+func policyItem(frequencyType string, frequencyInterval int, retentionUnit string, retentionValue int) PolicyItem {
+	return PolicyItem{FrequencyType: &frequencyType, FrequencyInterval: &frequencyInterval, RetentionUnit: &retentionUnit, RetentionValue: &retentionValue}
+}
+
+func copySetting(cloudProvider, regionName, replicationSpecID string, frequencies ...string) CopySetting {
+	return CopySetting{CloudProvider: &cloudProvider, RegionName: &regionName, ReplicationSpecId: &replicationSpecID, Frequencies: frequencies}
+}
+
+func export(exportBucketID, frequencyType string) *Export {
+	return &Export{ExportBucketId: &exportBucketID, FrequencyType: &frequencyType}
+}
+
+func TestValidateSchedule(t *testing.T) {
+	enabled := true
+
+	testCases := []struct {
+		name    string
+		model   *Model
+		wantErr bool
+	}{
+		{"hourly every 6 hours", &Model{PolicyItems: []PolicyItem{policyItem("hourly", 6, "days", 2)}}, false},
+		{"weekly on sunday", &Model{PolicyItems: []PolicyItem{policyItem("weekly", 7, "weeks", 4)}}, false},
+		{"monthly on the last day", &Model{PolicyItems: []PolicyItem{policyItem("monthly", 40, "months", 12)}}, false},
+		{"export with bucket", &Model{AutoExportEnabled: &enabled, Export: export("5f5a5e1b2c3d4e5f6a7b8c9d", "monthly")}, false},
+		{"hourly every 5 hours", &Model{PolicyItems: []PolicyItem{policyItem("hourly", 5, "days", 2)}}, true},
+		{"daily every 2 days", &Model{PolicyItems: []PolicyItem{policyItem("daily", 2, "days", 7)}}, true},
+		{"monthly on day 30", &Model{PolicyItems: []PolicyItem{policyItem("monthly", 30, "months", 12)}}, true},
+		{"export without bucket", &Model{AutoExportEnabled: &enabled}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateSchedule(tc.model)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("want error %t, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestExpandBackupScheduleClearsRemovedSettings(t *testing.T) {
+	previous := &Model{
+		PolicyItems:  []PolicyItem{policyItem("daily", 1, "days", 7)},
+		Export:       export("5f5a5e1b2c3d4e5f6a7b8c9d", "monthly"),
+		CopySettings: []CopySetting{copySetting("AWS", "US_WEST_2", "a", "DAILY")},
+	}
+
+	schedule := expandBackupSchedule(previous, &Model{}, "5f5a5e1b2c3d4e5f6a7b8c9d")
+
+	if schedule.Export == nil || *schedule.Export != (backupExport{}) {
+		t.Errorf("want an empty export sent, got %v", schedule.Export)
+	}
+	if schedule.AutoExportEnabled == nil || *schedule.AutoExportEnabled {
+		t.Errorf("want auto export disabled, got %v", schedule.AutoExportEnabled)
+	}
+	if schedule.CopySettings == nil || len(*schedule.CopySettings) != 0 {
+		t.Errorf("want an empty list of copy settings sent, got %v", schedule.CopySettings)
+	}
+
+	schedule = expandBackupSchedule(nil, &Model{}, "")
+
+	if schedule.Export != nil || schedule.AutoExportEnabled != nil || schedule.CopySettings != nil {
+		t.Errorf("want nothing cleared on create, got %+v", schedule)
+	}
+}
+
+func TestApplyScheduleRemovesPolicyItems(t *testing.T) {
+	projectID, clusterName := "5f5a5e1b2c3d4e5f6a7b8c9a", "cluster"
+
+	testCases := []struct {
+		name       string
+		previous   *Model
+		current    *Model
+		wantDelete bool
+	}{
+		{"removed", &Model{PolicyItems: []PolicyItem{policyItem("daily", 1, "days", 7)}}, &Model{}, true},
+		{"replaced", &Model{PolicyItems: []PolicyItem{policyItem("daily", 1, "days", 7)}}, &Model{PolicyItems: []PolicyItem{policyItem("weekly", 7, "weeks", 4)}}, false},
+		{"created", nil, &Model{}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			deleted := false
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodDelete {
+					deleted = true
+				}
+				fmt.Fprint(w, `{"clusterId": "5f5a5e1b2c3d4e5f6a7b8c9e", "policies": [{"id": "5f5a5e1b2c3d4e5f6a7b8c9d"}]}`)
+			}))
+			defer server.Close()
+			client := mongodbatlas.NewClient(nil)
+			client.BaseURL, _ = url.Parse(server.URL + "/")
+
+			tc.current.ProjectId = &projectID
+			tc.current.ClusterName = &clusterName
+			if err := applySchedule(client, tc.previous, tc.current); err != nil {
+				t.Fatalf("want no error, got %s", err)
+			}
+			if deleted != tc.wantDelete {
+				t.Errorf("want policy items deleted %t, got %t", tc.wantDelete, deleted)
+			}
+		})
+	}
+}
+
+func TestFlattenBackupScheduleSortsPolicyItems(t *testing.T) {
+	model := &Model{}
+
+	flattenBackupSchedule(model, &backupSchedule{
+		Policies: []mongodbatlas.Policy{{
+			ID: "5f5a5e1b2c3d4e5f6a7b8c9d",
+			PolicyItems: []mongodbatlas.PolicyItem{
+				{ID: "1", FrequencyType: "monthly", FrequencyInterval: 40, RetentionUnit: "months", RetentionValue: 12},
+				{ID: "2", FrequencyType: "weekly", FrequencyInterval: 7, RetentionUnit: "weeks", RetentionValue: 4},
+				{ID: "3", FrequencyType: "daily", FrequencyInterval: 1, RetentionUnit: "days", RetentionValue: 7},
+				{ID: "4", FrequencyType: "hourly", FrequencyInterval: 12, RetentionUnit: "days", RetentionValue: 2},
+				{ID: "5", FrequencyType: "weekly", FrequencyInterval: 3, RetentionUnit: "weeks", RetentionValue: 4},
+			},
+		}},
+	})
+
+	want := []string{"hourly/12", "daily/1", "weekly/3", "weekly/7", "monthly/40"}
+	if len(model.PolicyItems) != len(want) {
+		t.Fatalf("want %d policy items, got %d", len(want), len(model.PolicyItems))
+	}
+	for i, item := range model.PolicyItems {
+		if got := fmt.Sprintf("%s/%d", *item.FrequencyType, *item.FrequencyInterval); got != want[i] {
+			t.Errorf("policy item %d: want %s, got %s", i, want[i], got)
+		}
+	}
+}
+
+func TestFlattenBackupScheduleMatchesTemplate(t *testing.T) {
+	model := &Model{CopySettings: []CopySetting{copySetting("AWS", "US_WEST_2", "a", "HOURLY", "DAILY")}}
+
+	no := false
+	flattenBackupSchedule(model, &backupSchedule{
+		ClusterID:         "5f5a5e1b2c3d4e5f6a7b8c9e",
+		AutoExportEnabled: &no,
+		Export:            &backupExport{},
+		CopySettings: &[]backupCopySetting{
+			{CloudProvider: "AWS", RegionName: "US_WEST_2", ReplicationSpecID: "a", ShouldCopyOplogs: &no, Frequencies: []string{"DAILY", "HOURLY"}},
+		},
+	})
+
+	if model.AutoExportEnabled != nil {
+		t.Errorf("want AutoExportEnabled left out, got %t", *model.AutoExportEnabled)
+	}
+	if model.Export != nil {
+		t.Errorf("want the empty export left out, got %v", model.Export)
+	}
+	if len(model.CopySettings) != 1 {
+		t.Fatalf("want 1 copy setting, got %d", len(model.CopySettings))
+	}
+	if model.CopySettings[0].ShouldCopyOplogs != nil {
+		t.Errorf("want ShouldCopyOplogs left out, got %t", *model.CopySettings[0].ShouldCopyOplogs)
+	}
+	if got := model.CopySettings[0].Frequencies; got[0] != "DAILY" || got[1] != "HOURLY" {
+		t.Errorf("want the frequencies sorted, got %v", got)
+	}
+}
@@ -0,0 +1,537 @@
+package resource
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/422158/mongodbstpatlas-cloudformation-resources/cfn-resources/V1/cloud-backup-snapshot-restore-job/cmd/util"
+	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/spf13/cast"
+	"go.mongodb.org/atlas/mongodbatlas"
+)
+
+const (
+	deliveryTypeAutomated   = "automated"
+	deliveryTypeDownload    = "download"
+	deliveryTypePointInTime = "pointInTime"
+)
+
+// states of a restore job, Atlas reports flags and timestamps instead, restoreJobState derives them
+const (
+	restoreJobInProgress = "IN_PROGRESS"
+	restoreJobCompleted  = "COMPLETED"
+	restoreJobFailed     = "FAILED"
+	restoreJobCancelled  = "CANCELLED"
+	restoreJobExpired    = "EXPIRED"
+)
+
+// Create handles the Create event from the Cloudformation service.
+func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	client, err := util.CreateMongoDBClient(*currentModel.ApiKeys.PublicKey, *currentModel.ApiKeys.PrivateKey)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	if _, ok := req.CallbackContext["restoreJobState"]; ok {
+		return validateProgress(client, req, currentModel)
+	}
+
+	if err := validateRestoreJob(currentModel); err != nil {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          err.Error(),
+			HandlerErrorCode: "InvalidRequest",
+		}, nil
+	}
+
+	projectID := *currentModel.ProjectId
+	clusterName := *currentModel.ClusterName
+
+	job, _, err := createRestoreJob(client, projectID, clusterName, expandRestoreJob(currentModel))
+	if err != nil {
+		return handler.ProgressEvent{}, fmt.Errorf("error creating restore job for cluster (%s): %s", clusterName, err)
+	}
+
+	currentModel.JobId = &job.ID
+
+	cfnid := buildRestoreJobCfnIdentifier(currentModel.ProjectId, currentModel.ClusterName, currentModel.JobId)
+
+	currentModel.RestoreJobCfnIdentifier = &cfnid
+
+	// putting required parameters into parameter store (needed for read operation)
+	_, err = putParameterIntoParameterStore(currentModel.RestoreJobCfnIdentifier, &ParameterToBePersistedSpec{ApiKeys: currentModel.ApiKeys, ProjectId: currentModel.ProjectId, ClusterName: currentModel.ClusterName, JobId: currentModel.JobId}, req.Session)
+	if err != nil {
+		return handler.ProgressEvent{}, fmt.Errorf("error when putting api keys into parameter store: %s", err)
+	}
+
+	state := restoreJobState(job)
+
+	return handler.ProgressEvent{
+		OperationStatus:      handler.InProgress,
+		Message:              fmt.Sprintf("Create Restore Job `%s`", state),
+		ResourceModel:        currentModel,
+		CallbackDelaySeconds: 65,
+		CallbackContext: map[string]interface{}{
+			"restoreJobState": state,
+		},
+	}, nil
+}
+
+// Read handles the Read event from the Cloudformation service.
+func Read(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	params, err := getParameterFromParameterStore(currentModel.RestoreJobCfnIdentifier, req.Session)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	client, err := util.CreateMongoDBClient(*params.ApiKeys.PublicKey, *params.ApiKeys.PrivateKey)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	job, _, err := getRestoreJob(client, *params.ProjectId, *params.ClusterName, *params.JobId)
+	if err != nil {
+		return handler.ProgressEvent{}, fmt.Errorf("error fetching restore job info (%s): %s", *params.JobId, err)
+	}
+
+	currentModel.ProjectId = params.ProjectId
+	currentModel.ClusterName = params.ClusterName
+	flattenRestoreJob(currentModel, job)
+
+	return handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		Message:         "Read Complete",
+		ResourceModel:   currentModel,
+	}, nil
+}
+
+// Update handles the Update event from the Cloudformation service.
+// Every property of a restore job is create only, an update only stores new api keys.
+func Update(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	client, err := util.CreateMongoDBClient(*currentModel.ApiKeys.PublicKey, *currentModel.ApiKeys.PrivateKey)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	params, err := getParameterFromParameterStore(currentModel.RestoreJobCfnIdentifier, req.Session)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	job, _, err := getRestoreJob(client, *params.ProjectId, *params.ClusterName, *params.JobId)
+	if err != nil {
+		return handler.ProgressEvent{}, fmt.Errorf("error fetching restore job info (%s): %s", *params.JobId, err)
+	}
+
+	flattenRestoreJob(currentModel, job)
+
+	// putting required parameter into parameter store
+	// the api keys might have been updated therefore we need to do this here
+	_, err = putParameterIntoParameterStore(currentModel.RestoreJobCfnIdentifier, &ParameterToBePersistedSpec{ApiKeys: currentModel.ApiKeys, ProjectId: currentModel.ProjectId, ClusterName: currentModel.ClusterName, JobId: currentModel.JobId}, req.Session)
+	if err != nil {
+		return handler.ProgressEvent{}, fmt.Errorf("error when putting api keys into parameter store: %s", err)
+	}
+
+	return handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		Message:         "Update Complete",
+		ResourceModel:   currentModel,
+	}, nil
+}
+
+// Delete handles the Delete event from the Cloudformation service.
+// A restored cluster keeps its data, only download restores that are still open are cancelled.
+func Delete(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	client, err := util.CreateMongoDBClient(*currentModel.ApiKeys.PublicKey, *currentModel.ApiKeys.PrivateKey)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	params, err := getParameterFromParameterStore(currentModel.RestoreJobCfnIdentifier, req.Session)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	jobID := *params.JobId
+
+	err = cancelOpenDownload(client, *params.ProjectId, *params.ClusterName, jobID)
+	if err != nil {
+		// even when error occurs when deleting, we still want to delete parameter from parameter store
+		_, errParams := deleteParameterFromParameterStore(currentModel.RestoreJobCfnIdentifier, req.Session)
+		if errParams != nil {
+			return handler.ProgressEvent{
+				OperationStatus:  handler.Failed,
+				Message:          "Delete Failed",
+				HandlerErrorCode: "GeneralServiceException",
+			}, fmt.Errorf("Error cancelling restore job with id(%s): %s.\nError deleting api keys from parameter store: %s", jobID, err, errParams)
+		}
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          "Delete Failed",
+			HandlerErrorCode: "GeneralServiceException",
+		}, fmt.Errorf("error cancelling restore job with id (%s): %s", jobID, err)
+	}
+
+	_, err = deleteParameterFromParameterStore(currentModel.RestoreJobCfnIdentifier, req.Session)
+	if err != nil {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          "Delete Failed",
+			HandlerErrorCode: "GeneralServiceException",
+		}, fmt.Errorf("error deleting parameters for restore job %s: %s", jobID, err)
+	}
+
+	return handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		Message:         "Delete Complete",
+		ResourceModel:   currentModel,
+	}, nil
+}
+
+// List handles the List event from the Cloudformation service.
+func List(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	client, err := util.CreateMongoDBClient(*currentModel.ApiKeys.PublicKey, *currentModel.ApiKeys.PrivateKey)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	projectID := *currentModel.ProjectId
+	clusterName := *currentModel.ClusterName
+
+	const itemsPerPage = 100
+
+	models := make([]interface{}, 0)
+	for pageNum := 1; ; pageNum++ {
+		page, resp, err := listRestoreJobs(client, projectID, clusterName, pageNum, itemsPerPage)
+		if err != nil {
+			return handler.ProgressEvent{}, fmt.Errorf("error listing restore jobs for cluster (%s): %s", clusterName, err)
+		}
+
+		for i := range page.Results {
+			var model Model
+			model.ProjectId = currentModel.ProjectId
+			model.ClusterName = currentModel.ClusterName
+			flattenRestoreJob(&model, &page.Results[i])
+			cfnid := buildRestoreJobCfnIdentifier(model.ProjectId, model.ClusterName, model.JobId)
+			model.RestoreJobCfnIdentifier = &cfnid
+			models = append(models, model)
+		}
+
+		if len(page.Results) < itemsPerPage || resp.IsLastPage() {
+			break
+		}
+	}
+
+	return handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		Message:         "List Complete",
+		ResourceModels:  models,
+	}, nil
+}
+
+// validateRestoreJob checks that the model sets what its delivery type needs and nothing the delivery type ignores
+func validateRestoreJob(model *Model) error {
+	deliveryType := cast.ToString(model.DeliveryType)
+	hasPointInTime := model.PointInTimeUTCSeconds != nil
+	hasOplog := model.OplogTs != nil || model.OplogInc != nil
+
+	switch deliveryType {
+	case deliveryTypeAutomated, deliveryTypeDownload:
+		if model.SnapshotId == nil {
+			return fmt.Errorf("SnapshotId is required for %s restores", deliveryType)
+		}
+		if hasPointInTime || hasOplog {
+			return fmt.Errorf("PointInTimeUTCSeconds, OplogTs and OplogInc are only valid for %s restores", deliveryTypePointInTime)
+		}
+	case deliveryTypePointInTime:
+		if model.SnapshotId != nil {
+			return fmt.Errorf("SnapshotId is not valid for %s restores", deliveryTypePointInTime)
+		}
+		if hasPointInTime == hasOplog {
+			return fmt.Errorf("either PointInTimeUTCSeconds or OplogTs and OplogInc are required for %s restores", deliveryTypePointInTime)
+		}
+		if hasOplog && (model.OplogTs == nil || model.OplogInc == nil) {
+			return fmt.Errorf("OplogTs and OplogInc must be set together")
+		}
+	}
+
+	if deliveryType == deliveryTypeDownload {
+		if model.TargetClusterName != nil || model.TargetProjectId != nil {
+			return fmt.Errorf("TargetClusterName and TargetProjectId are not valid for %s restores", deliveryTypeDownload)
+		}
+	} else if model.TargetClusterName == nil {
+		return fmt.Errorf("TargetClusterName is required for %s restores", deliveryType)
+	}
+	return nil
+}
+
+func expandRestoreJob(model *Model) *restoreJob {
+	job := &restoreJob{
+		DeliveryType: cast.ToString(model.DeliveryType),
+		SnapshotID:   cast.ToString(model.SnapshotId),
+	}
+
+	if model.TargetClusterName != nil {
+		job.TargetClusterName = *model.TargetClusterName
+		job.TargetGroupID = *model.ProjectId
+		if model.TargetProjectId != nil {
+			job.TargetGroupID = *model.TargetProjectId
+		}
+	}
+
+	if model.PointInTimeUTCSeconds != nil {
+		job.PointInTimeUTCSeconds = cast.ToInt64(*model.PointInTimeUTCSeconds)
+	}
+	if model.OplogTs != nil {
+		job.OplogTs = cast.ToInt64(*model.OplogTs)
+	}
+	if model.OplogInc != nil {
+		job.OplogInc = cast.ToInt64(*model.OplogInc)
+	}
+
+	return job
+}
+
+func flattenRestoreJob(currentModel *Model, job *restoreJob) {
+	currentModel.JobId = &job.ID
+	currentModel.DeliveryType = &job.DeliveryType
+
+	currentModel.SnapshotId = nil
+	if job.SnapshotID != "" {
+		currentModel.SnapshotId = &job.SnapshotID
+	}
+
+	currentModel.TargetClusterName = nil
+	if job.TargetClusterName != "" {
+		currentModel.TargetClusterName = &job.TargetClusterName
+	}
+	// the target project defaults to the project of the source cluster, only report it back when it differs or the
+	// template spells it out
+	if job.TargetGroupID != "" && (currentModel.TargetProjectId != nil || currentModel.ProjectId == nil || job.TargetGroupID != *currentModel.ProjectId) {
+		currentModel.TargetProjectId = &job.TargetGroupID
+	} else {
+		currentModel.TargetProjectId = nil
+	}
+
+	currentModel.PointInTimeUTCSeconds = nil
+	if job.PointInTimeUTCSeconds != 0 {
+		pointInTime := cast.ToInt(job.PointInTimeUTCSeconds)
+		currentModel.PointInTimeUTCSeconds = &pointInTime
+	}
+	currentModel.OplogTs = nil
+	currentModel.OplogInc = nil
+	if job.OplogTs != 0 {
+		oplogTs := cast.ToInt(job.OplogTs)
+		oplogInc := cast.ToInt(job.OplogInc)
+		currentModel.OplogTs = &oplogTs
+		currentModel.OplogInc = &oplogInc
+	}
+
+	currentModel.DeliveryUrl = job.DeliveryURL
+	currentModel.Timestamp = stringOrNil(job.Timestamp)
+	currentModel.CreatedAt = stringOrNil(job.CreatedAt)
+	currentModel.FinishedAt = stringOrNil(job.FinishedAt)
+	currentModel.ExpiresAt = stringOrNil(job.ExpiresAt)
+}
+
+// restoreJobState tells where the job stands. Download restores are complete once Atlas has the download urls,
+// the other delivery types once the data is restored into the target cluster.
+func restoreJobState(job *restoreJob) string {
+	switch {
+	case job.Failed:
+		return restoreJobFailed
+	case job.Cancelled:
+		return restoreJobCancelled
+	case job.FinishedAt != "":
+		return restoreJobCompleted
+	case job.DeliveryType == deliveryTypeDownload && len(job.DeliveryURL) > 0:
+		return restoreJobCompleted
+	case job.Expired:
+		return restoreJobExpired
+	}
+	return restoreJobInProgress
+}
+
+// defaultStabilizationTimeoutMinutes is how long the callbacks poll a restore job when StabilizationTimeoutMinutes is not set
+const defaultStabilizationTimeoutMinutes = 180
+
+func validateProgress(client *mongodbatlas.Client, req handler.Request, currentModel *Model) (handler.ProgressEvent, error) {
+	jobID := *currentModel.JobId
+
+	job, _, err := getRestoreJob(client, *currentModel.ProjectId, *currentModel.ClusterName, jobID)
+	if err != nil {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          fmt.Sprintf("error waiting for restore job (%s) to complete: %s", jobID, err),
+			HandlerErrorCode: "GeneralServiceException",
+		}, nil
+	}
+
+	return progressOfRestoreJob(req, currentModel, job), nil
+}
+
+// progressOfRestoreJob turns the polled job into the next progress event, a job that failed, was cancelled or
+// expired fails the operation, as does waiting longer than the stabilization timeout
+func progressOfRestoreJob(req handler.Request, currentModel *Model, job *restoreJob) handler.ProgressEvent {
+	jobID := *currentModel.JobId
+
+	startTime := time.Now().UTC()
+	if started, ok := req.CallbackContext["startTime"]; ok {
+		if t, err := time.Parse(time.RFC3339, cast.ToString(started)); err == nil {
+			startTime = t
+		}
+	}
+	pollCount := cast.ToInt(req.CallbackContext["pollCount"]) + 1
+
+	state := restoreJobState(job)
+	switch state {
+	case restoreJobCompleted:
+		flattenRestoreJob(currentModel, job)
+		p := handler.NewProgressEvent()
+		p.ResourceModel = currentModel
+		p.OperationStatus = handler.Success
+		p.Message = "Complete"
+		return p
+	case restoreJobInProgress:
+	default:
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          fmt.Sprintf("restore job (%s) of cluster (%s) did not complete: %s", jobID, *currentModel.ClusterName, state),
+			HandlerErrorCode: "NotStabilized",
+		}
+	}
+
+	timeout := stabilizationTimeout(currentModel)
+	if time.Since(startTime) > timeout {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          fmt.Sprintf("restore job (%s) of cluster (%s) did not complete within %s, still %s after %d polls", jobID, *currentModel.ClusterName, timeout, state, pollCount),
+			HandlerErrorCode: "NotStabilized",
+		}
+	}
+
+	p := handler.NewProgressEvent()
+	p.ResourceModel = currentModel
+	p.OperationStatus = handler.InProgress
+	p.CallbackDelaySeconds = 60
+	p.Message = "Pending"
+	p.CallbackContext = map[string]interface{}{
+		"restoreJobState": state,
+		"startTime":       startTime.Format(time.RFC3339),
+		"pollCount":       pollCount,
+	}
+	return p
+}
+
+func stabilizationTimeout(currentModel *Model) time.Duration {
+	if currentModel.StabilizationTimeoutMinutes != nil && *currentModel.StabilizationTimeoutMinutes > 0 {
+		return time.Duration(*currentModel.StabilizationTimeoutMinutes) * time.Minute
+	}
+	return time.Duration(defaultStabilizationTimeoutMinutes) * time.Minute
+}
+
+// cancelOpenDownload cancels the job when it is a download restore whose urls Atlas still serves
+func cancelOpenDownload(client *mongodbatlas.Client, projectID, clusterName, jobID string) error {
+	job, resp, err := getRestoreJob(client, projectID, clusterName, jobID)
+	if err != nil {
+		if resp != nil && resp.StatusCode == 404 {
+			return nil
+		}
+		return err
+	}
+
+	if job.DeliveryType != deliveryTypeDownload || job.Cancelled || job.Expired || job.Failed {
+		return nil
+	}
+
+	resp, err = cancelRestoreJob(client, projectID, clusterName, jobID)
+	if err != nil && (resp == nil || resp.StatusCode != 404) {
+		return err
+	}
+	return nil
+}
+
+func stringOrNil(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+type ParameterToBePersistedSpec struct {
+	ApiKeys     *ApiKeyDefinition
+	ProjectId   *string
+	ClusterName *string
+	JobId       *string
+}
+
+func putParameterIntoParameterStore(resourcePrimaryIdentifier *string, params *ParameterToBePersistedSpec, session *session.Session) (*ssm.PutParameterOutput, error) {
+	ssmClient, err := util.CreateSSMClient(session)
+	if err != nil {
+		return nil, err
+	}
+	// transform api keys to json string
+	parameterName := buildApiKeyParameterName(*resourcePrimaryIdentifier)
+	byteParams, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	stringifiedParams := string(byteParams)
+	parameterType := "SecureString"
+	overwrite := true
+	putParamOutput, err := ssmClient.PutParameter(&ssm.PutParameterInput{Name: &parameterName, Value: &stringifiedParams, Type: &parameterType, Overwrite: &overwrite})
+	if err != nil {
+		return nil, err
+	}
+
+	return putParamOutput, nil
+}
+
+func deleteParameterFromParameterStore(resourcePrimaryIdentifier *string, session *session.Session) (*ssm.DeleteParameterOutput, error) {
+	ssmClient, err := util.CreateSSMClient(session)
+	if err != nil {
+		return nil, err
+	}
+	parameterName := buildApiKeyParameterName(*resourcePrimaryIdentifier)
+
+	deleteParamOutput, err := ssmClient.DeleteParameter(&ssm.DeleteParameterInput{Name: &parameterName})
+	if err != nil {
+		return nil, err
+	}
+
+	return deleteParamOutput, nil
+}
+
+func getParameterFromParameterStore(resourcePrimaryIdentifier *string, session *session.Session) (*ParameterToBePersistedSpec, error) {
+	ssmClient, err := util.CreateSSMClient(session)
+	if err != nil {
+		return nil, err
+	}
+	parameterName := buildApiKeyParameterName(*resourcePrimaryIdentifier)
+	decrypt := true
+	getParamOutput, err := ssmClient.GetParameter(&ssm.GetParameterInput{Name: &parameterName, WithDecryption: &decrypt})
+	if err != nil {
+		return nil, err
+	}
+
+	var params ParameterToBePersistedSpec
+	err = json.Unmarshal([]byte(*getParamOutput.Parameter.Value), &params)
+	if err != nil {
+		return nil, err
+	}
+	return &params, nil
+}
+
+func buildRestoreJobCfnIdentifier(projectId *string, clusterName *string, jobId *string) string {
+	return fmt.Sprintf("%s-%s-%s", *projectId, *clusterName, *jobId)
+}
+
+func buildApiKeyParameterName(resourcePrimaryIdentifier string) string {
+	// this is strictly coupled with permissions for handlers, changing this means changing permissions in handler
+	// moreover changing this might cause polution in parameter store -  be sure you know what you are doing
+	parameterStorePrefix := "mongodbstpatlasv1cloudbackupsnapshotrestorejob"
+	return fmt.Sprintf("%s-%s", parameterStorePrefix, resourcePrimaryIdentifier)
+}
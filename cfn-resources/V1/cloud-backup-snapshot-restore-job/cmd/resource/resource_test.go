@@ -0,0 +1,165 @@
+package resource
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
+)
+
+// restoreJobModel returns a model of the delivery type, the snapshot and the target cluster are left out when empty
+func restoreJobModel(deliveryType, snapshotID, targetClusterName string) *Model {
+	model := &Model{DeliveryType: &deliveryType}
+	if snapshotID != "" {
+		model.SnapshotId = &snapshotID
+	}
+	if targetClusterName != "" {
+		model.TargetClusterName = &targetClusterName
+	}
+	return model
+}
+
+// pointInTime returns a point in time restore into staging, up to a timestamp or an oplog entry when set
+func pointInTime(pointInTimeUTCSeconds, oplogTs, oplogInc int) *Model {
+	model := restoreJobModel(deliveryTypePointInTime, "", "staging")
+	if pointInTimeUTCSeconds != 0 {
+		model.PointInTimeUTCSeconds = &pointInTimeUTCSeconds
+	}
+	if oplogTs != 0 {
+		model.OplogTs = &oplogTs
+	}
+	if oplogInc != 0 {
+		model.OplogInc = &oplogInc
+	}
+	return model
+}
+
+func TestValidateRestoreJob(t *testing.T) {
+	targetProjectID := "p"
+	automatedIntoProject := restoreJobModel(deliveryTypeAutomated, "s", "staging")
+	automatedIntoProject.TargetProjectId = &targetProjectID
+	pointInTimeUTCSeconds := 1617000000
+	automatedWithPointInTime := restoreJobModel(deliveryTypeAutomated, "s", "staging")
+	automatedWithPointInTime.PointInTimeUTCSeconds = &pointInTimeUTCSeconds
+	snapshotID := "s"
+	pointInTimeWithSnapshot := pointInTime(1617000000, 0, 0)
+	pointInTimeWithSnapshot.SnapshotId = &snapshotID
+
+	testCases := []struct {
+		name    string
+		model   *Model
+		wantErr bool
+	}{
+		{"automated", restoreJobModel(deliveryTypeAutomated, "s", "staging"), false},
+		{"automated into another project", automatedIntoProject, false},
+		{"download", restoreJobModel(deliveryTypeDownload, "s", ""), false},
+		{"point in time", pointInTime(1617000000, 0, 0), false},
+		{"point in time from oplog", pointInTime(0, 1617000000, 3), false},
+		{"automated without snapshot", restoreJobModel(deliveryTypeAutomated, "", "staging"), true},
+		{"automated without target", restoreJobModel(deliveryTypeAutomated, "s", ""), true},
+		{"automated with point in time", automatedWithPointInTime, true},
+		{"download with target", restoreJobModel(deliveryTypeDownload, "s", "staging"), true},
+		{"point in time with snapshot", pointInTimeWithSnapshot, true},
+		{"point in time without timestamp", pointInTime(0, 0, 0), true},
+		{"point in time with both timestamps", pointInTime(1617000000, 1617000000, 3), true},
+		{"point in time without oplog increment", pointInTime(0, 1617000000, 0), true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateRestoreJob(tc.model)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("want error %t, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestRestoreJobState(t *testing.T) {
+	testCases := []struct {
+		name string
+		job  restoreJob
+		want string
+	}{
+		{"automated running", restoreJob{DeliveryType: deliveryTypeAutomated}, restoreJobInProgress},
+		{"automated finished", restoreJob{DeliveryType: deliveryTypeAutomated, FinishedAt: "2021-04-01T10:00:00Z"}, restoreJobCompleted},
+		{"automated failed", restoreJob{DeliveryType: deliveryTypeAutomated, Failed: true}, restoreJobFailed},
+		{"download preparing", restoreJob{DeliveryType: deliveryTypeDownload}, restoreJobInProgress},
+		{"download ready", restoreJob{DeliveryType: deliveryTypeDownload, DeliveryURL: []string{"https://restore.example.com/a.tar.gz"}}, restoreJobCompleted},
+		{"download cancelled", restoreJob{DeliveryType: deliveryTypeDownload, Cancelled: true}, restoreJobCancelled},
+		{"download expired", restoreJob{DeliveryType: deliveryTypeDownload, Expired: true}, restoreJobExpired},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := restoreJobState(&tc.job); got != tc.want {
+				t.Errorf("want %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestFlattenRestoreJobOmitsDefaultTargetProject(t *testing.T) {
+	projectID := "p"
+	model := restoreJobModel(deliveryTypeAutomated, "s", "staging")
+	model.ProjectId = &projectID
+
+	flattenRestoreJob(model, &restoreJob{ID: "j", DeliveryType: "automated", SnapshotID: "s", TargetClusterName: "staging", TargetGroupID: "p"})
+
+	if model.TargetProjectId != nil {
+		t.Errorf("want the default target project left out, got %s", *model.TargetProjectId)
+	}
+
+	flattenRestoreJob(model, &restoreJob{ID: "j", DeliveryType: "automated", SnapshotID: "s", TargetClusterName: "staging", TargetGroupID: "other"})
+
+	if model.TargetProjectId == nil || *model.TargetProjectId != "other" {
+		t.Errorf("want the target project of another project, got %v", model.TargetProjectId)
+	}
+}
+
+func TestProgressOfRestoreJob(t *testing.T) {
+	jobID, clusterName := "j", "production"
+	timeoutMinutes := 15
+	startedLongAgo := time.Now().UTC().Add(-4 * time.Hour).Format(time.RFC3339)
+	startedRecently := time.Now().UTC().Add(-20 * time.Minute).Format(time.RFC3339)
+	running := restoreJob{DeliveryType: deliveryTypeAutomated}
+
+	testCases := []struct {
+		name           string
+		timeoutMinutes *int
+		context        map[string]interface{}
+		job            restoreJob
+		wantStatus     handler.Status
+		wantCode       string
+	}{
+		{"completed", nil, map[string]interface{}{}, restoreJob{DeliveryType: deliveryTypeAutomated, FinishedAt: "2021-04-01T10:00:00Z"}, handler.Success, ""},
+		{"first poll", nil, map[string]interface{}{}, running, handler.InProgress, ""},
+		{"failed", nil, map[string]interface{}{}, restoreJob{DeliveryType: deliveryTypeAutomated, Failed: true}, handler.Failed, "NotStabilized"},
+		{"default timeout exceeded", nil, map[string]interface{}{"startTime": startedLongAgo}, running, handler.Failed, "NotStabilized"},
+		{"within the default timeout", nil, map[string]interface{}{"startTime": startedRecently}, running, handler.InProgress, ""},
+		{"custom timeout exceeded", &timeoutMinutes, map[string]interface{}{"startTime": startedRecently}, running, handler.Failed, "NotStabilized"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			model := &Model{JobId: &jobID, ClusterName: &clusterName, StabilizationTimeoutMinutes: tc.timeoutMinutes}
+			p := progressOfRestoreJob(handler.Request{CallbackContext: tc.context}, model, &tc.job)
+
+			if p.OperationStatus != tc.wantStatus || p.HandlerErrorCode != tc.wantCode {
+				t.Errorf("want %s %s, got %s %s: %s", tc.wantStatus, tc.wantCode, p.OperationStatus, p.HandlerErrorCode, p.Message)
+			}
+		})
+	}
+}
+
+func TestProgressOfRestoreJobKeepsStartTime(t *testing.T) {
+	jobID, clusterName := "j", "production"
+	started := time.Now().UTC().Add(-10 * time.Minute).Format(time.RFC3339)
+	req := handler.Request{CallbackContext: map[string]interface{}{"restoreJobState": restoreJobInProgress, "startTime": started, "pollCount": 3}}
+
+	p := progressOfRestoreJob(req, &Model{JobId: &jobID, ClusterName: &clusterName}, &restoreJob{DeliveryType: deliveryTypeDownload})
+
+	if p.CallbackContext["startTime"] != started || p.CallbackContext["pollCount"] != 4 {
+		t.Errorf("want the start time kept and the poll counted, got %v", p.CallbackContext)
+	}
+}
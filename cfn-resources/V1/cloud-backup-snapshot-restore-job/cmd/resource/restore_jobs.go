@@ -0,0 +1,102 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"go.mongodb.org/atlas/mongodbatlas"
+)
+
+// the restore job type of the mongodbatlas client does not tell whether the job failed, the endpoints are called
+// directly instead, paths are resolved relative to the client base url (https://cloud.mongodb.com/api/atlas/v1.0/)
+const restoreJobsPath = "groups/%s/clusters/%s/backup/restoreJobs"
+
+type restoreJob struct {
+	ID                    string   `json:"id,omitempty"`
+	SnapshotID            string   `json:"snapshotId,omitempty"`
+	DeliveryType          string   `json:"deliveryType,omitempty"`
+	DeliveryURL           []string `json:"deliveryUrl,omitempty"`
+	TargetClusterName     string   `json:"targetClusterName,omitempty"`
+	TargetGroupID         string   `json:"targetGroupId,omitempty"`
+	Cancelled             bool     `json:"cancelled,omitempty"`
+	Expired               bool     `json:"expired,omitempty"`
+	Failed                bool     `json:"failed,omitempty"`
+	CreatedAt             string   `json:"createdAt,omitempty"`
+	ExpiresAt             string   `json:"expiresAt,omitempty"`
+	FinishedAt            string   `json:"finishedAt,omitempty"`
+	Timestamp             string   `json:"timestamp,omitempty"`
+	OplogTs               int64    `json:"oplogTs,omitempty"`
+	OplogInc              int64    `json:"oplogInc,omitempty"`
+	PointInTimeUTCSeconds int64    `json:"pointInTimeUTCSeconds,omitempty"`
+}
+
+type restoreJobsResponse struct {
+	Links      []*mongodbatlas.Link `json:"links,omitempty"`
+	Results    []restoreJob         `json:"results,omitempty"`
+	TotalCount int                  `json:"totalCount,omitempty"`
+}
+
+func restoreJobPath(projectID, clusterName, jobID string) string {
+	return fmt.Sprintf("%s/%s", fmt.Sprintf(restoreJobsPath, projectID, url.PathEscape(clusterName)), url.PathEscape(jobID))
+}
+
+func getRestoreJob(client *mongodbatlas.Client, projectID, clusterName, jobID string) (*restoreJob, *mongodbatlas.Response, error) {
+	return doRestoreJob(client, http.MethodGet, restoreJobPath(projectID, clusterName, jobID), nil)
+}
+
+func createRestoreJob(client *mongodbatlas.Client, projectID, clusterName string, job *restoreJob) (*restoreJob, *mongodbatlas.Response, error) {
+	return doRestoreJob(client, http.MethodPost, fmt.Sprintf(restoreJobsPath, projectID, url.PathEscape(clusterName)), job)
+}
+
+// cancelRestoreJob cancels a download restore job, Atlas does not cancel the other delivery types
+func cancelRestoreJob(client *mongodbatlas.Client, projectID, clusterName, jobID string) (*mongodbatlas.Response, error) {
+	req, err := client.NewRequest(context.Background(), http.MethodDelete, restoreJobPath(projectID, clusterName, jobID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.Do(context.Background(), req, nil)
+}
+
+func listRestoreJobs(client *mongodbatlas.Client, projectID, clusterName string, pageNum, itemsPerPage int) (*restoreJobsResponse, *mongodbatlas.Response, error) {
+	path := fmt.Sprintf("%s?pageNum=%d&itemsPerPage=%d", fmt.Sprintf(restoreJobsPath, projectID, url.PathEscape(clusterName)), pageNum, itemsPerPage)
+
+	req, err := client.NewRequest(context.Background(), http.MethodGet, path, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	root := new(restoreJobsResponse)
+	resp, err := client.Do(context.Background(), req, root)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	if l := root.Links; l != nil {
+		resp.Links = l
+	}
+
+	return root, resp, nil
+}
+
+func doRestoreJob(client *mongodbatlas.Client, method, path string, body *restoreJob) (*restoreJob, *mongodbatlas.Response, error) {
+	var reqBody interface{}
+	if body != nil {
+		reqBody = body
+	}
+
+	req, err := client.NewRequest(context.Background(), method, path, reqBody)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	root := new(restoreJob)
+	resp, err := client.Do(context.Background(), req, root)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return root, resp, nil
+}
@@ -4,33 +4,41 @@ package resource
 
 // Model is autogenerated from the json schema
 type Model struct {
-	ApiKeys                  *ApiKeyDefinition `json:",omitempty"`
-	AutoScaling              *AutoScaling      `json:",omitempty"`
-	ClusterCfnIdentifier     *string           `json:",omitempty"`
-	BackupEnabled            *bool             `json:",omitempty"`
-	BiConnector              *BiConnector      `json:",omitempty"`
-	ClusterType              *string           `json:",omitempty"`
-	SrvConnectionString      *string           `json:",omitempty"`
-	ConnectionString         *string           `json:",omitempty"`
-	DiskSizeGB               *float64          `json:",omitempty"`
-	EncryptionAtRestProvider *string           `json:",omitempty"`
-	ProjectId                *string           `json:",omitempty"`
-	Id                       *string           `json:",omitempty"`
-	Labels                   []Labels          `json:",omitempty"`
-	MongoDBVersion           *string           `json:",omitempty"`
-	MongoDBMajorVersion      *string           `json:",omitempty"`
-	MongoURI                 *string           `json:",omitempty"`
-	MongoURIUpdated          *string           `json:",omitempty"`
-	MongoURIWithOptions      *string           `json:",omitempty"`
-	Name                     *string           `json:",omitempty"`
-	NumShards                *int              `json:",omitempty"`
-	Paused                   *bool             `json:",omitempty"`
-	PitEnabled               *bool             `json:",omitempty"`
-	ProviderBackupEnabled    *bool             `json:",omitempty"`
-	ProviderSettings         *ProviderSettings `json:",omitempty"`
-	ReplicationSpecs         []ReplicationSpec `json:",omitempty"`
-	SrvAddress               *string           `json:",omitempty"`
-	StateName                *string           `json:",omitempty"`
+	ApiKeys                          *ApiKeyDefinition                 `json:",omitempty"`
+	AdvancedConfiguration            *AdvancedConfiguration            `json:",omitempty"`
+	AutoScaling                      *AutoScaling                      `json:",omitempty"`
+	ClusterCfnIdentifier             *string                           `json:",omitempty"`
+	BackupEnabled                    *bool                             `json:",omitempty"`
+	BiConnector                      *BiConnector                      `json:",omitempty"`
+	ClusterType                      *string                           `json:",omitempty"`
+	SrvConnectionString              *string                           `json:",omitempty"`
+	ConnectionString                 *string                           `json:",omitempty"`
+	PrivateConnectionString          *string                           `json:",omitempty"`
+	PrivateSrvConnectionString       *string                           `json:",omitempty"`
+	PrivateEndpointConnectionStrings []PrivateEndpointConnectionString `json:",omitempty"`
+	DiskSizeGB                       *float64                          `json:",omitempty"`
+	EncryptionAtRestProvider         *string                           `json:",omitempty"`
+	ProjectId                        *string                           `json:",omitempty"`
+	Id                               *string                           `json:",omitempty"`
+	Labels                           []Labels                          `json:",omitempty"`
+	MongoDBVersion                   *string                           `json:",omitempty"`
+	MongoDBMajorVersion              *string                           `json:",omitempty"`
+	MongoURI                         *string                           `json:",omitempty"`
+	MongoURIUpdated                  *string                           `json:",omitempty"`
+	MongoURIWithOptions              *string                           `json:",omitempty"`
+	Name                             *string                           `json:",omitempty"`
+	NumShards                        *int                              `json:",omitempty"`
+	Paused                           *bool                             `json:",omitempty"`
+	PitEnabled                       *bool                             `json:",omitempty"`
+	ProviderBackupEnabled            *bool                             `json:",omitempty"`
+	ProviderSettings                 *ProviderSettings                 `json:",omitempty"`
+	ReplicationSpecs                 []ReplicationSpec                 `json:",omitempty"`
+	SrvAddress                       *string                           `json:",omitempty"`
+	FinalSnapshot                    *FinalSnapshot                    `json:",omitempty"`
+	TerminationProtectionEnabled     *bool                             `json:",omitempty"`
+	FailoverTrigger                  *string                           `json:",omitempty"`
+	StabilizationTimeoutMinutes      *int                              `json:",omitempty"`
+	StateName                        *string                           `json:",omitempty"`
 }
 
 // ApiKeyDefinition is autogenerated from the json schema
@@ -39,6 +47,18 @@ type ApiKeyDefinition struct {
 	PrivateKey *string `json:",omitempty"`
 }
 
+// AdvancedConfiguration is autogenerated from the json schema
+type AdvancedConfiguration struct {
+	JavascriptEnabled         *bool    `json:",omitempty"`
+	MinimumEnabledTlsProtocol *string  `json:",omitempty"`
+	NoTableScan               *bool    `json:",omitempty"`
+	OplogSizeMB               *int     `json:",omitempty"`
+	OplogMinRetentionHours    *float64 `json:",omitempty"`
+	SampleSizeBIConnector     *int     `json:",omitempty"`
+	DefaultReadConcern        *string  `json:",omitempty"`
+	DefaultWriteConcern       *string  `json:",omitempty"`
+}
+
 // AutoScaling is autogenerated from the json schema
 type AutoScaling struct {
 	Compute       *AutoScalingCompute `json:",omitempty"`
@@ -57,6 +77,21 @@ type BiConnector struct {
 	Enabled        *bool   `json:",omitempty"`
 }
 
+// PrivateEndpointConnectionString is autogenerated from the json schema
+type PrivateEndpointConnectionString struct {
+	ConnectionString    *string           `json:",omitempty"`
+	SrvConnectionString *string           `json:",omitempty"`
+	Type                *string           `json:",omitempty"`
+	Endpoints           []PrivateEndpoint `json:",omitempty"`
+}
+
+// PrivateEndpoint is autogenerated from the json schema
+type PrivateEndpoint struct {
+	EndpointId   *string `json:",omitempty"`
+	ProviderName *string `json:",omitempty"`
+	Region       *string `json:",omitempty"`
+}
+
 // Labels is autogenerated from the json schema
 type Labels struct {
 	Key   *string `json:",omitempty"`
@@ -102,3 +137,9 @@ type RegionConfig struct {
 	Priority       *int    `json:",omitempty"`
 	ReadOnlyNodes  *int    `json:",omitempty"`
 }
+
+// FinalSnapshot is autogenerated from the json schema
+type FinalSnapshot struct {
+	Description     *string `json:",omitempty"`
+	RetentionInDays *int    `json:",omitempty"`
+}
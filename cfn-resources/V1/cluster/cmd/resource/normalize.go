@@ -0,0 +1,498 @@
+package resource
+
+import (
+	"reflect"
+	"sort"
+
+	"go.mongodb.org/atlas/mongodbatlas"
+)
+
+// flattenCluster maps everything Atlas reports about a cluster onto a model. Empty values are left unset, and
+// regions and labels are sorted so the result does not depend on the order Atlas returns them in.
+func flattenCluster(cluster *mongodbatlas.Cluster) *Model {
+	model := &Model{
+		BackupEnabled:            cluster.BackupEnabled,
+		ClusterType:              stringOrNil(cluster.ClusterType),
+		DiskSizeGB:               cluster.DiskSizeGB,
+		EncryptionAtRestProvider: stringOrNil(cluster.EncryptionAtRestProvider),
+		Id:                       stringOrNil(cluster.ID),
+		MongoDBVersion:           stringOrNil(cluster.MongoDBVersion),
+		MongoURI:                 stringOrNil(cluster.MongoURI),
+		MongoURIUpdated:          stringOrNil(cluster.MongoURIUpdated),
+		MongoURIWithOptions:      stringOrNil(cluster.MongoURIWithOptions),
+		Name:                     stringOrNil(cluster.Name),
+		ProjectId:                stringOrNil(cluster.GroupID),
+		Paused:                   cluster.Paused,
+		PitEnabled:               cluster.PitEnabled,
+		ProviderBackupEnabled:    cluster.ProviderBackupEnabled,
+		SrvAddress:               stringOrNil(cluster.SrvAddress),
+		StateName:                stringOrNil(cluster.StateName),
+	}
+
+	if cluster.MongoDBMajorVersion != "" {
+		version := formatMongoDBMajorVersion(cluster.MongoDBMajorVersion)
+		model.MongoDBMajorVersion = &version
+	}
+
+	if cluster.NumShards != nil {
+		model.NumShards = castNO64(cluster.NumShards)
+	}
+
+	if cluster.ConnectionStrings != nil {
+		model.ConnectionString = stringOrNil(cluster.ConnectionStrings.Standard)
+		model.SrvConnectionString = stringOrNil(cluster.ConnectionStrings.StandardSrv)
+		model.PrivateConnectionString = stringOrNil(cluster.ConnectionStrings.Private)
+		model.PrivateSrvConnectionString = stringOrNil(cluster.ConnectionStrings.PrivateSrv)
+		model.PrivateEndpointConnectionStrings = flattenPrivateEndpoints(cluster.ConnectionStrings.PrivateEndpoint)
+	}
+
+	model.AutoScaling = flattenAutoScaling(cluster.AutoScaling)
+	model.BiConnector = flattenBiConnector(cluster.BiConnector)
+	model.ProviderSettings = flattenProviderSettings(cluster.ProviderSettings)
+	model.ReplicationSpecs = flattenReplicationSpecs(cluster.ReplicationSpecs)
+	model.Labels = flattenLabels(cluster.Labels)
+
+	return model
+}
+
+// normalizeCluster turns the flattened Atlas state into what Read reports for prior, the model CloudFormation sent.
+// Settings prior leaves unset are omitted when Atlas reports its default for them, anything else Atlas reports is kept
+// so drift and the settings of an imported cluster show. A MongoDBMajorVersion Atlas formats differently keeps the
+// spelling of prior, and regions and labels follow the order of prior when they hold the same entries. Read only
+// properties always come from Atlas, and the settings Atlas does not know about come from prior.
+func normalizeCluster(prior *Model, actual *Model) *Model {
+	model := *actual
+
+	model.ReplicationSpecs = make([]ReplicationSpec, len(actual.ReplicationSpecs))
+	copy(model.ReplicationSpecs, actual.ReplicationSpecs)
+	for i := range model.ReplicationSpecs {
+		// Atlas assigns the spec ids, they are only reported when the template refers to them
+		if i >= len(prior.ReplicationSpecs) || prior.ReplicationSpecs[i].ID == nil {
+			model.ReplicationSpecs[i].ID = nil
+		}
+		if i < len(prior.ReplicationSpecs) {
+			model.ReplicationSpecs[i].RegionsConfig = orderRegionsLike(prior.ReplicationSpecs[i].RegionsConfig, model.ReplicationSpecs[i].RegionsConfig)
+		}
+	}
+	if actual.ReplicationSpecs == nil {
+		model.ReplicationSpecs = nil
+	}
+	model.Labels = orderLabelsLike(prior.Labels, model.Labels)
+
+	clearDefaults(reflect.ValueOf(prior).Elem(), reflect.ValueOf(&model).Elem(), reflect.ValueOf(atlasDefaults(&model)).Elem())
+
+	if prior.MongoDBMajorVersion != nil && model.MongoDBMajorVersion != nil &&
+		formatMongoDBMajorVersion(*prior.MongoDBMajorVersion) == *model.MongoDBMajorVersion {
+		model.MongoDBMajorVersion = prior.MongoDBMajorVersion
+	}
+
+	model.ClusterCfnIdentifier = prior.ClusterCfnIdentifier
+	model.SrvConnectionString = actual.SrvConnectionString
+	model.ConnectionString = actual.ConnectionString
+	model.PrivateConnectionString = actual.PrivateConnectionString
+	model.PrivateSrvConnectionString = actual.PrivateSrvConnectionString
+	model.PrivateEndpointConnectionStrings = actual.PrivateEndpointConnectionStrings
+	model.Id = actual.Id
+	model.MongoDBVersion = actual.MongoDBVersion
+	model.MongoURI = actual.MongoURI
+	model.MongoURIUpdated = actual.MongoURIUpdated
+	model.MongoURIWithOptions = actual.MongoURIWithOptions
+	model.ProjectId = actual.ProjectId
+	model.Name = actual.Name
+	model.SrvAddress = actual.SrvAddress
+	model.StateName = actual.StateName
+
+	model.ApiKeys = prior.ApiKeys
+	model.FinalSnapshot = prior.FinalSnapshot
+	model.TerminationProtectionEnabled = prior.TerminationProtectionEnabled
+	model.FailoverTrigger = prior.FailoverTrigger
+	model.StabilizationTimeoutMinutes = prior.StabilizationTimeoutMinutes
+
+	return &model
+}
+
+// atlasDefaults returns what Atlas reports for the settings a template leaves unset. The replication specs follow
+// the shape of actual, the first region of the first spec defaults to the single region Atlas derives from the
+// provider settings.
+func atlasDefaults(actual *Model) *Model {
+	yes, no := true, false
+	replicaSet, encryptionNone, standardVolume, secondary := "REPLICASET", "NONE", "STANDARD", "secondary"
+	zoneName, tls12, readConcern, writeConcern := "Zone 1", "TLS1_2", "available", "1"
+	oneShard, priority, electableNodes, noNodes := 1, 7, 3, 0
+
+	numShards := actual.NumShards
+	if numShards == nil {
+		numShards = &oneShard
+	}
+	var regionName *string
+	if actual.ProviderSettings != nil {
+		regionName = actual.ProviderSettings.RegionName
+	}
+
+	specs := make([]ReplicationSpec, len(actual.ReplicationSpecs))
+	for i, spec := range actual.ReplicationSpecs {
+		specs[i].RegionsConfig = make([]RegionConfig, len(spec.RegionsConfig))
+		for j := range spec.RegionsConfig {
+			specs[i].RegionsConfig[j] = RegionConfig{AnalyticsNodes: &noNodes, ReadOnlyNodes: &noNodes}
+		}
+	}
+	if len(specs) > 0 {
+		specs[0].NumShards = numShards
+		specs[0].ZoneName = &zoneName
+		if len(specs[0].RegionsConfig) > 0 {
+			specs[0].RegionsConfig[0].RegionName = regionName
+			specs[0].RegionsConfig[0].Priority = &priority
+			specs[0].RegionsConfig[0].ElectableNodes = &electableNodes
+		}
+	}
+
+	return &Model{
+		BackupEnabled:            &no,
+		ClusterType:              &replicaSet,
+		EncryptionAtRestProvider: &encryptionNone,
+		NumShards:                &oneShard,
+		Paused:                   &no,
+		PitEnabled:               &no,
+		ProviderBackupEnabled:    &no,
+		AutoScaling: &AutoScaling{
+			DiskGBEnabled: &yes,
+			Compute:       &AutoScalingCompute{Enabled: &no, ScaleDownEnabled: &no},
+		},
+		BiConnector: &BiConnector{Enabled: &no, ReadPreference: &secondary},
+		ProviderSettings: &ProviderSettings{
+			EncryptEBSVolume: &yes,
+			VolumeType:       &standardVolume,
+		},
+		ReplicationSpecs: specs,
+		AdvancedConfiguration: &AdvancedConfiguration{
+			JavascriptEnabled:         &yes,
+			MinimumEnabledTlsProtocol: &tls12,
+			NoTableScan:               &no,
+			DefaultReadConcern:        &readConcern,
+			DefaultWriteConcern:       &writeConcern,
+		},
+	}
+}
+
+// clearDefaults unsets the fields of actual that are unset in prior and hold the Atlas default. A block or list unset
+// in prior is only unset as a whole, when everything in it is default, so no half of a block is reported. Blocks and
+// lists set in prior are checked field by field. Blocks in actual are copied before they are changed, actual may
+// share them with other models.
+func clearDefaults(prior, actual, defaults reflect.Value) {
+	for i := 0; i < actual.NumField(); i++ {
+		p, a, d := prior.Field(i), actual.Field(i), defaults.Field(i)
+		switch a.Kind() {
+		case reflect.Ptr:
+			if a.IsNil() {
+				continue
+			}
+			if p.IsNil() {
+				if isDefault(a, d) {
+					a.Set(reflect.Zero(a.Type()))
+				}
+				continue
+			}
+			if a.Elem().Kind() == reflect.Struct {
+				block := reflect.New(a.Elem().Type())
+				block.Elem().Set(a.Elem())
+				clearDefaults(p.Elem(), block.Elem(), elemOrZero(d))
+				a.Set(block)
+			}
+		case reflect.Slice:
+			if a.IsNil() {
+				continue
+			}
+			if p.IsNil() {
+				if isDefault(a, d) {
+					a.Set(reflect.Zero(a.Type()))
+				}
+				continue
+			}
+			if a.Type().Elem().Kind() == reflect.Struct {
+				entries := reflect.MakeSlice(a.Type(), a.Len(), a.Len())
+				reflect.Copy(entries, a)
+				for j := 0; j < entries.Len() && j < p.Len(); j++ {
+					clearDefaults(p.Index(j), entries.Index(j), indexOrZero(d, j))
+				}
+				a.Set(entries)
+			}
+		}
+	}
+}
+
+// isDefault reports whether actual holds nothing but the defaults, unset values count as default
+func isDefault(actual, defaults reflect.Value) bool {
+	switch actual.Kind() {
+	case reflect.Ptr:
+		if actual.IsNil() {
+			return true
+		}
+		if actual.Elem().Kind() == reflect.Struct {
+			return isDefault(actual.Elem(), elemOrZero(defaults))
+		}
+		return !defaults.IsNil() && reflect.DeepEqual(actual.Elem().Interface(), defaults.Elem().Interface())
+	case reflect.Slice:
+		if actual.Len() == 0 {
+			return true
+		}
+		if actual.Len() != defaults.Len() {
+			return false
+		}
+		for j := 0; j < actual.Len(); j++ {
+			if !isDefault(actual.Index(j), defaults.Index(j)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Struct:
+		for i := 0; i < actual.NumField(); i++ {
+			if !isDefault(actual.Field(i), defaults.Field(i)) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(actual.Interface(), defaults.Interface())
+}
+
+func elemOrZero(v reflect.Value) reflect.Value {
+	if v.IsNil() {
+		return reflect.Zero(v.Type().Elem())
+	}
+	return v.Elem()
+}
+
+func indexOrZero(v reflect.Value, i int) reflect.Value {
+	if i >= v.Len() {
+		return reflect.Zero(v.Type().Elem())
+	}
+	return v.Index(i)
+}
+
+// orderRegionsLike returns the regions in the order of prior when both name the same regions, otherwise unchanged
+func orderRegionsLike(prior []RegionConfig, regions []RegionConfig) []RegionConfig {
+	if len(prior) != len(regions) {
+		return regions
+	}
+
+	byName := make(map[string]RegionConfig, len(regions))
+	for _, region := range regions {
+		byName[stringValue(region.RegionName)] = region
+	}
+
+	ordered := make([]RegionConfig, 0, len(regions))
+	for _, p := range prior {
+		region, ok := byName[stringValue(p.RegionName)]
+		if !ok {
+			return regions
+		}
+		ordered = append(ordered, region)
+		delete(byName, stringValue(p.RegionName))
+	}
+	return ordered
+}
+
+// orderLabelsLike returns the labels in the order of prior when both hold the same labels, otherwise unchanged
+func orderLabelsLike(prior []Labels, labels []Labels) []Labels {
+	if len(prior) != len(labels) {
+		return labels
+	}
+
+	remaining := make([]Labels, len(labels))
+	copy(remaining, labels)
+
+	ordered := make([]Labels, 0, len(labels))
+	for _, p := range prior {
+		found := false
+		for j, l := range remaining {
+			if stringValue(l.Key) == stringValue(p.Key) && stringValue(l.Value) == stringValue(p.Value) {
+				ordered = append(ordered, l)
+				remaining = append(remaining[:j], remaining[j+1:]...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return labels
+		}
+	}
+	return ordered
+}
+
+func flattenAutoScaling(autoScaling *mongodbatlas.AutoScaling) *AutoScaling {
+	if autoScaling == nil {
+		return nil
+	}
+
+	res := &AutoScaling{
+		DiskGBEnabled: autoScaling.DiskGBEnabled,
+	}
+	if autoScaling.Compute != nil && (autoScaling.Compute.Enabled != nil || autoScaling.Compute.ScaleDownEnabled != nil) {
+		res.Compute = &AutoScalingCompute{
+			Enabled:          autoScaling.Compute.Enabled,
+			ScaleDownEnabled: autoScaling.Compute.ScaleDownEnabled,
+		}
+	}
+	if res.DiskGBEnabled == nil && res.Compute == nil {
+		return nil
+	}
+	return res
+}
+
+func flattenBiConnector(biConnector *mongodbatlas.BiConnector) *BiConnector {
+	if biConnector == nil || (biConnector.Enabled == nil && biConnector.ReadPreference == "") {
+		return nil
+	}
+
+	return &BiConnector{
+		ReadPreference: stringOrNil(biConnector.ReadPreference),
+		Enabled:        biConnector.Enabled,
+	}
+}
+
+func flattenProviderSettings(providerSettings *mongodbatlas.ProviderSettings) *ProviderSettings {
+	if providerSettings == nil {
+		return nil
+	}
+
+	res := &ProviderSettings{
+		BackingProviderName: stringOrNil(providerSettings.BackingProviderName),
+		ProviderName:        stringOrNil(providerSettings.ProviderName),
+		EncryptEBSVolume:    providerSettings.EncryptEBSVolume,
+		InstanceSizeName:    stringOrNil(providerSettings.InstanceSizeName),
+		RegionName:          stringOrNil(providerSettings.RegionName),
+		VolumeType:          stringOrNil(providerSettings.VolumeType),
+	}
+	if providerSettings.DiskIOPS != nil {
+		res.DiskIOPS = castNO64(providerSettings.DiskIOPS)
+	}
+	if compute := providerSettings.AutoScaling; compute != nil && compute.Compute != nil &&
+		(compute.Compute.MinInstanceSize != "" || compute.Compute.MaxInstanceSize != "") {
+		res.AutoScaling = &AutoScalingProvider{
+			Compute: &AutoScalingProviderCompute{
+				MinInstanceSize: stringOrNil(compute.Compute.MinInstanceSize),
+				MaxInstanceSize: stringOrNil(compute.Compute.MaxInstanceSize),
+			},
+		}
+	}
+	return res
+}
+
+func flattenReplicationSpecs(rSpecs []mongodbatlas.ReplicationSpec) []ReplicationSpec {
+	var specs []ReplicationSpec
+	for _, rSpec := range rSpecs {
+		spec := ReplicationSpec{
+			ID:            stringOrNil(rSpec.ID),
+			ZoneName:      stringOrNil(rSpec.ZoneName),
+			RegionsConfig: flattenRegionsConfig(rSpec.RegionsConfig),
+		}
+		if rSpec.NumShards != nil {
+			spec.NumShards = castNO64(rSpec.NumShards)
+		}
+		specs = append(specs, spec)
+	}
+	return specs
+}
+
+// flattenRegionsConfig returns the regions sorted by name, Atlas reports them as a map
+func flattenRegionsConfig(regionsConfig map[string]mongodbatlas.RegionsConfig) []RegionConfig {
+	names := make([]string, 0, len(regionsConfig))
+	for regionName := range regionsConfig {
+		names = append(names, regionName)
+	}
+	sort.Strings(names)
+
+	var regions []RegionConfig
+	for i := range names {
+		regionConfig := regionsConfig[names[i]]
+		region := RegionConfig{
+			RegionName: &names[i],
+		}
+		if regionConfig.Priority != nil {
+			region.Priority = castNO64(regionConfig.Priority)
+		}
+		if regionConfig.AnalyticsNodes != nil {
+			region.AnalyticsNodes = castNO64(regionConfig.AnalyticsNodes)
+		}
+		if regionConfig.ElectableNodes != nil {
+			region.ElectableNodes = castNO64(regionConfig.ElectableNodes)
+		}
+		if regionConfig.ReadOnlyNodes != nil {
+			region.ReadOnlyNodes = castNO64(regionConfig.ReadOnlyNodes)
+		}
+		regions = append(regions, region)
+	}
+	return regions
+}
+
+// flattenLabels returns the labels sorted by key and value, Atlas does not guarantee their order
+func flattenLabels(clusterLabels []mongodbatlas.Label) []Labels {
+	sorted := make([]mongodbatlas.Label, len(clusterLabels))
+	copy(sorted, clusterLabels)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Key != sorted[j].Key {
+			return sorted[i].Key < sorted[j].Key
+		}
+		return sorted[i].Value < sorted[j].Value
+	})
+
+	var labels []Labels
+	for i := range sorted {
+		labels = append(labels, Labels{
+			Key:   &sorted[i].Key,
+			Value: &sorted[i].Value,
+		})
+	}
+	return labels
+}
+
+// flattenPrivateEndpoints sorts the private endpoint connection strings by type and connection string, and the
+// endpoints of each by endpoint id
+func flattenPrivateEndpoints(privateEndpoints []mongodbatlas.PrivateEndpoint) []PrivateEndpointConnectionString {
+	sorted := make([]mongodbatlas.PrivateEndpoint, len(privateEndpoints))
+	copy(sorted, privateEndpoints)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Type != sorted[j].Type {
+			return sorted[i].Type < sorted[j].Type
+		}
+		return sorted[i].SRVConnectionString < sorted[j].SRVConnectionString
+	})
+
+	var connectionStrings []PrivateEndpointConnectionString
+	for i := range sorted {
+		endpoints := make([]mongodbatlas.Endpoint, len(sorted[i].Endpoints))
+		copy(endpoints, sorted[i].Endpoints)
+		sort.Slice(endpoints, func(a, b int) bool {
+			return endpoints[a].EndpointID < endpoints[b].EndpointID
+		})
+
+		connectionString := PrivateEndpointConnectionString{
+			ConnectionString:    stringOrNil(sorted[i].ConnectionString),
+			SrvConnectionString: stringOrNil(sorted[i].SRVConnectionString),
+			Type:                stringOrNil(sorted[i].Type),
+		}
+		for j := range endpoints {
+			connectionString.Endpoints = append(connectionString.Endpoints, PrivateEndpoint{
+				EndpointId:   stringOrNil(endpoints[j].EndpointID),
+				ProviderName: stringOrNil(endpoints[j].ProviderName),
+				Region:       stringOrNil(endpoints[j].Region),
+			})
+		}
+		connectionStrings = append(connectionStrings, connectionString)
+	}
+	return connectionStrings
+}
+
+func stringOrNil(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,434 @@
+package resource
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"go.mongodb.org/atlas/mongodbatlas"
+)
+
+// atlasCluster answers like Atlas does for a cluster created from the template, with the defaults filled in
+func atlasCluster(template *Model) *mongodbatlas.Cluster {
+	cluster := expandCluster(template)
+	cluster.ID = "5f1ea7d9ab3c1c2f3e9b0a11"
+	cluster.GroupID = stringValue(template.ProjectId)
+	cluster.StateName = "IDLE"
+	cluster.MongoDBVersion = "4.4.4"
+	cluster.MongoURI = "mongodb://test-shard-00-00.abcde.mongodb.net:27017"
+	cluster.MongoURIUpdated = "2021-03-01T10:00:00Z"
+	cluster.MongoURIWithOptions = "mongodb://test-shard-00-00.abcde.mongodb.net:27017/?ssl=true"
+	cluster.SrvAddress = "mongodb+srv://test.abcde.mongodb.net"
+	cluster.ConnectionStrings = &mongodbatlas.ConnectionStrings{
+		Standard:    "mongodb://test-shard-00-00.abcde.mongodb.net:27017",
+		StandardSrv: "mongodb+srv://test.abcde.mongodb.net",
+	}
+
+	if cluster.ClusterType == "" {
+		cluster.ClusterType = "REPLICASET"
+	}
+	if cluster.EncryptionAtRestProvider == "" {
+		cluster.EncryptionAtRestProvider = "NONE"
+	}
+	if cluster.MongoDBMajorVersion == "" {
+		cluster.MongoDBMajorVersion = "4.4"
+	}
+	if cluster.DiskSizeGB == nil {
+		diskSizeGB := 10.0
+		cluster.DiskSizeGB = &diskSizeGB
+	}
+	if cluster.NumShards == nil {
+		numShards := int64(1)
+		cluster.NumShards = &numShards
+	}
+	if cluster.BackupEnabled == nil {
+		cluster.BackupEnabled = new(bool)
+	}
+	if cluster.PitEnabled == nil {
+		cluster.PitEnabled = new(bool)
+	}
+	if cluster.ProviderBackupEnabled == nil {
+		cluster.ProviderBackupEnabled = new(bool)
+	}
+	if cluster.Paused == nil {
+		cluster.Paused = new(bool)
+	}
+	if cluster.AutoScaling == nil {
+		enabled := true
+		cluster.AutoScaling = &mongodbatlas.AutoScaling{
+			DiskGBEnabled: &enabled,
+			Compute:       &mongodbatlas.Compute{Enabled: new(bool), ScaleDownEnabled: new(bool)},
+		}
+	}
+	if cluster.BiConnector == nil {
+		cluster.BiConnector = &mongodbatlas.BiConnector{Enabled: new(bool), ReadPreference: "secondary"}
+	}
+	if cluster.ProviderSettings.AutoScaling == nil {
+		cluster.ProviderSettings.AutoScaling = &mongodbatlas.AutoScaling{Compute: &mongodbatlas.Compute{}}
+	}
+	if cluster.ReplicationSpecs == nil {
+		cluster.ReplicationSpecs = []mongodbatlas.ReplicationSpec{
+			{
+				NumShards: cluster.NumShards,
+				ZoneName:  "Zone 1",
+				RegionsConfig: map[string]mongodbatlas.RegionsConfig{
+					cluster.ProviderSettings.RegionName: {},
+				},
+			},
+		}
+	}
+	for i := range cluster.ReplicationSpecs {
+		if cluster.ReplicationSpecs[i].ID == "" {
+			cluster.ReplicationSpecs[i].ID = "5f1ea7d9ab3c1c2f3e9b0a2" + string(rune('0'+i))
+		}
+	}
+	// Atlas returns labels in its own order
+	for i, j := 0, len(cluster.Labels)-1; i < j; i, j = i+1, j-1 {
+		cluster.Labels[i], cluster.Labels[j] = cluster.Labels[j], cluster.Labels[i]
+	}
+	return cluster
+}
+
+func modelFromJSON(t *testing.T, data string) *Model {
+	t.Helper()
+
+	model := &Model{}
+	if err := json.Unmarshal([]byte(data), model); err != nil {
+		t.Fatalf("invalid model %s: %s", data, err)
+	}
+	return model
+}
+
+func TestNormalizeClusterRoundTrip(t *testing.T) {
+	testCases := []struct {
+		name     string
+		template string
+	}{
+		{
+			name: "minimal replica set",
+			template: `{
+				"ProjectId": "5f1ea7d9ab3c1c2f3e9b0a00",
+				"Name": "minimal",
+				"ProviderSettings": {"ProviderName": "AWS", "InstanceSizeName": "M10", "RegionName": "US_EAST_1"}
+			}`,
+		},
+		{
+			name: "short major version",
+			template: `{
+				"ProjectId": "5f1ea7d9ab3c1c2f3e9b0a00",
+				"Name": "version",
+				"MongoDBMajorVersion": "4",
+				"ProviderSettings": {"ProviderName": "AWS", "InstanceSizeName": "M10", "RegionName": "US_EAST_1"}
+			}`,
+		},
+		{
+			name: "multi region in template order",
+			template: `{
+				"ProjectId": "5f1ea7d9ab3c1c2f3e9b0a00",
+				"Name": "regions",
+				"ProviderSettings": {"ProviderName": "AWS", "InstanceSizeName": "M30"},
+				"ReplicationSpecs": [{
+					"NumShards": 1,
+					"ZoneName": "Zone 1",
+					"RegionsConfig": [
+						{"RegionName": "US_WEST_2", "ElectableNodes": 3, "Priority": 7, "ReadOnlyNodes": 0},
+						{"RegionName": "EU_WEST_1", "ElectableNodes": 2, "Priority": 6},
+						{"RegionName": "AP_SOUTHEAST_2", "ElectableNodes": 2, "Priority": 5, "AnalyticsNodes": 1}
+					]
+				}]
+			}`,
+		},
+		{
+			name: "labels in template order",
+			template: `{
+				"ProjectId": "5f1ea7d9ab3c1c2f3e9b0a00",
+				"Name": "labels",
+				"ProviderSettings": {"ProviderName": "AWS", "InstanceSizeName": "M10", "RegionName": "US_EAST_1"},
+				"Labels": [{"Key": "team", "Value": "db"}, {"Key": "env", "Value": "prod"}, {"Key": "cost", "Value": "42"}]
+			}`,
+		},
+		{
+			name: "optional blocks partially set",
+			template: `{
+				"ProjectId": "5f1ea7d9ab3c1c2f3e9b0a00",
+				"Name": "blocks",
+				"ClusterType": "REPLICASET",
+				"DiskSizeGB": 40,
+				"BackupEnabled": false,
+				"ProviderBackupEnabled": true,
+				"AutoScaling": {"DiskGBEnabled": true},
+				"BiConnector": {"Enabled": false},
+				"ProviderSettings": {
+					"ProviderName": "AWS",
+					"InstanceSizeName": "M10",
+					"RegionName": "EU_CENTRAL_1",
+					"DiskIOPS": 100,
+					"EncryptEBSVolume": false,
+					"AutoScaling": {"Compute": {"MaxInstanceSize": "M40"}}
+				}
+			}`,
+		},
+		{
+			name: "handler settings and api keys",
+			template: `{
+				"ApiKeys": {"PublicKey": "public", "PrivateKey": "private"},
+				"ProjectId": "5f1ea7d9ab3c1c2f3e9b0a00",
+				"Name": "handler",
+				"ClusterCfnIdentifier": "cluster-handler-5f1ea7d9ab3c1c2f3e9b0a00",
+				"TerminationProtectionEnabled": true,
+				"StabilizationTimeoutMinutes": 30,
+				"FinalSnapshot": {"RetentionInDays": 7},
+				"ProviderSettings": {"ProviderName": "AWS", "InstanceSizeName": "M10", "RegionName": "US_EAST_1"}
+			}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			template := modelFromJSON(t, tc.template)
+			cluster := atlasCluster(modelFromJSON(t, tc.template))
+
+			want := modelFromJSON(t, tc.template)
+			want.Id = &cluster.ID
+			want.StateName = &cluster.StateName
+			want.MongoDBVersion = &cluster.MongoDBVersion
+			want.MongoURI = &cluster.MongoURI
+			want.MongoURIUpdated = &cluster.MongoURIUpdated
+			want.MongoURIWithOptions = &cluster.MongoURIWithOptions
+			want.SrvAddress = &cluster.SrvAddress
+			want.ConnectionString = &cluster.ConnectionStrings.Standard
+			want.SrvConnectionString = &cluster.ConnectionStrings.StandardSrv
+			// Atlas picks the version and the disk size of the tier, they are reported even when the template leaves them out
+			if want.MongoDBMajorVersion == nil {
+				want.MongoDBMajorVersion = &cluster.MongoDBMajorVersion
+			}
+			if want.DiskSizeGB == nil {
+				want.DiskSizeGB = cluster.DiskSizeGB
+			}
+
+			// the regions come from a map, repeat to catch order dependent output
+			for i := 0; i < 20; i++ {
+				created := normalizeCluster(template, flattenCluster(cluster))
+				if !reflect.DeepEqual(want, created) {
+					t.Fatalf("create: want %s, got %s", spew.Sdump(want), spew.Sdump(created))
+				}
+
+				read := normalizeCluster(created, flattenCluster(cluster))
+				if !reflect.DeepEqual(created, read) {
+					t.Fatalf("read after create: want %s, got %s", spew.Sdump(created), spew.Sdump(read))
+				}
+			}
+		})
+	}
+}
+
+func TestNormalizeClusterReportsDrift(t *testing.T) {
+	template := modelFromJSON(t, `{
+		"ProjectId": "5f1ea7d9ab3c1c2f3e9b0a00",
+		"Name": "drift",
+		"DiskSizeGB": 40,
+		"ProviderSettings": {"ProviderName": "AWS", "InstanceSizeName": "M10", "RegionName": "US_EAST_1"},
+		"Labels": [{"Key": "env", "Value": "prod"}]
+	}`)
+
+	cluster := atlasCluster(modelFromJSON(t, `{
+		"ProjectId": "5f1ea7d9ab3c1c2f3e9b0a00",
+		"Name": "drift",
+		"DiskSizeGB": 80,
+		"ProviderSettings": {"ProviderName": "AWS", "InstanceSizeName": "M20", "RegionName": "US_EAST_1"},
+		"Labels": [{"Key": "env", "Value": "prod"}, {"Key": "added", "Value": "manually"}]
+	}`))
+
+	read := normalizeCluster(template, flattenCluster(cluster))
+
+	if *read.DiskSizeGB != 80 {
+		t.Errorf("DiskSizeGB: want 80, got %v", *read.DiskSizeGB)
+	}
+	if *read.ProviderSettings.InstanceSizeName != "M20" {
+		t.Errorf("InstanceSizeName: want M20, got %s", *read.ProviderSettings.InstanceSizeName)
+	}
+	if len(read.Labels) != 2 || *read.Labels[0].Key != "added" || *read.Labels[1].Key != "env" {
+		t.Errorf("Labels: want added and env sorted, got %s", spew.Sdump(read.Labels))
+	}
+	if read.ClusterType != nil || read.AutoScaling != nil || read.BiConnector != nil {
+		t.Errorf("settings missing from the template should stay unset, got %s", spew.Sdump(read))
+	}
+}
+
+func TestReadClusterWithIdentifierOnly(t *testing.T) {
+	cluster := atlasCluster(modelFromJSON(t, `{
+		"ProjectId": "5f1ea7d9ab3c1c2f3e9b0a00",
+		"Name": "imported",
+		"ProviderBackupEnabled": true,
+		"ProviderSettings": {"ProviderName": "AWS", "InstanceSizeName": "M10", "RegionName": "US_EAST_1"},
+		"Labels": [{"Key": "env", "Value": "prod"}]
+	}`))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/processArgs") {
+			fmt.Fprint(w, `{"javascriptEnabled": true, "minimumEnabledTlsProtocol": "TLS1_2", "noTableScan": false, "defaultReadConcern": "available", "defaultWriteConcern": "1"}`)
+			return
+		}
+		json.NewEncoder(w).Encode(cluster)
+	}))
+	defer server.Close()
+	client := mongodbatlas.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	// what CloudFormation sends for drift detection, import and the Read after List
+	model := modelFromJSON(t, `{"ClusterCfnIdentifier": "cluster-imported-5f1ea7d9ab3c1c2f3e9b0a00"}`)
+	if err := readCluster(client, "5f1ea7d9ab3c1c2f3e9b0a00", "imported", model); err != nil {
+		t.Fatal(err)
+	}
+
+	want := modelFromJSON(t, `{
+		"ClusterCfnIdentifier": "cluster-imported-5f1ea7d9ab3c1c2f3e9b0a00",
+		"ProjectId": "5f1ea7d9ab3c1c2f3e9b0a00",
+		"Name": "imported",
+		"DiskSizeGB": 10,
+		"MongoDBMajorVersion": "4.4",
+		"ProviderBackupEnabled": true,
+		"ProviderSettings": {"ProviderName": "AWS", "InstanceSizeName": "M10", "RegionName": "US_EAST_1"},
+		"Labels": [{"Key": "env", "Value": "prod"}]
+	}`)
+	want.Id = &cluster.ID
+	want.StateName = &cluster.StateName
+	want.MongoDBVersion = &cluster.MongoDBVersion
+	want.MongoURI = &cluster.MongoURI
+	want.MongoURIUpdated = &cluster.MongoURIUpdated
+	want.MongoURIWithOptions = &cluster.MongoURIWithOptions
+	want.SrvAddress = &cluster.SrvAddress
+	want.ConnectionString = &cluster.ConnectionStrings.Standard
+	want.SrvConnectionString = &cluster.ConnectionStrings.StandardSrv
+
+	if !reflect.DeepEqual(want, model) {
+		t.Errorf("want %s, got %s", spew.Sdump(want), spew.Sdump(model))
+	}
+}
+
+func TestNormalizeClusterWithoutTemplateKeepsChangedDefaults(t *testing.T) {
+	cluster := atlasCluster(modelFromJSON(t, `{
+		"ProjectId": "5f1ea7d9ab3c1c2f3e9b0a00",
+		"Name": "tuned",
+		"ClusterType": "SHARDED",
+		"NumShards": 2,
+		"BiConnector": {"Enabled": true, "ReadPreference": "analytics"},
+		"ProviderSettings": {"ProviderName": "AWS", "InstanceSizeName": "M30", "RegionName": "US_EAST_1"}
+	}`))
+
+	model := normalizeCluster(&Model{}, flattenCluster(cluster))
+
+	if stringValue(model.ClusterType) != "SHARDED" || model.NumShards == nil || *model.NumShards != 2 {
+		t.Errorf("want the cluster type and shards reported, got %v and %v", model.ClusterType, model.NumShards)
+	}
+	if model.BiConnector == nil || stringValue(model.BiConnector.ReadPreference) != "analytics" || model.BiConnector.Enabled == nil {
+		t.Errorf("want the whole BI connector block reported, got %s", spew.Sdump(model.BiConnector))
+	}
+	// the default zone of a sharded cluster still holds the default region
+	if model.ReplicationSpecs != nil || model.AutoScaling != nil {
+		t.Errorf("want the default replication spec and auto-scaling hidden, got %s", spew.Sdump(model))
+	}
+}
+
+func TestFlattenClusterWithoutTemplate(t *testing.T) {
+	cluster := &mongodbatlas.Cluster{
+		Name:        "listed",
+		ClusterType: "GEOSHARDED",
+		ProviderSettings: &mongodbatlas.ProviderSettings{
+			ProviderName:     "AWS",
+			InstanceSizeName: "M30",
+			AutoScaling:      &mongodbatlas.AutoScaling{Compute: &mongodbatlas.Compute{}},
+		},
+		BiConnector: &mongodbatlas.BiConnector{},
+		ReplicationSpecs: []mongodbatlas.ReplicationSpec{
+			{
+				ID: "5f1ea7d9ab3c1c2f3e9b0a20",
+				RegionsConfig: map[string]mongodbatlas.RegionsConfig{
+					"US_WEST_2":      {},
+					"AP_SOUTHEAST_2": {},
+					"EU_WEST_1":      {},
+				},
+			},
+		},
+	}
+
+	model := flattenCluster(cluster)
+
+	var regions []string
+	for _, region := range model.ReplicationSpecs[0].RegionsConfig {
+		regions = append(regions, *region.RegionName)
+	}
+	if want := []string{"AP_SOUTHEAST_2", "EU_WEST_1", "US_WEST_2"}; !reflect.DeepEqual(want, regions) {
+		t.Errorf("regions: want %v, got %v", want, regions)
+	}
+	if model.BiConnector != nil || model.ProviderSettings.AutoScaling != nil || model.AutoScaling != nil {
+		t.Errorf("empty blocks should be unset, got %s", spew.Sdump(model))
+	}
+	if model.ProviderSettings.RegionName != nil || model.MongoDBMajorVersion != nil || model.StateName != nil {
+		t.Errorf("empty values should be unset, got %s", spew.Sdump(model))
+	}
+}
+
+func TestFormatMongoDBMajorVersion(t *testing.T) {
+	testCases := map[string]string{
+		"4":   "4.0",
+		"4.0": "4.0",
+		"4.4": "4.4",
+		"5":   "5.0",
+	}
+
+	for version, want := range testCases {
+		if got := formatMongoDBMajorVersion(version); got != want {
+			t.Errorf("formatMongoDBMajorVersion(%q): want %q, got %q", version, want, got)
+		}
+	}
+}
+
+func TestFlattenClusterPrivateConnectionStrings(t *testing.T) {
+	cluster := atlasCluster(modelFromJSON(t, `{"Name": "test", "ProviderSettings": {"ProviderName": "AWS", "InstanceSizeName": "M10", "RegionName": "US_EAST_1"}}`))
+	cluster.ConnectionStrings.Private = "mongodb://test-shard-00-00-pri.abcde.mongodb.net:27017"
+	cluster.ConnectionStrings.PrivateSrv = "mongodb+srv://test-pri.abcde.mongodb.net"
+	cluster.ConnectionStrings.PrivateEndpoint = []mongodbatlas.PrivateEndpoint{
+		{
+			SRVConnectionString: "mongodb+srv://test-pl-1.abcde.mongodb.net",
+			Type:                "MONGOD",
+			Endpoints: []mongodbatlas.Endpoint{
+				{EndpointID: "vpce-0bbb", ProviderName: "AWS", Region: "us-west-2"},
+				{EndpointID: "vpce-0aaa", ProviderName: "AWS", Region: "us-east-1"},
+			},
+		},
+		{
+			SRVConnectionString: "mongodb+srv://test-pl-0.abcde.mongodb.net",
+			Type:                "MONGOD",
+			Endpoints:           []mongodbatlas.Endpoint{{EndpointID: "vpce-0ccc", ProviderName: "AWS", Region: "us-east-1"}},
+		},
+	}
+
+	model := normalizeCluster(modelFromJSON(t, `{"Name": "test"}`), flattenCluster(cluster))
+
+	if model.PrivateConnectionString == nil || *model.PrivateConnectionString != cluster.ConnectionStrings.Private {
+		t.Errorf("want the private connection string, got %v", model.PrivateConnectionString)
+	}
+	if model.PrivateSrvConnectionString == nil || *model.PrivateSrvConnectionString != cluster.ConnectionStrings.PrivateSrv {
+		t.Errorf("want the private srv connection string, got %v", model.PrivateSrvConnectionString)
+	}
+
+	privateEndpoints := model.PrivateEndpointConnectionStrings
+	if len(privateEndpoints) != 2 {
+		t.Fatalf("want 2 private endpoint connection strings, got %d", len(privateEndpoints))
+	}
+	if *privateEndpoints[0].SrvConnectionString != "mongodb+srv://test-pl-0.abcde.mongodb.net" {
+		t.Errorf("want the private endpoint connection strings sorted, got %s first", *privateEndpoints[0].SrvConnectionString)
+	}
+	if privateEndpoints[0].ConnectionString != nil {
+		t.Errorf("want the empty connection string left out, got %s", *privateEndpoints[0].ConnectionString)
+	}
+	if endpoints := privateEndpoints[1].Endpoints; len(endpoints) != 2 || *endpoints[0].EndpointId != "vpce-0aaa" {
+		t.Errorf("want the endpoints sorted by id, got %s", spew.Sdump(endpoints))
+	}
+}
@@ -5,7 +5,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
 	"strings"
+	"time"
 
 	"github.com/422158/mongodbstpatlas-cloudformation-resources/cfn-resources/V1/cluster/cmd/util"
 	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
@@ -32,247 +36,784 @@ func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler
 	}
 
 	if _, ok := req.CallbackContext["stateName"]; ok {
-		return validateProgress(client, req, currentModel, "IDLE", "CREATING")
+		progress, err := validateProgress(client, req, currentModel, "IDLE", "CREATING", "UPDATING", "REPAIRING")
+		if err != nil || progress.OperationStatus != handler.Success {
+			return progress, err
+		}
+		// process args can only be set once the cluster exists
+		progress, err = applyAdvancedConfigurationAfterCreate(client, req, currentModel, progress)
+		if err != nil || progress.OperationStatus != handler.Success {
+			return progress, err
+		}
+		// the created model reports the same as a following Read
+		return progress, readCluster(client, *currentModel.ProjectId, *currentModel.Name, currentModel)
 	}
 
 	projectID := *currentModel.ProjectId
 
-	if len(currentModel.ReplicationSpecs) > 0 {
-		if currentModel.ClusterType != nil {
-			return handler.ProgressEvent{}, errors.New("error creating cluster: ClusterType should be set when `ReplicationSpecs` is set")
+	if currentModel.Paused != nil && *currentModel.Paused {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          "error creating cluster: a cluster cannot be created paused, set Paused in a later update",
+			HandlerErrorCode: "InvalidRequest",
+		}, nil
+	}
+
+	if verr := validateCluster(currentModel); verr != nil {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          fmt.Sprintf("error creating cluster: %s", verr),
+			HandlerErrorCode: "InvalidRequest",
+		}, nil
+	}
+
+	clusterRequest := expandCluster(currentModel)
+
+	cluster, resp, err := client.Clusters.Create(context.Background(), projectID, clusterRequest)
+	if err != nil {
+		return handler.ProgressEvent{}, fmt.Errorf("error creating cluster: %w %v", err, &resp)
+	}
+
+	currentModel.Id = &cluster.ID
+	currentModel.StateName = &cluster.StateName
+
+	cfnid := buildClusterCfnIdentifier(currentModel.ProjectId, currentModel.Name)
+
+	currentModel.ClusterCfnIdentifier = &cfnid
+
+	// putting required parameters into parameter store (needed for read operation)
+	_, err = putParameterIntoParameterStore(currentModel.ClusterCfnIdentifier, &ParameterToBePersistedSpec{ApiKeys: currentModel.ApiKeys, ProjectId: currentModel.ProjectId, ClusterName: currentModel.Name}, req.Session)
+	if err != nil {
+		return handler.ProgressEvent{}, fmt.Errorf("error when putting api keys into parameter store: %s", err)
+	}
+
+	return handler.ProgressEvent{
+		OperationStatus:      handler.InProgress,
+		Message:              fmt.Sprintf("Create Cluster `%s`", cluster.StateName),
+		ResourceModel:        currentModel,
+		CallbackDelaySeconds: 65,
+		CallbackContext: map[string]interface{}{
+			"stateName": cluster.StateName,
+		},
+	}, nil
+}
+
+// Read handles the Read event from the Cloudformation service.
+func Read(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	params, err := getParameterFromParameterStore(currentModel.ClusterCfnIdentifier, req.Session)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	client, err := util.CreateMongoDBClient(*params.ApiKeys.PublicKey, *params.ApiKeys.PrivateKey)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	err = readCluster(client, *params.ProjectId, *params.ClusterName, currentModel)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	return handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		Message:         "Read Complete",
+		ResourceModel:   currentModel,
+	}, nil
+}
+
+// readCluster replaces currentModel with the normalized state of the cluster, see normalizeCluster
+func readCluster(client *mongodbatlas.Client, projectID, clusterName string, currentModel *Model) error {
+	cluster, _, err := client.Clusters.Get(context.Background(), projectID, clusterName)
+	if err != nil {
+		return fmt.Errorf("error fetching cluster info (%s): %s", clusterName, err)
+	}
+
+	actual := flattenCluster(cluster)
+	// Atlas keeps no process args for shared tier clusters and rejects the request for them
+	if !isTenantCluster(cluster) {
+		processArgs, err := getProcessArgs(client, projectID, clusterName)
+		if err != nil {
+			return fmt.Errorf("error fetching advanced configuration of cluster (%s): %s", clusterName, err)
 		}
+		actual.AdvancedConfiguration = flattenAdvancedConfiguration(processArgs)
+	}
+	// the identifiers come from the parameter store, a Read can be sent with nothing but the primary identifier
+	actual.ProjectId = &projectID
+	actual.Name = &clusterName
+
+	*currentModel = *normalizeCluster(currentModel, actual)
+	return nil
+}
 
-		if currentModel.NumShards != nil {
-			return handler.ProgressEvent{}, errors.New("error creating cluster: NumShards should be set when `ReplicationSpecs` is set")
+// Update handles the Update event from the Cloudformation service.
+func Update(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	client, err := util.CreateMongoDBClient(*currentModel.ApiKeys.PublicKey, *currentModel.ApiKeys.PrivateKey)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	if _, ok := req.CallbackContext["stateName"]; ok {
+		progress, err := validateProgress(client, req, currentModel, "IDLE", "UPDATING", "REPAIRING")
+		if err != nil || progress.OperationStatus != handler.Success {
+			return progress, err
 		}
+		if _, ok := req.CallbackContext["tenantUpgrade"]; !ok || isSharedTier(cast.ToString(req.CallbackContext["instanceSizeName"])) {
+			return progress, readCluster(client, *currentModel.ProjectId, *currentModel.Name, currentModel)
+		}
+		// the tenant upgrade only changes the tier, the rest of the template is applied as a regular update
+		req.CallbackContext = nil
 	}
 
-	var autoScaling *mongodbatlas.AutoScaling
-	if currentModel.AutoScaling != nil {
-		autoScaling = &mongodbatlas.AutoScaling{
-			DiskGBEnabled: currentModel.AutoScaling.DiskGBEnabled,
+	projectID := *currentModel.ProjectId
+	clusterName := *currentModel.Name
+
+	if fields := changedCreateOnlyProperties(prevModel, currentModel); len(fields) > 0 {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          fmt.Sprintf("error updating cluster with name \"%s\": %s cannot be changed after the cluster is created, replace the cluster instead", clusterName, strings.Join(fields, ", ")),
+			HandlerErrorCode: "NotUpdatable",
+		}, nil
+	}
+
+	if verr := validateUpdate(prevModel, currentModel); verr != nil {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          fmt.Sprintf("error updating cluster with name \"%s\": %s", clusterName, verr),
+			HandlerErrorCode: "InvalidRequest",
+		}, nil
+	}
+
+	if failoverRequested(prevModel, currentModel) {
+		if clusterSettingsChanged(prevModel, currentModel) || isTrue(prevModel.Paused) != isTrue(currentModel.Paused) {
+			return handler.ProgressEvent{
+				OperationStatus:  handler.Failed,
+				Message:          fmt.Sprintf("error updating cluster with name \"%s\": FailoverTrigger cannot be changed together with other cluster settings, trigger the failover in a separate update", clusterName),
+				HandlerErrorCode: "InvalidRequest",
+			}, nil
 		}
-		if currentModel.AutoScaling.Compute != nil {
-			compute := &mongodbatlas.Compute{}
-			if currentModel.AutoScaling.Compute.Enabled != nil {
-				compute.Enabled = currentModel.AutoScaling.Compute.Enabled
-			}
-			if currentModel.AutoScaling.Compute.ScaleDownEnabled != nil {
-				compute.ScaleDownEnabled = currentModel.AutoScaling.Compute.ScaleDownEnabled
+		return testFailover(req, client, currentModel)
+	}
+
+	cfnid := buildClusterCfnIdentifier(currentModel.ProjectId, currentModel.Name)
+	currentModel.ClusterCfnIdentifier = &cfnid
+
+	// a stack update that leaves the cluster untouched, i.e. only tags or handler settings changed, needs no Atlas call
+	if !clusterSettingsChanged(prevModel, currentModel) && isTrue(prevModel.Paused) == isTrue(currentModel.Paused) {
+		// the api keys might have been updated therefore we need to do this here
+		_, err = putParameterIntoParameterStore(currentModel.ClusterCfnIdentifier, &ParameterToBePersistedSpec{ApiKeys: currentModel.ApiKeys, ProjectId: currentModel.ProjectId, ClusterName: currentModel.Name}, req.Session)
+		if err != nil {
+			return handler.ProgressEvent{}, fmt.Errorf("error when putting api keys into parameter store: %s", err)
+		}
+		return handler.ProgressEvent{
+			OperationStatus: handler.Success,
+			Message:         "Complete",
+			ResourceModel:   currentModel,
+		}, nil
+	}
+
+	currentClusterInfo, _, err := client.Clusters.Get(context.Background(), projectID, clusterName)
+	if err != nil {
+		return handler.ProgressEvent{}, fmt.Errorf("error fetching cluster info (%s): %s", clusterName, err)
+	}
+
+	isPaused := currentClusterInfo.Paused != nil && *currentClusterInfo.Paused
+	desiredPaused := currentModel.Paused != nil && *currentModel.Paused
+	settingsChanged := clusterSettingsChanged(prevModel, currentModel)
+
+	action, perr := decidePauseAction(isPaused, desiredPaused, settingsChanged)
+	if perr != nil {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          fmt.Sprintf("error updating cluster with name \"%s\": %s", clusterName, perr),
+			HandlerErrorCode: "InvalidRequest",
+		}, nil
+	}
+	switch action {
+	case pauseActionSend:
+		return updatePaused(req, client, currentModel, desiredPaused)
+	case pauseActionNone:
+		return handler.ProgressEvent{
+			OperationStatus: handler.Success,
+			Message:         "Complete",
+			ResourceModel:   currentModel,
+		}, nil
+	}
+
+	preUpdateClusterSize := currentClusterInfo.ProviderSettings.InstanceSizeName
+	desiredClusterSize := preUpdateClusterSize
+	if currentModel.ProviderSettings != nil && currentModel.ProviderSettings.InstanceSizeName != nil {
+		desiredClusterSize = *currentModel.ProviderSettings.InstanceSizeName
+	}
+
+	if isSharedTier(preUpdateClusterSize) {
+		if desiredClusterSize != preUpdateClusterSize {
+			if tierRank(desiredClusterSize) < tierRank(preUpdateClusterSize) {
+				return handler.ProgressEvent{
+					OperationStatus:  handler.Failed,
+					Message:          fmt.Sprintf("error updating cluster with name \"%s\": Atlas cannot downgrade a shared tier cluster from %s to %s, shared tier clusters can only be upgraded to a larger shared tier or to a dedicated tier", clusterName, preUpdateClusterSize, desiredClusterSize),
+					HandlerErrorCode: "InvalidRequest",
+				}, nil
 			}
+			return upgradeTenantCluster(req, client, currentModel, currentClusterInfo, desiredClusterSize)
+		}
+		// shared tier clusters only accept a few modifications, there is nothing to send when the template did not change
+		if !settingsChanged {
+			return handler.ProgressEvent{
+				OperationStatus: handler.Success,
+				Message:         "Complete",
+				ResourceModel:   currentModel,
+			}, nil
+		}
+	}
 
-			autoScaling.Compute = compute
+	clusterRequest, clusterChanged := expandClusterChanges(prevModel, currentModel)
+
+	if currentModel.AdvancedConfiguration != nil && !reflect.DeepEqual(prevModel.AdvancedConfiguration, currentModel.AdvancedConfiguration) {
+		_, err = updateProcessArgs(client, projectID, clusterName, expandAdvancedConfiguration(currentModel.AdvancedConfiguration))
+		if err != nil {
+			return handler.ProgressEvent{}, fmt.Errorf("error updating advanced configuration of cluster (%s): %s", clusterName, err)
 		}
 	}
 
-	clusterRequest := &mongodbatlas.Cluster{
-		Name:                     cast.ToString(currentModel.Name),
-		EncryptionAtRestProvider: cast.ToString(currentModel.EncryptionAtRestProvider),
-		ClusterType:              cast.ToString(currentModel.ClusterType),
-		AutoScaling:              autoScaling,
-		NumShards:                cast64(currentModel.NumShards),
+	// the labels sent replace the ones in Atlas, so sending the whole set adds and removes labels at once,
+	// only an empty set needs its own request
+	if len(currentModel.Labels) == 0 && len(currentClusterInfo.Labels) > 0 && !reflect.DeepEqual(prevModel.Labels, currentModel.Labels) {
+		err = removeAllLabels(client, projectID, clusterName)
+		if err != nil {
+			return handler.ProgressEvent{}, fmt.Errorf("error removing labels of cluster (%s): %s", clusterName, err)
+		}
 	}
 
-	if currentModel.BackupEnabled != nil {
-		clusterRequest.BackupEnabled = currentModel.BackupEnabled
+	// when only the advanced configuration or the labels changed there is nothing left to send,
+	// the callbacks still wait for Atlas to apply the change
+	cluster := &mongodbatlas.Cluster{ID: currentClusterInfo.ID, StateName: "UPDATING"}
+	if clusterChanged {
+		cluster, _, err = client.Clusters.Update(context.Background(), projectID, clusterName, clusterRequest)
+		if err != nil {
+			return handler.ProgressEvent{}, fmt.Errorf("error creating cluster: %s", err)
+		}
 	}
 
-	if currentModel.ProviderBackupEnabled != nil {
-		clusterRequest.ProviderBackupEnabled = currentModel.ProviderBackupEnabled
+	currentModel.Id = &cluster.ID
+
+	// putting required parameter into parameter store
+	// the api keys might have been updated therefore we need to do this here
+	_, err = putParameterIntoParameterStore(currentModel.ClusterCfnIdentifier, &ParameterToBePersistedSpec{ApiKeys: currentModel.ApiKeys, ProjectId: currentModel.ProjectId, ClusterName: currentModel.Name}, req.Session)
+	if err != nil {
+		return handler.ProgressEvent{}, fmt.Errorf("error when putting api keys into parameter store: %s", err)
 	}
 
-	if currentModel.PitEnabled != nil {
-		clusterRequest.PitEnabled = currentModel.PitEnabled
+	return handler.ProgressEvent{
+		OperationStatus:      handler.InProgress,
+		Message:              fmt.Sprintf("Update Cluster `%s`", cluster.StateName),
+		ResourceModel:        currentModel,
+		CallbackDelaySeconds: 65,
+		CallbackContext: map[string]interface{}{
+			"stateName": cluster.StateName,
+		},
+	}, nil
+}
+
+// pauseAction is what an update does about the paused state of the cluster
+type pauseAction int
+
+const (
+	// pauseActionApply leaves the cluster running and applies the changed settings
+	pauseActionApply pauseAction = iota
+	// pauseActionSend only pauses or resumes the cluster
+	pauseActionSend
+	// pauseActionNone keeps the paused cluster as it is, there is nothing to send
+	pauseActionNone
+)
+
+// decidePauseAction picks the pauseAction for an update, Atlas rejects pausing or resuming combined with other
+// changes as well as any change to a paused cluster
+func decidePauseAction(isPaused, desiredPaused, settingsChanged bool) (pauseAction, error) {
+	if isPaused != desiredPaused {
+		if settingsChanged {
+			return pauseActionApply, errors.New("Paused cannot be changed together with other cluster settings, apply the pause or resume in a separate update")
+		}
+		return pauseActionSend, nil
+	}
+	if isPaused {
+		if settingsChanged {
+			return pauseActionApply, errors.New("the cluster is paused, resume it (Paused: false) before changing other settings")
+		}
+		return pauseActionNone, nil
 	}
+	return pauseActionApply, nil
+}
 
-	if currentModel.DiskSizeGB != nil {
-		clusterRequest.DiskSizeGB = currentModel.DiskSizeGB
+// updatePaused sends a request that only pauses or resumes the cluster, Atlas rejects pausing combined with other changes
+func updatePaused(req handler.Request, client *mongodbatlas.Client, currentModel *Model, paused bool) (handler.ProgressEvent, error) {
+	clusterName := *currentModel.Name
+
+	cluster, _, err := client.Clusters.Update(context.Background(), *currentModel.ProjectId, clusterName, &mongodbatlas.Cluster{Paused: &paused})
+	if err != nil {
+		return handler.ProgressEvent{}, fmt.Errorf("error updating paused state of cluster (%s): %s", clusterName, err)
 	}
 
-	if currentModel.MongoDBMajorVersion != nil {
-		clusterRequest.MongoDBMajorVersion = formatMongoDBMajorVersion(*currentModel.MongoDBMajorVersion)
+	currentModel.Id = &cluster.ID
+
+	cfnid := buildClusterCfnIdentifier(currentModel.ProjectId, currentModel.Name)
+
+	currentModel.ClusterCfnIdentifier = &cfnid
+
+	// the api keys might have been updated therefore we need to do this here
+	_, err = putParameterIntoParameterStore(currentModel.ClusterCfnIdentifier, &ParameterToBePersistedSpec{ApiKeys: currentModel.ApiKeys, ProjectId: currentModel.ProjectId, ClusterName: currentModel.Name}, req.Session)
+	if err != nil {
+		return handler.ProgressEvent{}, fmt.Errorf("error when putting api keys into parameter store: %s", err)
 	}
 
-	if currentModel.BiConnector != nil {
-		clusterRequest.BiConnector = expandBiConnector(currentModel.BiConnector)
+	action := "Resume"
+	if paused {
+		action = "Pause"
+	}
+
+	return handler.ProgressEvent{
+		OperationStatus:      handler.InProgress,
+		Message:              fmt.Sprintf("%s Cluster `%s`", action, cluster.StateName),
+		ResourceModel:        currentModel,
+		CallbackDelaySeconds: 65,
+		CallbackContext: map[string]interface{}{
+			"stateName": cluster.StateName,
+			"paused":    paused,
+		},
+	}, nil
+}
+
+// upgradeTenantCluster moves a shared tier (M0, M2, M5) cluster to a larger tier, regular cluster updates cannot change the tier of a tenant cluster
+func upgradeTenantCluster(req handler.Request, client *mongodbatlas.Client, currentModel *Model, currentClusterInfo *mongodbatlas.Cluster, instanceSizeName string) (handler.ProgressEvent, error) {
+	projectID := *currentModel.ProjectId
+	clusterName := *currentModel.Name
+
+	upgradeRequest := tenantUpgradeRequest(currentModel, currentClusterInfo, instanceSizeName)
+
+	path := fmt.Sprintf("groups/%s/clusters/tenantUpgrade", projectID)
+	apiReq, err := client.NewRequest(context.Background(), http.MethodPost, path, upgradeRequest)
+	if err != nil {
+		return handler.ProgressEvent{}, fmt.Errorf("error upgrading cluster (%s): %s", clusterName, err)
+	}
+
+	cluster := new(mongodbatlas.Cluster)
+	_, err = client.Do(context.Background(), apiReq, cluster)
+	if err != nil {
+		return handler.ProgressEvent{}, fmt.Errorf("error upgrading cluster (%s) from %s to %s: %s", clusterName, currentClusterInfo.ProviderSettings.InstanceSizeName, instanceSizeName, err)
+	}
+
+	currentModel.Id = &cluster.ID
+
+	cfnid := buildClusterCfnIdentifier(currentModel.ProjectId, currentModel.Name)
+
+	currentModel.ClusterCfnIdentifier = &cfnid
+
+	// the api keys might have been updated therefore we need to do this here
+	_, err = putParameterIntoParameterStore(currentModel.ClusterCfnIdentifier, &ParameterToBePersistedSpec{ApiKeys: currentModel.ApiKeys, ProjectId: currentModel.ProjectId, ClusterName: currentModel.Name}, req.Session)
+	if err != nil {
+		return handler.ProgressEvent{}, fmt.Errorf("error when putting api keys into parameter store: %s", err)
+	}
+
+	stateName := cluster.StateName
+	if stateName == "" {
+		stateName = "UPDATING"
 	}
 
+	return handler.ProgressEvent{
+		OperationStatus:      handler.InProgress,
+		Message:              fmt.Sprintf("Upgrade Cluster to %s `%s`", instanceSizeName, stateName),
+		ResourceModel:        currentModel,
+		CallbackDelaySeconds: 65,
+		CallbackContext: map[string]interface{}{
+			"stateName":        stateName,
+			"tenantUpgrade":    true,
+			"instanceSizeName": instanceSizeName,
+		},
+	}, nil
+}
+
+// tenantUpgradeRequest builds the body of the tenant upgrade, the template wins over the current provider settings
+func tenantUpgradeRequest(currentModel *Model, currentClusterInfo *mongodbatlas.Cluster, instanceSizeName string) *mongodbatlas.Cluster {
+	providerName := currentClusterInfo.ProviderSettings.ProviderName
+	regionName := currentClusterInfo.ProviderSettings.RegionName
 	if currentModel.ProviderSettings != nil {
-		clusterRequest.ProviderSettings = expandProviderSettings(currentModel.ProviderSettings)
+		if currentModel.ProviderSettings.ProviderName != nil {
+			providerName = *currentModel.ProviderSettings.ProviderName
+		}
+		if currentModel.ProviderSettings.RegionName != nil {
+			regionName = *currentModel.ProviderSettings.RegionName
+		}
+	}
+	// a dedicated cluster runs directly on the cloud provider that backed the tenant cluster
+	if providerName == "TENANT" && !isSharedTier(instanceSizeName) {
+		providerName = currentClusterInfo.ProviderSettings.BackingProviderName
+		if currentModel.ProviderSettings != nil && currentModel.ProviderSettings.BackingProviderName != nil {
+			providerName = *currentModel.ProviderSettings.BackingProviderName
+		}
 	}
 
-	if currentModel.ReplicationSpecs != nil {
-		clusterRequest.ReplicationSpecs = expandReplicationSpecs(currentModel.ReplicationSpecs)
+	upgradeRequest := &mongodbatlas.Cluster{
+		Name: *currentModel.Name,
+		ProviderSettings: &mongodbatlas.ProviderSettings{
+			ProviderName:     providerName,
+			InstanceSizeName: instanceSizeName,
+			RegionName:       regionName,
+		},
 	}
+	if isSharedTier(instanceSizeName) {
+		upgradeRequest.ProviderSettings.BackingProviderName = currentClusterInfo.ProviderSettings.BackingProviderName
+	}
+	return upgradeRequest
+}
 
-	cluster, resp, err := client.Clusters.Create(context.Background(), projectID, clusterRequest)
+// testFailover restarts the primaries of the cluster, the callbacks wait until the cluster is IDLE again
+func testFailover(req handler.Request, client *mongodbatlas.Client, currentModel *Model) (handler.ProgressEvent, error) {
+	clusterName := *currentModel.Name
+
+	path := fmt.Sprintf("groups/%s/clusters/%s/restartPrimaries", *currentModel.ProjectId, url.PathEscape(clusterName))
+	apiReq, err := client.NewRequest(context.Background(), http.MethodPost, path, nil)
 	if err != nil {
-		return handler.ProgressEvent{}, fmt.Errorf("error creating cluster: %w %v", err, &resp)
+		return handler.ProgressEvent{}, fmt.Errorf("error triggering failover of cluster (%s): %s", clusterName, err)
 	}
 
-	currentModel.Id = &cluster.ID
-	currentModel.StateName = &cluster.StateName
+	_, err = client.Do(context.Background(), apiReq, nil)
+	if err != nil {
+		return handler.ProgressEvent{}, fmt.Errorf("error triggering failover of cluster (%s): %s", clusterName, err)
+	}
 
 	cfnid := buildClusterCfnIdentifier(currentModel.ProjectId, currentModel.Name)
 
 	currentModel.ClusterCfnIdentifier = &cfnid
 
-	// putting required parameters into parameter store (needed for read operation)
+	// the api keys might have been updated therefore we need to do this here
 	_, err = putParameterIntoParameterStore(currentModel.ClusterCfnIdentifier, &ParameterToBePersistedSpec{ApiKeys: currentModel.ApiKeys, ProjectId: currentModel.ProjectId, ClusterName: currentModel.Name}, req.Session)
 	if err != nil {
-		return handler.ProgressEvent{}, fmt.Errorf("error when putting api keys into parameter store: %s", err)
+		return handler.ProgressEvent{}, fmt.Errorf("error when putting api keys into parameter store: %s", err)
+	}
+
+	return handler.ProgressEvent{
+		OperationStatus:      handler.InProgress,
+		Message:              "Test Failover `REPAIRING`",
+		ResourceModel:        currentModel,
+		CallbackDelaySeconds: 65,
+		CallbackContext: map[string]interface{}{
+			"stateName": "REPAIRING",
+		},
+	}, nil
+}
+
+// failoverRequested reports whether FailoverTrigger got a new value, removing it triggers nothing
+func failoverRequested(prevModel *Model, currentModel *Model) bool {
+	return currentModel.FailoverTrigger != nil && stringValue(prevModel.FailoverTrigger) != *currentModel.FailoverTrigger
+}
+
+// isTenantCluster reports whether the cluster runs on a shared tier
+func isTenantCluster(cluster *mongodbatlas.Cluster) bool {
+	ps := cluster.ProviderSettings
+	return ps != nil && (ps.ProviderName == "TENANT" || isSharedTier(ps.InstanceSizeName))
+}
+
+func isSharedTier(instanceSizeName string) bool {
+	return instanceSizeName == "M0" || instanceSizeName == "M2" || instanceSizeName == "M5"
+}
+
+// tierRank orders the shared tiers below every dedicated tier, dedicated tiers all share the same rank
+// because any shared tier can be upgraded to any of them
+func tierRank(instanceSizeName string) int {
+	switch instanceSizeName {
+	case "M0":
+		return 0
+	case "M2":
+		return 1
+	case "M5":
+		return 2
+	}
+	return 3
+}
+
+// changedCreateOnlyProperties lists the properties an update cannot change, the createOnlyProperties of the schema.
+// The cloud provider is not one of them, a tenant upgrade turns ProviderName TENANT into the backing provider and
+// Atlas moves dedicated clusters between cloud providers.
+func changedCreateOnlyProperties(prevModel *Model, currentModel *Model) []string {
+	var fields []string
+	if prevModel.Name != nil && stringValue(prevModel.Name) != stringValue(currentModel.Name) {
+		fields = append(fields, "Name")
+	}
+	if prevModel.ProjectId != nil && stringValue(prevModel.ProjectId) != stringValue(currentModel.ProjectId) {
+		fields = append(fields, "ProjectId")
+	}
+	return fields
+}
+
+// clusterSettingsChanged reports whether anything besides Paused, FailoverTrigger, the api keys, the handler only settings and read only
+// properties differs between the models
+func clusterSettingsChanged(prevModel *Model, currentModel *Model) bool {
+	return !reflect.DeepEqual(settingsOf(prevModel), settingsOf(currentModel))
+}
+
+func settingsOf(model *Model) Model {
+	settings := *model
+	settings.ApiKeys = nil
+	settings.Paused = nil
+	settings.ClusterCfnIdentifier = nil
+	settings.SrvConnectionString = nil
+	settings.ConnectionString = nil
+	settings.PrivateConnectionString = nil
+	settings.PrivateSrvConnectionString = nil
+	settings.PrivateEndpointConnectionStrings = nil
+	settings.Id = nil
+	settings.MongoDBVersion = nil
+	settings.MongoURI = nil
+	settings.MongoURIUpdated = nil
+	settings.MongoURIWithOptions = nil
+	settings.SrvAddress = nil
+	settings.StateName = nil
+	settings.StabilizationTimeoutMinutes = nil
+	settings.TerminationProtectionEnabled = nil
+	settings.FinalSnapshot = nil
+	settings.FailoverTrigger = nil
+	return settings
+}
+
+// Delete handles the Delete event from the Cloudformation service.
+func Delete(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	client, err := util.CreateMongoDBClient(*currentModel.ApiKeys.PublicKey, *currentModel.ApiKeys.PrivateKey)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	if _, ok := req.CallbackContext["stateName"]; ok {
+		progress, err := validateProgress(client, req, currentModel, "DELETED", "DELETING", "IDLE")
+		if snapshotID, ok := req.CallbackContext["finalSnapshotId"]; ok && err == nil && progress.OperationStatus == handler.Success {
+			progress.Message = fmt.Sprintf("Complete, final snapshot %s", cast.ToString(snapshotID))
+		}
+		return progress, err
+	}
+
+	projectID := *currentModel.ProjectId
+	clusterName := *currentModel.Name
+
+	var finalSnapshotID string
+	if snapshotID, ok := req.CallbackContext["finalSnapshotId"]; ok {
+		progress := validateFinalSnapshot(client, req, currentModel, cast.ToString(snapshotID))
+		if progress.OperationStatus != handler.Success {
+			return progress, nil
+		}
+		finalSnapshotID = cast.ToString(snapshotID)
+	} else {
+		// refuse before touching anything, the parameter store entry is still needed to manage the kept cluster
+		if currentModel.TerminationProtectionEnabled != nil && *currentModel.TerminationProtectionEnabled {
+			return handler.ProgressEvent{
+				OperationStatus:  handler.Failed,
+				Message:          fmt.Sprintf("cluster (%s) has TerminationProtectionEnabled set to true, set it to false before deleting or replacing the cluster", clusterName),
+				HandlerErrorCode: "InvalidRequest",
+			}, nil
+		}
+
+		if currentModel.FinalSnapshot != nil {
+			return takeFinalSnapshot(client, currentModel)
+		}
+	}
+
+	_, err = client.Clusters.Delete(context.Background(), projectID, clusterName)
+	if err != nil {
+		// even when error occurs when deleting, we still want to delete parameter from parameter store
+		_, errParams := deleteParameterFromParameterStore(currentModel.ClusterCfnIdentifier, req.Session)
+		if errParams != nil {
+			return handler.ProgressEvent{
+				OperationStatus:  handler.Failed,
+				Message:          "Delete Failed",
+				HandlerErrorCode: "GeneralServiceException",
+			}, fmt.Errorf("Error deleting cluster with name(%s): %s.\nError deleting api keys from parameter store: %s", clusterName, err, errParams)
+		}
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          "Delete Failed",
+			HandlerErrorCode: "GeneralServiceException",
+		}, fmt.Errorf("error deleting cluster with name (%s): %s", clusterName, err)
+	}
+
+	_, err = deleteParameterFromParameterStore(currentModel.ClusterCfnIdentifier, req.Session)
+	if err != nil {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          "Delete Failed",
+			HandlerErrorCode: "GeneralServiceException",
+		}, fmt.Errorf("error deleting parameters for cluster %s: %s", clusterName, err)
+	}
+
+	callbackContext := map[string]interface{}{
+		"stateName": "DELETING",
+	}
+	if finalSnapshotID != "" {
+		callbackContext["finalSnapshotId"] = finalSnapshotID
+	}
+
+	return handler.ProgressEvent{
+		OperationStatus:      handler.InProgress,
+		Message:              "Delete In Progress",
+		ResourceModel:        currentModel,
+		CallbackDelaySeconds: 65,
+		CallbackContext:      callbackContext,
+	}, nil
+}
+
+// takeFinalSnapshot requests the on-demand snapshot that Delete waits for before removing the cluster
+func takeFinalSnapshot(client *mongodbatlas.Client, currentModel *Model) (handler.ProgressEvent, error) {
+	clusterName := *currentModel.Name
+
+	snapshotRequest := &mongodbatlas.CloudProviderSnapshot{
+		Description:     cast.ToString(currentModel.FinalSnapshot.Description),
+		RetentionInDays: cast.ToInt(currentModel.FinalSnapshot.RetentionInDays),
+	}
+	if snapshotRequest.Description == "" {
+		snapshotRequest.Description = fmt.Sprintf("final snapshot of %s", clusterName)
+	}
+
+	params := &mongodbatlas.SnapshotReqPathParameters{
+		GroupID:     *currentModel.ProjectId,
+		ClusterName: clusterName,
+	}
+	snapshot, _, err := client.CloudProviderSnapshots.Create(context.Background(), params, snapshotRequest)
+	if err != nil {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          fmt.Sprintf("error taking final snapshot of cluster (%s), the cluster was not deleted: %s", clusterName, err),
+			HandlerErrorCode: "GeneralServiceException",
+		}, nil
 	}
 
 	return handler.ProgressEvent{
 		OperationStatus:      handler.InProgress,
-		Message:              fmt.Sprintf("Create Cluster `%s`", cluster.StateName),
+		Message:              fmt.Sprintf("Final Snapshot `%s`", snapshot.Status),
 		ResourceModel:        currentModel,
 		CallbackDelaySeconds: 65,
 		CallbackContext: map[string]interface{}{
-			"stateName": cluster.StateName,
+			"finalSnapshotId": snapshot.ID,
+			"startTime":       time.Now().UTC().Format(time.RFC3339),
 		},
 	}, nil
 }
 
-// Read handles the Read event from the Cloudformation service.
-func Read(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
-	params, err := getParameterFromParameterStore(currentModel.ClusterCfnIdentifier, req.Session)
-	if err != nil {
-		return handler.ProgressEvent{}, err
-	}
+// validateFinalSnapshot polls the final snapshot, Success means the cluster can be deleted
+func validateFinalSnapshot(client *mongodbatlas.Client, req handler.Request, currentModel *Model, snapshotID string) handler.ProgressEvent {
+	clusterName := *currentModel.Name
 
-	client, err := util.CreateMongoDBClient(*params.ApiKeys.PublicKey, *params.ApiKeys.PrivateKey)
-	if err != nil {
-		return handler.ProgressEvent{}, err
+	params := &mongodbatlas.SnapshotReqPathParameters{
+		GroupID:     *currentModel.ProjectId,
+		ClusterName: clusterName,
+		SnapshotID:  snapshotID,
 	}
-
-	cluster, _, err := client.Clusters.Get(context.Background(), *params.ProjectId, *params.ClusterName)
+	snapshot, _, err := client.CloudProviderSnapshots.GetOneCloudProviderSnapshot(context.Background(), params)
 	if err != nil {
-		return handler.ProgressEvent{}, fmt.Errorf("error fetching cluster info (%s): %s", *params.ClusterName, err)
-	}
-
-	currentModel.AutoScaling = &AutoScaling{
-		DiskGBEnabled: cluster.AutoScaling.DiskGBEnabled,
-		Compute: &AutoScalingCompute{
-			ScaleDownEnabled: cluster.AutoScaling.Compute.ScaleDownEnabled,
-			Enabled:          cluster.AutoScaling.Compute.Enabled,
-		},
-	}
-
-	currentModel.BackupEnabled = cluster.BackupEnabled
-	currentModel.PitEnabled = cluster.PitEnabled
-
-	currentModel.BiConnector = &BiConnector{
-		ReadPreference: &cluster.BiConnector.ReadPreference,
-		Enabled:        cluster.BiConnector.Enabled,
-	}
-
-	currentModel.ProviderBackupEnabled = cluster.ProviderBackupEnabled
-	currentModel.ClusterType = &cluster.ClusterType
-	currentModel.DiskSizeGB = cluster.DiskSizeGB
-	currentModel.EncryptionAtRestProvider = &cluster.EncryptionAtRestProvider
-	currentModel.MongoDBMajorVersion = &cluster.MongoDBMajorVersion
-
-	if cluster.NumShards != nil {
-		currentModel.NumShards = castNO64(cluster.NumShards)
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          fmt.Sprintf("error fetching final snapshot (%s) of cluster (%s), the cluster was not deleted: %s", snapshotID, clusterName, err),
+			HandlerErrorCode: "GeneralServiceException",
+		}
 	}
 
-	currentModel.MongoDBVersion = &cluster.MongoDBVersion
-	currentModel.MongoURI = &cluster.MongoURI
-	currentModel.MongoURIUpdated = &cluster.MongoURIUpdated
-	currentModel.MongoURIWithOptions = &cluster.MongoURIWithOptions
-	currentModel.Paused = cluster.Paused
-	currentModel.SrvAddress = &cluster.SrvAddress
-	currentModel.StateName = &cluster.StateName
-
-	if &cluster.ConnectionStrings.PrivateSrv != nil {
-		currentModel.ConnectionString = &cluster.ConnectionStrings.Standard
-	}
-	if &cluster.ConnectionStrings.StandardSrv != nil {
-		currentModel.SrvConnectionString = &cluster.ConnectionStrings.StandardSrv
+	switch snapshot.Status {
+	case "completed":
+		p := handler.NewProgressEvent()
+		p.ResourceModel = currentModel
+		p.OperationStatus = handler.Success
+		return p
+	case "queued", "inProgress":
+	default:
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          fmt.Sprintf("final snapshot (%s) of cluster (%s) ended with status %s, the cluster was not deleted", snapshotID, clusterName, snapshot.Status),
+			HandlerErrorCode: "GeneralServiceException",
+		}
 	}
 
-	currentModel.BiConnector = &BiConnector{
-		ReadPreference: &cluster.BiConnector.ReadPreference,
-		Enabled:        cluster.BiConnector.Enabled,
+	startTime := time.Now().UTC()
+	if started, ok := req.CallbackContext["startTime"]; ok {
+		if t, err := time.Parse(time.RFC3339, cast.ToString(started)); err == nil {
+			startTime = t
+		}
 	}
+	pollCount := cast.ToInt(req.CallbackContext["pollCount"]) + 1
 
-	currentModel.Id = &cluster.ID
-
-	if cluster.ProviderSettings != nil {
-		currentModel.ProviderSettings = &ProviderSettings{
-			BackingProviderName: &cluster.ProviderSettings.BackingProviderName,
-			ProviderName:        &cluster.ProviderSettings.ProviderName,
-			AutoScaling: &AutoScalingProvider{
-				Compute: &AutoScalingProviderCompute{
-					MinInstanceSize: &cluster.ProviderSettings.AutoScaling.Compute.MinInstanceSize,
-					MaxInstanceSize: &cluster.ProviderSettings.AutoScaling.Compute.MaxInstanceSize,
-				},
-			},
-			InstanceSizeName: &cluster.ProviderSettings.InstanceSizeName,
-			RegionName:       &cluster.ProviderSettings.RegionName,
+	timeout := stabilizationTimeout(currentModel)
+	if time.Since(startTime) > timeout {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          fmt.Sprintf("final snapshot (%s) of cluster (%s) did not complete within %s, still %s after %d polls, the cluster was not deleted", snapshotID, clusterName, timeout, snapshot.Status, pollCount),
+			HandlerErrorCode: "NotStabilized",
 		}
 	}
 
-	currentModel.ReplicationSpecs = flattenReplicationSpecs(cluster.ReplicationSpecs)
-
-	return handler.ProgressEvent{
-		OperationStatus: handler.Success,
-		Message:         "Read Complete",
-		ResourceModel:   currentModel,
-	}, nil
+	p := handler.NewProgressEvent()
+	p.ResourceModel = currentModel
+	p.OperationStatus = handler.InProgress
+	p.CallbackDelaySeconds = 60
+	p.Message = fmt.Sprintf("Final Snapshot `%s`", snapshot.Status)
+	p.CallbackContext = map[string]interface{}{
+		"finalSnapshotId": snapshotID,
+		"startTime":       startTime.Format(time.RFC3339),
+		"pollCount":       pollCount,
+	}
+	return p
 }
 
-// Update handles the Update event from the Cloudformation service.
-func Update(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+// List handles the List event from the Cloudformation service.
+func List(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
 	client, err := util.CreateMongoDBClient(*currentModel.ApiKeys.PublicKey, *currentModel.ApiKeys.PrivateKey)
 	if err != nil {
 		return handler.ProgressEvent{}, err
 	}
 
-	if _, ok := req.CallbackContext["stateName"]; ok {
-		return validateProgress(client, req, currentModel, "IDLE", "UPDATING")
-	}
-
 	projectID := *currentModel.ProjectId
-	clusterName := *currentModel.Name
 
-	currentClusterInfo, _, err := client.Clusters.Get(context.Background(), projectID, clusterName)
+	clusters, err := listAllClusters(client, projectID)
 	if err != nil {
-		return handler.ProgressEvent{}, fmt.Errorf("error fetching cluster info (%s): %s", clusterName, err)
+		return handler.ProgressEvent{}, fmt.Errorf("error listing clusters for project (%s): %s", projectID, err)
 	}
 
-	preUpdateClusterSize := currentClusterInfo.ProviderSettings.InstanceSizeName
-	desiredClusterSize := *currentModel.ProviderSettings.InstanceSizeName
+	models := make([]interface{}, 0, len(clusters))
+	for i := range clusters {
+		model := flattenCluster(&clusters[i])
+		model.ProjectId = currentModel.ProjectId
 
-	if preUpdateClusterSize == "M2" || preUpdateClusterSize == "M5" {
-		if desiredClusterSize != preUpdateClusterSize {
-			return handler.ProgressEvent{
-				OperationStatus:  handler.Failed,
-				Message:          "Update Failed",
-				HandlerErrorCode: "GeneralServiceException",
-			}, fmt.Errorf("Error updating cluster with name \"%s\": You cannot update cluster tier of SHARED tier (M2, M5) clusters.", clusterName)
-		}
-		// if cluster is SHARED tier, we simply complete without invoking update API (as it is forbidden by Atlas)
-		// this "silent" completion without performing UPDATE operation, should not cause problem
-		// this is to avoid errors when i.e. tags are added cloudformation stack and update of the cluster is triggered
-		return handler.ProgressEvent{
-			OperationStatus: handler.Success,
-			Message:         "Complete",
-			ResourceModel:   currentModel,
-		}, nil
+		cfnid := buildClusterCfnIdentifier(model.ProjectId, model.Name)
+		model.ClusterCfnIdentifier = &cfnid
+
+		models = append(models, *model)
 	}
 
-	if len(currentModel.ReplicationSpecs) > 0 {
-		if currentModel.ClusterType != nil {
-			return handler.ProgressEvent{}, errors.New("error updating cluster: ClusterType should be set when `ReplicationSpecs` is set")
+	return handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		Message:         "List Complete",
+		ResourceModels:  models,
+	}, nil
+}
+
+// listAllClusters pages through the clusters of the project until Atlas reports the last page
+func listAllClusters(client *mongodbatlas.Client, projectID string) ([]mongodbatlas.Cluster, error) {
+	const itemsPerPage = 100
+
+	var clusters []mongodbatlas.Cluster
+	for pageNum := 1; ; pageNum++ {
+		page, resp, err := client.Clusters.List(context.Background(), projectID, &mongodbatlas.ListOptions{
+			PageNum:      pageNum,
+			ItemsPerPage: itemsPerPage,
+		})
+		if err != nil {
+			return nil, err
 		}
 
-		if currentModel.NumShards != nil {
-			return handler.ProgressEvent{}, errors.New("error updating cluster: NumShards should be set when `ReplicationSpecs` is set")
+		clusters = append(clusters, page...)
+
+		if len(page) < itemsPerPage || resp == nil || resp.IsLastPage() {
+			return clusters, nil
 		}
 	}
+}
 
+// expandCluster builds the Atlas request for the settings of the model, Create and Update send the same request
+func expandCluster(currentModel *Model) *mongodbatlas.Cluster {
 	var autoScaling *mongodbatlas.AutoScaling
 	if currentModel.AutoScaling != nil {
 		autoScaling = &mongodbatlas.AutoScaling{
@@ -296,7 +837,10 @@ func Update(req handler.Request, prevModel *Model, currentModel *Model) (handler
 		EncryptionAtRestProvider: cast.ToString(currentModel.EncryptionAtRestProvider),
 		ClusterType:              cast.ToString(currentModel.ClusterType),
 		AutoScaling:              autoScaling,
-		NumShards:                cast64(currentModel.NumShards),
+	}
+
+	if currentModel.NumShards != nil {
+		clusterRequest.NumShards = cast64(currentModel.NumShards)
 	}
 
 	if currentModel.BackupEnabled != nil {
@@ -331,94 +875,80 @@ func Update(req handler.Request, prevModel *Model, currentModel *Model) (handler
 		clusterRequest.ReplicationSpecs = expandReplicationSpecs(currentModel.ReplicationSpecs)
 	}
 
-	cluster, _, err := client.Clusters.Update(context.Background(), projectID, clusterName, clusterRequest)
-	if err != nil {
-		return handler.ProgressEvent{}, fmt.Errorf("error creating cluster: %s", err)
-	}
-
-	currentModel.Id = &cluster.ID
+	clusterRequest.Labels = expandLabels(currentModel.Labels)
 
-	cfnid := buildClusterCfnIdentifier(currentModel.ProjectId, currentModel.Name)
+	return clusterRequest
+}
 
-	currentModel.ClusterCfnIdentifier = &cfnid
+// expandClusterChanges builds an Atlas request holding only the settings that differ between the models, blocks such
+// as ProviderSettings are sent whole when any of their fields changed. The flag reports whether there is anything to send.
+func expandClusterChanges(prevModel *Model, currentModel *Model) (*mongodbatlas.Cluster, bool) {
+	prev := expandCluster(prevModel)
+	current := expandCluster(currentModel)
 
-	// putting required parameter into parameter store
-	// the api keys might have been updated therefore we need to do this here
-	_, err = putParameterIntoParameterStore(currentModel.ClusterCfnIdentifier, &ParameterToBePersistedSpec{ApiKeys: currentModel.ApiKeys, ProjectId: currentModel.ProjectId, ClusterName: currentModel.Name}, req.Session)
-	if err != nil {
-		return handler.ProgressEvent{}, fmt.Errorf("error when putting api keys into parameter store: %s", err)
+	changes := &mongodbatlas.Cluster{
+		Name: current.Name,
 	}
+	changed := false
 
-	return handler.ProgressEvent{
-		OperationStatus:      handler.InProgress,
-		Message:              fmt.Sprintf("Update Cluster `%s`", cluster.StateName),
-		ResourceModel:        currentModel,
-		CallbackDelaySeconds: 65,
-		CallbackContext: map[string]interface{}{
-			"stateName": cluster.StateName,
-		},
-	}, nil
-}
-
-// Delete handles the Delete event from the Cloudformation service.
-func Delete(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
-	client, err := util.CreateMongoDBClient(*currentModel.ApiKeys.PublicKey, *currentModel.ApiKeys.PrivateKey)
-	if err != nil {
-		return handler.ProgressEvent{}, err
+	if !reflect.DeepEqual(prev.AutoScaling, current.AutoScaling) {
+		changes.AutoScaling = current.AutoScaling
+		changed = true
 	}
-
-	if _, ok := req.CallbackContext["stateName"]; ok {
-		return validateProgress(client, req, currentModel, "DELETED", "DELETING")
+	if !reflect.DeepEqual(prev.BackupEnabled, current.BackupEnabled) {
+		changes.BackupEnabled = current.BackupEnabled
+		changed = true
 	}
-
-	projectID := *currentModel.ProjectId
-	clusterName := *currentModel.Name
-
-	_, err = client.Clusters.Delete(context.Background(), projectID, clusterName)
-	if err != nil {
-		// even when error occurs when deleting, we still want to delete parameter from parameter store
-		_, errParams := deleteParameterFromParameterStore(currentModel.ClusterCfnIdentifier, req.Session)
-		if errParams != nil {
-			return handler.ProgressEvent{
-				OperationStatus:  handler.Failed,
-				Message:          "Delete Failed",
-				HandlerErrorCode: "GeneralServiceException",
-			}, fmt.Errorf("Error deleting cluster with name(%s): %s.\nError deleting api keys from parameter store: %s", clusterName, err, errParams)
-		}
-		return handler.ProgressEvent{
-			OperationStatus:  handler.Failed,
-			Message:          "Delete Failed",
-			HandlerErrorCode: "GeneralServiceException",
-		}, fmt.Errorf("error deleting cluster with name (%s): %s", clusterName, err)
+	if !reflect.DeepEqual(prev.BiConnector, current.BiConnector) {
+		changes.BiConnector = current.BiConnector
+		changed = true
 	}
-
-	_, err = deleteParameterFromParameterStore(currentModel.ClusterCfnIdentifier, req.Session)
-	if err != nil {
-		return handler.ProgressEvent{
-			OperationStatus:  handler.Failed,
-			Message:          "Delete Failed",
-			HandlerErrorCode: "GeneralServiceException",
-		}, fmt.Errorf("error deleting parameters for cluster %s: %s", clusterName, err)
+	if prev.ClusterType != current.ClusterType {
+		changes.ClusterType = current.ClusterType
+		changed = true
+	}
+	if !reflect.DeepEqual(prev.DiskSizeGB, current.DiskSizeGB) {
+		changes.DiskSizeGB = current.DiskSizeGB
+		changed = true
+	}
+	if prev.EncryptionAtRestProvider != current.EncryptionAtRestProvider {
+		changes.EncryptionAtRestProvider = current.EncryptionAtRestProvider
+		changed = true
+	}
+	if !reflect.DeepEqual(prev.Labels, current.Labels) && len(current.Labels) > 0 {
+		changes.Labels = current.Labels
+		changed = true
+	}
+	if prev.MongoDBMajorVersion != current.MongoDBMajorVersion {
+		changes.MongoDBMajorVersion = current.MongoDBMajorVersion
+		changed = true
+	}
+	if !reflect.DeepEqual(prev.NumShards, current.NumShards) {
+		changes.NumShards = current.NumShards
+		changed = true
+	}
+	if !reflect.DeepEqual(prev.PitEnabled, current.PitEnabled) {
+		changes.PitEnabled = current.PitEnabled
+		changed = true
+	}
+	if !reflect.DeepEqual(prev.ProviderBackupEnabled, current.ProviderBackupEnabled) {
+		changes.ProviderBackupEnabled = current.ProviderBackupEnabled
+		changed = true
+	}
+	if !reflect.DeepEqual(prev.ProviderSettings, current.ProviderSettings) {
+		changes.ProviderSettings = current.ProviderSettings
+		changed = true
+	}
+	if !reflect.DeepEqual(prev.ReplicationSpecs, current.ReplicationSpecs) {
+		changes.ReplicationSpecs = current.ReplicationSpecs
+		changed = true
 	}
 
-	return handler.ProgressEvent{
-		OperationStatus:      handler.InProgress,
-		Message:              "Delete In Progress",
-		ResourceModel:        currentModel,
-		CallbackDelaySeconds: 65,
-		CallbackContext: map[string]interface{}{
-			"stateName": "DELETING",
-		},
-	}, nil
+	return changes, changed
 }
 
-// List NOOP
-func List(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
-	return handler.ProgressEvent{
-		OperationStatus: handler.Success,
-		Message:         "List Complete",
-		ResourceModel:   currentModel,
-	}, nil
+func isTrue(b *bool) bool {
+	return b != nil && *b
 }
 
 func expandBiConnector(biConnector *BiConnector) *mongodbatlas.BiConnector {
@@ -484,6 +1014,33 @@ func expandRegionsConfig(regions []RegionConfig) map[string]mongodbatlas.Regions
 	return regionsConfig
 }
 
+func expandLabels(labels []Labels) []mongodbatlas.Label {
+	var res []mongodbatlas.Label
+	for _, l := range labels {
+		res = append(res, mongodbatlas.Label{
+			Key:   cast.ToString(l.Key),
+			Value: cast.ToString(l.Value),
+		})
+	}
+	return res
+}
+
+// removeAllLabels clears the labels of the cluster, mongodbatlas.Cluster omits an empty label list so it cannot express this
+func removeAllLabels(client *mongodbatlas.Client, projectID, clusterName string) error {
+	path := fmt.Sprintf("groups/%s/clusters/%s", projectID, url.PathEscape(clusterName))
+	body := map[string]interface{}{
+		"labels": []mongodbatlas.Label{},
+	}
+
+	req, err := client.NewRequest(context.Background(), http.MethodPatch, path, body)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.Do(context.Background(), req, nil)
+	return err
+}
+
 func formatMongoDBMajorVersion(val interface{}) string {
 	if strings.Contains(val.(string), ".") {
 		return val.(string)
@@ -491,70 +1048,231 @@ func formatMongoDBMajorVersion(val interface{}) string {
 	return fmt.Sprintf("%.1f", cast.ToFloat32(val))
 }
 
-func flattenReplicationSpecs(rSpecs []mongodbatlas.ReplicationSpec) []ReplicationSpec {
-	specs := make([]ReplicationSpec, 0)
-	for _, rSpec := range rSpecs {
-		spec := ReplicationSpec{
-			ID:            &rSpec.ID,
-			NumShards:     castNO64(rSpec.NumShards),
-			ZoneName:      &rSpec.ZoneName,
-			RegionsConfig: flattenRegionsConfig(rSpec.RegionsConfig),
-		}
-		specs = append(specs, spec)
+// processArgs are the advanced configuration options of a cluster, mongodbatlas.ProcessArgs lacks several of them
+type processArgs struct {
+	JavascriptEnabled         *bool    `json:"javascriptEnabled,omitempty"`
+	MinimumEnabledTLSProtocol string   `json:"minimumEnabledTlsProtocol,omitempty"`
+	NoTableScan               *bool    `json:"noTableScan,omitempty"`
+	OplogSizeMB               *int64   `json:"oplogSizeMB,omitempty"`
+	OplogMinRetentionHours    *float64 `json:"oplogMinRetentionHours,omitempty"`
+	SampleSizeBIConnector     *int64   `json:"sampleSizeBIConnector,omitempty"`
+	DefaultReadConcern        string   `json:"defaultReadConcern,omitempty"`
+	DefaultWriteConcern       string   `json:"defaultWriteConcern,omitempty"`
+}
+
+func processArgsPath(projectID, clusterName string) string {
+	return fmt.Sprintf("groups/%s/clusters/%s/processArgs", projectID, url.PathEscape(clusterName))
+}
+
+func getProcessArgs(client *mongodbatlas.Client, projectID, clusterName string) (*processArgs, error) {
+	req, err := client.NewRequest(context.Background(), http.MethodGet, processArgsPath(projectID, clusterName), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	root := new(processArgs)
+	_, err = client.Do(context.Background(), req, root)
+	if err != nil {
+		return nil, err
 	}
-	return specs
+	return root, nil
 }
 
-func flattenRegionsConfig(regionsConfig map[string]mongodbatlas.RegionsConfig) []RegionConfig {
-	regions := make([]RegionConfig, 0)
+func updateProcessArgs(client *mongodbatlas.Client, projectID, clusterName string, args *processArgs) (*processArgs, error) {
+	req, err := client.NewRequest(context.Background(), http.MethodPatch, processArgsPath(projectID, clusterName), args)
+	if err != nil {
+		return nil, err
+	}
 
-	for regionName, regionConfig := range regionsConfig {
-		region := RegionConfig{
-			RegionName:     &regionName,
-			Priority:       castNO64(regionConfig.Priority),
-			AnalyticsNodes: castNO64(regionConfig.AnalyticsNodes),
-			ElectableNodes: castNO64(regionConfig.ElectableNodes),
-			ReadOnlyNodes:  castNO64(regionConfig.ReadOnlyNodes),
-		}
-		regions = append(regions, region)
+	root := new(processArgs)
+	_, err = client.Do(context.Background(), req, root)
+	if err != nil {
+		return nil, err
 	}
-	return regions
+	return root, nil
 }
 
-func validateProgress(client *mongodbatlas.Client, req handler.Request, currentModel *Model, targetState string, pendingState string) (handler.ProgressEvent, error) {
-	isReady, state, err := isClusterInTargetState(client, *currentModel.ProjectId, *currentModel.Name, targetState)
+// applyAdvancedConfigurationAfterCreate sets the process args once the created cluster is IDLE and waits for the cluster to settle again
+func applyAdvancedConfigurationAfterCreate(client *mongodbatlas.Client, req handler.Request, currentModel *Model, progress handler.ProgressEvent) (handler.ProgressEvent, error) {
+	if currentModel.AdvancedConfiguration == nil {
+		return progress, nil
+	}
+	if _, ok := req.CallbackContext["advancedConfigurationApplied"]; ok {
+		return progress, nil
+	}
+
+	_, err := updateProcessArgs(client, *currentModel.ProjectId, *currentModel.Name, expandAdvancedConfiguration(currentModel.AdvancedConfiguration))
 	if err != nil {
-		return handler.ProgressEvent{}, err
+		return handler.ProgressEvent{}, fmt.Errorf("error setting advanced configuration of cluster (%s): %s", *currentModel.Name, err)
+	}
+
+	return handler.ProgressEvent{
+		OperationStatus:      handler.InProgress,
+		Message:              "Apply Advanced Configuration",
+		ResourceModel:        currentModel,
+		CallbackDelaySeconds: 65,
+		CallbackContext: map[string]interface{}{
+			"stateName":                    "UPDATING",
+			"advancedConfigurationApplied": true,
+		},
+	}, nil
+}
+
+func expandAdvancedConfiguration(advancedConfiguration *AdvancedConfiguration) *processArgs {
+	args := &processArgs{
+		JavascriptEnabled:         advancedConfiguration.JavascriptEnabled,
+		MinimumEnabledTLSProtocol: cast.ToString(advancedConfiguration.MinimumEnabledTlsProtocol),
+		NoTableScan:               advancedConfiguration.NoTableScan,
+		OplogMinRetentionHours:    advancedConfiguration.OplogMinRetentionHours,
+		DefaultReadConcern:        cast.ToString(advancedConfiguration.DefaultReadConcern),
+		DefaultWriteConcern:       cast.ToString(advancedConfiguration.DefaultWriteConcern),
+	}
+	if advancedConfiguration.OplogSizeMB != nil {
+		args.OplogSizeMB = cast64(advancedConfiguration.OplogSizeMB)
+	}
+	if advancedConfiguration.SampleSizeBIConnector != nil {
+		args.SampleSizeBIConnector = cast64(advancedConfiguration.SampleSizeBIConnector)
+	}
+	return args
+}
+
+func flattenAdvancedConfiguration(args *processArgs) *AdvancedConfiguration {
+	advancedConfiguration := &AdvancedConfiguration{
+		JavascriptEnabled:      args.JavascriptEnabled,
+		NoTableScan:            args.NoTableScan,
+		OplogMinRetentionHours: args.OplogMinRetentionHours,
+	}
+	if args.MinimumEnabledTLSProtocol != "" {
+		advancedConfiguration.MinimumEnabledTlsProtocol = &args.MinimumEnabledTLSProtocol
+	}
+	if args.OplogSizeMB != nil {
+		advancedConfiguration.OplogSizeMB = castNO64(args.OplogSizeMB)
+	}
+	if args.SampleSizeBIConnector != nil {
+		advancedConfiguration.SampleSizeBIConnector = castNO64(args.SampleSizeBIConnector)
+	}
+	if args.DefaultReadConcern != "" {
+		advancedConfiguration.DefaultReadConcern = &args.DefaultReadConcern
+	}
+	if args.DefaultWriteConcern != "" {
+		advancedConfiguration.DefaultWriteConcern = &args.DefaultWriteConcern
+	}
+	return advancedConfiguration
+}
+
+// defaultStabilizationTimeoutMinutes is how long the callbacks poll a cluster when StabilizationTimeoutMinutes is not set
+const defaultStabilizationTimeoutMinutes = 180
+
+// validateProgress polls the cluster until it reaches targetState and the paused flag or tier the callback context
+// waits for, see progressOfState
+func validateProgress(client *mongodbatlas.Client, req handler.Request, currentModel *Model, targetState string, pendingStates ...string) (handler.ProgressEvent, error) {
+	clusterName := *currentModel.Name
+
+	target := clusterTarget{stateName: targetState}
+	if paused, ok := req.CallbackContext["paused"]; ok {
+		p := cast.ToBool(paused)
+		target.paused = &p
+	}
+	if instanceSizeName, ok := req.CallbackContext["instanceSizeName"]; ok {
+		target.instanceSizeName = cast.ToString(instanceSizeName)
+	}
+
+	isReady, state, err := isClusterInTargetState(client, *currentModel.ProjectId, clusterName, target)
+	if err != nil {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          fmt.Sprintf("error waiting for cluster (%s) to reach state %s: %s", clusterName, targetState, err),
+			HandlerErrorCode: "GeneralServiceException",
+		}, nil
 	}
 
-	if !isReady {
+	return progressOfState(req, currentModel, isReady, state, targetState, pendingStates), nil
+}
+
+// progressOfState turns the polled state into the next progress event, any state that is neither the target nor one
+// of pendingStates fails the operation, as does waiting longer than the stabilization timeout
+func progressOfState(req handler.Request, currentModel *Model, isReady bool, state string, targetState string, pendingStates []string) handler.ProgressEvent {
+	clusterName := *currentModel.Name
+
+	startTime := time.Now().UTC()
+	if started, ok := req.CallbackContext["startTime"]; ok {
+		if t, err := time.Parse(time.RFC3339, cast.ToString(started)); err == nil {
+			startTime = t
+		}
+	}
+	pollCount := cast.ToInt(req.CallbackContext["pollCount"]) + 1
+
+	if isReady {
 		p := handler.NewProgressEvent()
 		p.ResourceModel = currentModel
-		p.OperationStatus = handler.InProgress
-		p.CallbackDelaySeconds = 60
-		p.Message = "Pending"
-		p.CallbackContext = map[string]interface{}{
-			"stateName": state,
+		p.OperationStatus = handler.Success
+		p.Message = "Complete"
+		return p
+	}
+
+	if state != targetState && !contains(pendingStates, state) {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          fmt.Sprintf("error waiting for cluster (%s) to reach state %s: unexpected state %s", clusterName, targetState, state),
+			HandlerErrorCode: "GeneralServiceException",
+		}
+	}
+
+	timeout := stabilizationTimeout(currentModel)
+	if time.Since(startTime) > timeout {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          fmt.Sprintf("cluster (%s) did not reach state %s within %s, still %s after %d polls", clusterName, targetState, timeout, state, pollCount),
+			HandlerErrorCode: "NotStabilized",
 		}
-		return p, nil
 	}
 
 	p := handler.NewProgressEvent()
 	p.ResourceModel = currentModel
-	p.OperationStatus = handler.Success
-	p.Message = "Complete"
-	return p, nil
+	p.OperationStatus = handler.InProgress
+	p.CallbackDelaySeconds = 60
+	p.Message = "Pending"
+	// keep what the handler stored in the context, only the state and the polling bookkeeping change between callbacks
+	p.CallbackContext = map[string]interface{}{}
+	for k, v := range req.CallbackContext {
+		p.CallbackContext[k] = v
+	}
+	p.CallbackContext["stateName"] = state
+	p.CallbackContext["startTime"] = startTime.Format(time.RFC3339)
+	p.CallbackContext["pollCount"] = pollCount
+	return p
+}
+
+func stabilizationTimeout(currentModel *Model) time.Duration {
+	if currentModel.StabilizationTimeoutMinutes != nil && *currentModel.StabilizationTimeoutMinutes > 0 {
+		return time.Duration(*currentModel.StabilizationTimeoutMinutes) * time.Minute
+	}
+	return time.Duration(defaultStabilizationTimeoutMinutes) * time.Minute
 }
 
-func isClusterInTargetState(client *mongodbatlas.Client, projectID, clusterName, targetState string) (bool, string, error) {
+// clusterTarget describes the cluster a callback waits for, paused and instanceSizeName are only checked when set
+type clusterTarget struct {
+	stateName        string
+	paused           *bool
+	instanceSizeName string
+}
+
+func isClusterInTargetState(client *mongodbatlas.Client, projectID, clusterName string, target clusterTarget) (bool, string, error) {
 	cluster, resp, err := client.Clusters.Get(context.Background(), projectID, clusterName)
 	if err != nil {
 		if resp != nil && resp.StatusCode == 404 {
-			return "DELETED" == targetState, "DELETED", nil
+			return "DELETED" == target.stateName, "DELETED", nil
 		}
 		return false, "ERROR", fmt.Errorf("error fetching cluster info (%s): %s", clusterName, err)
 	}
-	return cluster.StateName == targetState, cluster.StateName, nil
+	if target.paused != nil && (cluster.Paused != nil && *cluster.Paused) != *target.paused {
+		return false, cluster.StateName, nil
+	}
+	// a tenant upgrade can report IDLE before it starts, the tier tells whether it already happened
+	if target.instanceSizeName != "" && (cluster.ProviderSettings == nil || cluster.ProviderSettings.InstanceSizeName != target.instanceSizeName) {
+		return false, cluster.StateName, nil
+	}
+	return cluster.StateName == target.stateName, cluster.StateName, nil
 }
 
 type ParameterToBePersistedSpec struct {
@@ -1,13 +1,22 @@
 package resource
 
 import (
+	"encoding/json"
 	"fmt"
 	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/422158/mongodbstpatlas-cloudformation-resources/cfn-resources/V1/cluster/cmd/testutil"
+	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
+	"go.mongodb.org/atlas/mongodbatlas"
 )
 
 const (
@@ -23,6 +32,10 @@ var (
 )
 
 func Test_CreatProject(t *testing.T) {
+	if publicKey == "" || privateKey == "" || projectID == "" {
+		t.Skipf("acceptance test, set %s, %s and %s to run it", publicKeyEnv, privateKeyEnv, projectIDEnv)
+	}
+
 	rand.Seed(time.Now().UnixNano())
 	id := rand.Int()
 	config := getConfiguration(id, publicKey, privateKey, projectID)
@@ -47,7 +60,6 @@ func getConfiguration(id int, publicKey, privateKey, projectID string) string {
 	"ProjectID": "%s",
 	"Name": "test-acc-cfn-cluster-%d",
 	"NumShards": 1,
-	"ProviderName": "AWS",
 	"ReplicationFactor": 3,
 	"ProviderBackupEnabled": false,
 	"AutoScaling": {
@@ -55,6 +67,7 @@ func getConfiguration(id int, publicKey, privateKey, projectID string) string {
 	},
 	"MongoDBVersion": "4.0",
 	"ProviderSettings": {
+		"ProviderName": "AWS",
 		"EncryptEBSVolume": false,
 		"InstanceSizeName": "M10",
 		"RegionName": "EU_CENTRAL_1",
@@ -66,3 +79,519 @@ func getConfiguration(id int, publicKey, privateKey, projectID string) string {
 	}
 }`, projectID, id, publicKey, privateKey)
 }
+
+func TestAdvancedConfigurationRoundTrip(t *testing.T) {
+	template := modelFromJSON(t, `{"AdvancedConfiguration": {
+		"JavascriptEnabled": false,
+		"MinimumEnabledTlsProtocol": "TLS1_2",
+		"NoTableScan": true,
+		"OplogSizeMB": 2048,
+		"OplogMinRetentionHours": 1.5,
+		"SampleSizeBIConnector": 500,
+		"DefaultReadConcern": "majority",
+		"DefaultWriteConcern": "majority"
+	}}`).AdvancedConfiguration
+
+	body, err := json.Marshal(expandAdvancedConfiguration(template))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// what Atlas answers is what it was sent
+	var args processArgs
+	if err := json.Unmarshal(body, &args); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := flattenAdvancedConfiguration(&args); !reflect.DeepEqual(template, got) {
+		t.Errorf("want %+v, got %+v", template, got)
+	}
+}
+
+func TestExpandAdvancedConfigurationOnlySendsSetOptions(t *testing.T) {
+	template := modelFromJSON(t, `{"AdvancedConfiguration": {"NoTableScan": false, "OplogSizeMB": 2048}}`).AdvancedConfiguration
+
+	body, err := json.Marshal(expandAdvancedConfiguration(template))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"noTableScan":false,"oplogSizeMB":2048}`; string(body) != want {
+		t.Errorf("want %s, got %s", want, body)
+	}
+}
+
+func TestApplyAdvancedConfigurationAfterCreateOnlyOnce(t *testing.T) {
+	progress := handler.ProgressEvent{OperationStatus: handler.Success, Message: "Complete"}
+
+	testCases := []struct {
+		name     string
+		template string
+		context  map[string]interface{}
+	}{
+		{"no advanced configuration", `{"Name": "plain"}`, map[string]interface{}{"stateName": "IDLE"}},
+		{"already applied", `{"Name": "tuned", "AdvancedConfiguration": {"NoTableScan": true}}`, map[string]interface{}{"stateName": "IDLE", "advancedConfigurationApplied": true}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// a nil client fails the test if the handler calls Atlas
+			got, err := applyAdvancedConfigurationAfterCreate(nil, handler.Request{CallbackContext: tc.context}, modelFromJSON(t, tc.template), progress)
+			if err != nil || !reflect.DeepEqual(progress, got) {
+				t.Errorf("want the progress passed through, got %+v, %v", got, err)
+			}
+		})
+	}
+}
+
+func TestListAllClustersPages(t *testing.T) {
+	const total = 150
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/groups/5f1ea7d9ab3c1c2f3e9b0a00/clusters" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		pageNum, _ := strconv.Atoi(r.URL.Query().Get("pageNum"))
+		itemsPerPage, _ := strconv.Atoi(r.URL.Query().Get("itemsPerPage"))
+
+		page := struct {
+			Links   []*mongodbatlas.Link   `json:"links"`
+			Results []mongodbatlas.Cluster `json:"results"`
+		}{}
+		for i := (pageNum - 1) * itemsPerPage; i < total && i < pageNum*itemsPerPage; i++ {
+			page.Results = append(page.Results, mongodbatlas.Cluster{Name: fmt.Sprintf("cluster-%d", i)})
+		}
+		if pageNum*itemsPerPage < total {
+			page.Links = append(page.Links, &mongodbatlas.Link{Rel: "next", Href: fmt.Sprintf("%s?pageNum=%d", r.URL.Path, pageNum+1)})
+		}
+		if err := json.NewEncoder(w).Encode(page); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer server.Close()
+
+	client := mongodbatlas.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	clusters, err := listAllClusters(client, "5f1ea7d9ab3c1c2f3e9b0a00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clusters) != total || clusters[total-1].Name != "cluster-149" {
+		t.Errorf("want all %d clusters, got %d", total, len(clusters))
+	}
+	if requests != 2 {
+		t.Errorf("want 2 pages requested, got %d", requests)
+	}
+}
+
+func TestFlattenClusterOfListedCluster(t *testing.T) {
+	// List only gets what Atlas reports, clusters that are still being created lack most blocks
+	model := flattenCluster(&mongodbatlas.Cluster{Name: "creating", StateName: "CREATING"})
+
+	if *model.Name != "creating" || *model.StateName != "CREATING" {
+		t.Errorf("want name and state, got %v and %v", model.Name, model.StateName)
+	}
+	if model.ProviderSettings != nil || model.ReplicationSpecs != nil || model.ConnectionString != nil || model.Labels != nil {
+		t.Errorf("want missing blocks left unset, got %+v", model)
+	}
+}
+
+func TestDecidePauseAction(t *testing.T) {
+	testCases := []struct {
+		name            string
+		isPaused        bool
+		desiredPaused   bool
+		settingsChanged bool
+		want            pauseAction
+		wantErr         bool
+	}{
+		{"running cluster", false, false, true, pauseActionApply, false},
+		{"pause", false, true, false, pauseActionSend, false},
+		{"resume", true, false, false, pauseActionSend, false},
+		{"stays paused", true, true, false, pauseActionNone, false},
+		{"pause with other settings", false, true, true, pauseActionApply, true},
+		{"resume with other settings", true, false, true, pauseActionApply, true},
+		{"settings of a paused cluster", true, true, true, pauseActionApply, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := decidePauseAction(tc.isPaused, tc.desiredPaused, tc.settingsChanged)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("want error %t, got %v", tc.wantErr, err)
+			}
+			if got != tc.want {
+				t.Errorf("want action %d, got %d", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestTierRank(t *testing.T) {
+	order := []string{"M0", "M2", "M5", "M10"}
+	for i := 1; i < len(order); i++ {
+		if tierRank(order[i-1]) >= tierRank(order[i]) {
+			t.Errorf("want %s ranked below %s", order[i-1], order[i])
+		}
+	}
+	if tierRank("M10") != tierRank("M40") {
+		t.Error("want every dedicated tier ranked the same")
+	}
+	if !isSharedTier("M2") || isSharedTier("M10") {
+		t.Error("want only M0, M2 and M5 to be shared tiers")
+	}
+}
+
+func TestTenantUpgradeRequest(t *testing.T) {
+	tenant := &mongodbatlas.Cluster{
+		Name: "starter",
+		ProviderSettings: &mongodbatlas.ProviderSettings{
+			ProviderName:        "TENANT",
+			BackingProviderName: "AWS",
+			InstanceSizeName:    "M2",
+			RegionName:          "US_EAST_1",
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		template string
+		size     string
+		want     mongodbatlas.ProviderSettings
+	}{
+		{
+			"larger shared tier keeps the backing provider",
+			`{"Name": "starter", "ProviderSettings": {"ProviderName": "TENANT", "InstanceSizeName": "M5"}}`,
+			"M5",
+			mongodbatlas.ProviderSettings{ProviderName: "TENANT", BackingProviderName: "AWS", InstanceSizeName: "M5", RegionName: "US_EAST_1"},
+		},
+		{
+			"dedicated tier runs on the backing provider",
+			`{"Name": "starter", "ProviderSettings": {"ProviderName": "TENANT", "InstanceSizeName": "M10"}}`,
+			"M10",
+			mongodbatlas.ProviderSettings{ProviderName: "AWS", InstanceSizeName: "M10", RegionName: "US_EAST_1"},
+		},
+		{
+			"template provider and region win",
+			`{"Name": "starter", "ProviderSettings": {"ProviderName": "AWS", "InstanceSizeName": "M10", "RegionName": "EU_WEST_1"}}`,
+			"M10",
+			mongodbatlas.ProviderSettings{ProviderName: "AWS", InstanceSizeName: "M10", RegionName: "EU_WEST_1"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tenantUpgradeRequest(modelFromJSON(t, tc.template), tenant, tc.size)
+
+			if got.Name != "starter" || !reflect.DeepEqual(&tc.want, got.ProviderSettings) {
+				t.Errorf("want %+v, got %+v", tc.want, got.ProviderSettings)
+			}
+		})
+	}
+}
+
+func TestProgressOfState(t *testing.T) {
+	timeoutMinutes := 15
+	startedLongAgo := time.Now().UTC().Add(-4 * time.Hour).Format(time.RFC3339)
+	startedRecently := time.Now().UTC().Add(-20 * time.Minute).Format(time.RFC3339)
+
+	testCases := []struct {
+		name       string
+		template   string
+		context    map[string]interface{}
+		isReady    bool
+		state      string
+		wantStatus handler.Status
+		wantCode   string
+	}{
+		{"ready", `{"Name": "polled"}`, map[string]interface{}{"stateName": "CREATING"}, true, "IDLE", handler.Success, ""},
+		{"first poll", `{"Name": "polled"}`, map[string]interface{}{"stateName": "CREATING"}, false, "CREATING", handler.InProgress, ""},
+		{"idle but not yet paused", `{"Name": "polled"}`, map[string]interface{}{"stateName": "UPDATING", "paused": true}, false, "IDLE", handler.InProgress, ""},
+		{"unexpected state", `{"Name": "polled"}`, map[string]interface{}{"stateName": "CREATING"}, false, "DELETING", handler.Failed, "GeneralServiceException"},
+		{"default timeout exceeded", `{"Name": "polled"}`, map[string]interface{}{"stateName": "CREATING", "startTime": startedLongAgo}, false, "CREATING", handler.Failed, "NotStabilized"},
+		{"within the default timeout", `{"Name": "polled"}`, map[string]interface{}{"stateName": "CREATING", "startTime": startedRecently}, false, "CREATING", handler.InProgress, ""},
+		{"custom timeout exceeded", fmt.Sprintf(`{"Name": "polled", "StabilizationTimeoutMinutes": %d}`, timeoutMinutes), map[string]interface{}{"stateName": "CREATING", "startTime": startedRecently}, false, "CREATING", handler.Failed, "NotStabilized"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := handler.Request{CallbackContext: tc.context}
+			p := progressOfState(req, modelFromJSON(t, tc.template), tc.isReady, tc.state, "IDLE", []string{"CREATING", "UPDATING", "REPAIRING"})
+
+			if p.OperationStatus != tc.wantStatus || p.HandlerErrorCode != tc.wantCode {
+				t.Errorf("want %s %s, got %s %s: %s", tc.wantStatus, tc.wantCode, p.OperationStatus, p.HandlerErrorCode, p.Message)
+			}
+		})
+	}
+}
+
+func TestProgressOfStateKeepsContext(t *testing.T) {
+	started := time.Now().UTC().Add(-10 * time.Minute).Format(time.RFC3339)
+	req := handler.Request{CallbackContext: map[string]interface{}{"stateName": "UPDATING", "startTime": started, "pollCount": 3, "tenantUpgrade": true, "instanceSizeName": "M10"}}
+
+	p := progressOfState(req, modelFromJSON(t, `{"Name": "polled"}`), false, "UPDATING", "IDLE", []string{"UPDATING"})
+
+	want := map[string]interface{}{"stateName": "UPDATING", "startTime": started, "pollCount": 4, "tenantUpgrade": true, "instanceSizeName": "M10"}
+	if !reflect.DeepEqual(want, p.CallbackContext) {
+		t.Errorf("want %v, got %v", want, p.CallbackContext)
+	}
+}
+
+func TestIsClusterInTargetState(t *testing.T) {
+	paused := true
+	testCases := []struct {
+		name      string
+		cluster   string
+		target    clusterTarget
+		wantReady bool
+	}{
+		{"idle", `{"stateName": "IDLE"}`, clusterTarget{stateName: "IDLE"}, true},
+		{"idle before the pause applied", `{"stateName": "IDLE", "paused": false}`, clusterTarget{stateName: "IDLE", paused: &paused}, false},
+		{"paused", `{"stateName": "IDLE", "paused": true}`, clusterTarget{stateName: "IDLE", paused: &paused}, true},
+		{"idle before the tenant upgrade started", `{"stateName": "IDLE", "providerSettings": {"instanceSizeName": "M2"}}`, clusterTarget{stateName: "IDLE", instanceSizeName: "M10"}, false},
+		{"upgraded", `{"stateName": "IDLE", "providerSettings": {"instanceSizeName": "M10"}}`, clusterTarget{stateName: "IDLE", instanceSizeName: "M10"}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, tc.cluster)
+			}))
+			defer server.Close()
+			client := mongodbatlas.NewClient(nil)
+			client.BaseURL, _ = url.Parse(server.URL + "/")
+
+			ready, _, err := isClusterInTargetState(client, "5f1ea7d9ab3c1c2f3e9b0a00", "polled", tc.target)
+			if err != nil || ready != tc.wantReady {
+				t.Errorf("want ready %t, got %t, %v", tc.wantReady, ready, err)
+			}
+		})
+	}
+}
+
+func TestIsClusterInTargetStateDeleted(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"errorCode": "CLUSTER_NOT_FOUND"}`)
+	}))
+	defer server.Close()
+	client := mongodbatlas.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	ready, state, err := isClusterInTargetState(client, "5f1ea7d9ab3c1c2f3e9b0a00", "gone", clusterTarget{stateName: "DELETED"})
+	if err != nil || !ready || state != "DELETED" {
+		t.Errorf("want a missing cluster to be DELETED, got %t %s %v", ready, state, err)
+	}
+}
+
+func TestReadTenantClusterWithoutProcessArgs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/processArgs") {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"errorCode": "TENANT_CLUSTER_PROCESS_ARGS_UNSUPPORTED"}`)
+			return
+		}
+		fmt.Fprint(w, `{"name": "shared", "stateName": "IDLE", "providerSettings": {"providerName": "TENANT", "backingProviderName": "AWS", "instanceSizeName": "M0", "regionName": "US_EAST_1"}}`)
+	}))
+	defer server.Close()
+	client := mongodbatlas.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	model := modelFromJSON(t, `{"ProviderSettings": {"ProviderName": "TENANT", "BackingProviderName": "AWS", "InstanceSizeName": "M0", "RegionName": "US_EAST_1"}}`)
+	if err := readCluster(client, "5f1ea7d9ab3c1c2f3e9b0a00", "shared", model); err != nil {
+		t.Fatalf("want the tenant cluster read without its process args, got %s", err)
+	}
+	if model.AdvancedConfiguration != nil {
+		t.Errorf("want no advanced configuration, got %+v", model.AdvancedConfiguration)
+	}
+}
+
+func TestDeleteTerminationProtectedCluster(t *testing.T) {
+	model := modelFromJSON(t, `{
+		"ApiKeys": {"PublicKey": "public", "PrivateKey": "private"},
+		"ProjectId": "5f1ea7d9ab3c1c2f3e9b0a00",
+		"Name": "protected",
+		"TerminationProtectionEnabled": true,
+		"FinalSnapshot": {"RetentionInDays": 7}
+	}`)
+
+	// refused before Atlas or the parameter store are called
+	p, err := Delete(handler.Request{}, nil, model)
+	if err != nil || p.OperationStatus != handler.Failed || p.HandlerErrorCode != "InvalidRequest" {
+		t.Errorf("want Failed InvalidRequest, got %s %s, %v", p.OperationStatus, p.HandlerErrorCode, err)
+	}
+}
+
+func TestTerminationProtectionIsNotAClusterSetting(t *testing.T) {
+	prev := modelFromJSON(t, `{"Name": "protected", "ProviderSettings": {"InstanceSizeName": "M10"}}`)
+	current := modelFromJSON(t, `{"Name": "protected", "ProviderSettings": {"InstanceSizeName": "M10"}, "TerminationProtectionEnabled": true}`)
+
+	if clusterSettingsChanged(prev, current) {
+		t.Error("want toggling TerminationProtectionEnabled to leave the cluster untouched")
+	}
+}
+
+func TestExpandClusterChanges(t *testing.T) {
+	prev := `{
+		"Name": "changes",
+		"DiskSizeGB": 40,
+		"MongoDBMajorVersion": "4.0",
+		"ProviderSettings": {"ProviderName": "AWS", "InstanceSizeName": "M10", "RegionName": "US_EAST_1"},
+		"Labels": [{"Key": "env", "Value": "prod"}]
+	}`
+
+	testCases := []struct {
+		name    string
+		current string
+		want    string
+	}{
+		{
+			name:    "nothing changed",
+			current: prev,
+			want:    `{"name": "changes"}`,
+		},
+		{
+			name: "equivalent major version",
+			current: `{
+				"Name": "changes",
+				"DiskSizeGB": 40,
+				"MongoDBMajorVersion": "4",
+				"ProviderSettings": {"ProviderName": "AWS", "InstanceSizeName": "M10", "RegionName": "US_EAST_1"},
+				"Labels": [{"Key": "env", "Value": "prod"}]
+			}`,
+			want: `{"name": "changes"}`,
+		},
+		{
+			name: "disk size only",
+			current: `{
+				"Name": "changes",
+				"DiskSizeGB": 80,
+				"MongoDBMajorVersion": "4.0",
+				"ProviderSettings": {"ProviderName": "AWS", "InstanceSizeName": "M10", "RegionName": "US_EAST_1"},
+				"Labels": [{"Key": "env", "Value": "prod"}]
+			}`,
+			want: `{"name": "changes", "diskSizeGB": 80}`,
+		},
+		{
+			name: "whole provider settings block",
+			current: `{
+				"Name": "changes",
+				"DiskSizeGB": 40,
+				"MongoDBMajorVersion": "4.0",
+				"ProviderSettings": {"ProviderName": "AWS", "InstanceSizeName": "M20", "RegionName": "US_EAST_1"},
+				"Labels": [{"Key": "env", "Value": "prod"}]
+			}`,
+			want: `{"name": "changes", "providerSettings": {"providerName": "AWS", "instanceSizeName": "M20", "regionName": "US_EAST_1"}}`,
+		},
+		{
+			name: "labels",
+			current: `{
+				"Name": "changes",
+				"DiskSizeGB": 40,
+				"MongoDBMajorVersion": "4.0",
+				"ProviderSettings": {"ProviderName": "AWS", "InstanceSizeName": "M10", "RegionName": "US_EAST_1"},
+				"Labels": [{"Key": "env", "Value": "prod"}, {"Key": "team", "Value": "db"}]
+			}`,
+			want: `{"name": "changes", "labels": [{"key": "env", "value": "prod"}, {"key": "team", "value": "db"}]}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			changes, changed := expandClusterChanges(modelFromJSON(t, prev), modelFromJSON(t, tc.current))
+
+			got, err := json.Marshal(changes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := &mongodbatlas.Cluster{}
+			if err := json.Unmarshal([]byte(tc.want), want); err != nil {
+				t.Fatal(err)
+			}
+			wantJSON, _ := json.Marshal(want)
+
+			if string(got) != string(wantJSON) {
+				t.Errorf("want %s, got %s", wantJSON, got)
+			}
+			if changed != (string(wantJSON) != `{"name":"changes"}`) {
+				t.Errorf("changed: got %t for %s", changed, got)
+			}
+		})
+	}
+}
+
+func TestChangedCreateOnlyProperties(t *testing.T) {
+	prev := `{
+		"ProjectId": "5f1ea7d9ab3c1c2f3e9b0a00",
+		"Name": "immutable",
+		"ProviderSettings": {"ProviderName": "TENANT", "BackingProviderName": "AWS", "InstanceSizeName": "M2", "RegionName": "US_EAST_1"}
+	}`
+
+	testCases := []struct {
+		name    string
+		current string
+		want    []string
+	}{
+		{
+			name: "tenant upgrade on the backing provider",
+			current: `{
+				"ProjectId": "5f1ea7d9ab3c1c2f3e9b0a00",
+				"Name": "immutable",
+				"ProviderSettings": {"ProviderName": "AWS", "InstanceSizeName": "M10", "RegionName": "US_EAST_1"}
+			}`,
+		},
+		{
+			name: "everything",
+			current: `{
+				"ProjectId": "5f1ea7d9ab3c1c2f3e9b0a99",
+				"Name": "renamed",
+				"ProviderSettings": {"ProviderName": "GCP", "InstanceSizeName": "M10", "RegionName": "CENTRAL_US"}
+			}`,
+			want: []string{"Name", "ProjectId"},
+		},
+		{
+			name: "cloud provider is left to Atlas",
+			current: `{
+				"ProjectId": "5f1ea7d9ab3c1c2f3e9b0a00",
+				"Name": "immutable",
+				"ProviderSettings": {"ProviderName": "GCP", "InstanceSizeName": "M10", "RegionName": "CENTRAL_US"}
+			}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := changedCreateOnlyProperties(modelFromJSON(t, prev), modelFromJSON(t, tc.current))
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Errorf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestFailoverRequested(t *testing.T) {
+	testCases := []struct {
+		name    string
+		prev    string
+		current string
+		want    bool
+	}{
+		{"first value", `{"Name": "drill"}`, `{"Name": "drill", "FailoverTrigger": "2021-q1"}`, true},
+		{"new value", `{"Name": "drill", "FailoverTrigger": "2021-q1"}`, `{"Name": "drill", "FailoverTrigger": "2021-q2"}`, true},
+		{"same value", `{"Name": "drill", "FailoverTrigger": "2021-q1"}`, `{"Name": "drill", "FailoverTrigger": "2021-q1"}`, false},
+		{"removed", `{"Name": "drill", "FailoverTrigger": "2021-q1"}`, `{"Name": "drill"}`, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			prev, current := modelFromJSON(t, tc.prev), modelFromJSON(t, tc.current)
+			if got := failoverRequested(prev, current); got != tc.want {
+				t.Errorf("want %t, got %t", tc.want, got)
+			}
+			if clusterSettingsChanged(prev, current) {
+				t.Errorf("want FailoverTrigger ignored by clusterSettingsChanged")
+			}
+		})
+	}
+}
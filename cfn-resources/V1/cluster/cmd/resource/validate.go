@@ -0,0 +1,349 @@
+package resource
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// the catalogs follow the names the Atlas API uses, see https://docs.atlas.mongodb.com/reference/amazon-aws/,
+// https://docs.atlas.mongodb.com/reference/google-gcp/ and https://docs.atlas.mongodb.com/reference/microsoft-azure/
+var instanceSizesByProvider = map[string][]string{
+	"AWS": {
+		"M10", "M20", "M30", "M40", "M50", "M60", "M80", "M100", "M140", "M200", "M300",
+		"R40", "R50", "R60", "R80", "R200", "R300", "R400", "R700",
+		"M40_NVME", "M50_NVME", "M60_NVME", "M80_NVME", "M200_NVME", "M400_NVME",
+	},
+	"GCP": {
+		"M10", "M20", "M30", "M40", "M50", "M60", "M80", "M140", "M200", "M250", "M300", "M400",
+		"R40", "R50", "R60", "R80", "R200", "R300", "R400", "R600",
+	},
+	"AZURE": {
+		"M10", "M20", "M30", "M40", "M50", "M60", "M80", "M90", "M200",
+		"R40", "R50", "R60", "R80", "R200", "R300", "R400",
+		"M60_NVME", "M80_NVME", "M200_NVME", "M300_NVME", "M400_NVME", "M600_NVME",
+	},
+	"TENANT": {"M0", "M2", "M5"},
+}
+
+var regionsByProvider = map[string][]string{
+	"AWS": {
+		"US_EAST_1", "US_EAST_2", "US_WEST_1", "US_WEST_2", "CA_CENTRAL_1", "SA_EAST_1",
+		"EU_NORTH_1", "EU_WEST_1", "EU_WEST_2", "EU_WEST_3", "EU_CENTRAL_1", "EU_SOUTH_1",
+		"ME_SOUTH_1", "AF_SOUTH_1",
+		"AP_EAST_1", "AP_NORTHEAST_1", "AP_NORTHEAST_2", "AP_NORTHEAST_3", "AP_SOUTH_1", "AP_SOUTHEAST_1", "AP_SOUTHEAST_2",
+	},
+	"GCP": {
+		"CENTRAL_US", "EASTERN_US", "US_EAST_4", "WESTERN_US", "US_WEST_2", "US_WEST_3", "US_WEST_4",
+		"NORTH_AMERICA_NORTHEAST_1", "NORTH_AMERICA_NORTHEAST_2", "SOUTH_AMERICA_EAST_1",
+		"WESTERN_EUROPE", "EUROPE_NORTH_1", "EUROPE_WEST_2", "EUROPE_WEST_3", "EUROPE_WEST_4", "EUROPE_WEST_6", "EUROPE_CENTRAL_2",
+		"AUSTRALIA_SOUTHEAST_1", "AUSTRALIA_SOUTHEAST_2", "EASTERN_ASIA_PACIFIC", "NORTHEASTERN_ASIA_PACIFIC", "SOUTHEASTERN_ASIA_PACIFIC",
+		"ASIA_EAST_2", "ASIA_NORTHEAST_2", "ASIA_NORTHEAST_3", "ASIA_SOUTH_1", "ASIA_SOUTH_2", "ASIA_SOUTHEAST_2",
+	},
+	"AZURE": {
+		"US_CENTRAL", "US_EAST", "US_EAST_2", "US_NORTH_CENTRAL", "US_WEST", "US_SOUTH_CENTRAL", "US_WEST_2", "US_WEST_CENTRAL",
+		"CANADA_EAST", "CANADA_CENTRAL", "BRAZIL_SOUTH", "BRAZIL_SOUTHEAST",
+		"EUROPE_NORTH", "EUROPE_WEST", "UK_SOUTH", "UK_WEST", "FRANCE_CENTRAL", "FRANCE_SOUTH",
+		"GERMANY_WEST_CENTRAL", "GERMANY_NORTH", "SWITZERLAND_NORTH", "SWITZERLAND_WEST", "NORWAY_EAST", "NORWAY_WEST",
+		"UAE_NORTH", "UAE_CENTRAL", "SOUTH_AFRICA_NORTH", "SOUTH_AFRICA_WEST",
+		"ASIA_EAST", "ASIA_SOUTH_EAST", "AUSTRALIA_EAST", "AUSTRALIA_SOUTH_EAST", "AUSTRALIA_CENTRAL", "AUSTRALIA_CENTRAL_2",
+		"INDIA_CENTRAL", "INDIA_SOUTH", "INDIA_WEST", "JAPAN_EAST", "JAPAN_WEST", "KOREA_CENTRAL", "KOREA_SOUTH",
+	},
+}
+
+const (
+	minDiskSizeGB = 10
+	minDiskIOPS   = 100
+	maxNumShards  = 50
+)
+
+// maxDiskSizeGB and maxDiskIOPS are keyed by the tier number of the instance size, larger tiers use the last entry
+var maxDiskSizeGB = []struct {
+	tier  int
+	limit float64
+}{
+	{10, 128}, {20, 256}, {30, 512}, {40, 1024}, {50, 4096},
+}
+
+var maxDiskIOPS = []struct {
+	tier  int
+	limit int
+}{
+	{40, 3000}, {50, 8000}, {60, 16000}, {80, 32000}, {140, 48000}, {200, 64000},
+}
+
+// validationError names the property a pre-flight check failed on
+type validationError struct {
+	path    string
+	message string
+}
+
+func (e *validationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.path, e.message)
+}
+
+func invalid(path, format string, a ...interface{}) *validationError {
+	return &validationError{path: path, message: fmt.Sprintf(format, a...)}
+}
+
+// violations collects every check a model fails, in the order the checks run
+type violations []*validationError
+
+func (v *violations) add(path, format string, a ...interface{}) {
+	*v = append(*v, invalid(path, format, a...))
+}
+
+func (v violations) contains(verr *validationError) bool {
+	for _, e := range v {
+		if *e == *verr {
+			return true
+		}
+	}
+	return false
+}
+
+// validateCluster checks the model against the rules Atlas enforces, so templates fail before anything is sent.
+// The first violation found is returned.
+func validateCluster(model *Model) *validationError {
+	if v := clusterViolations(model); len(v) > 0 {
+		return v[0]
+	}
+	return nil
+}
+
+// validateUpdate only reports violations the update introduces, settings Atlas already accepted are not checked
+// again. The first violation prevModel does not have is returned.
+func validateUpdate(prevModel *Model, currentModel *Model) *validationError {
+	accepted := clusterViolations(prevModel)
+	for _, verr := range clusterViolations(currentModel) {
+		if !accepted.contains(verr) {
+			return verr
+		}
+	}
+	return nil
+}
+
+// clusterViolations runs every check on the model, a failed check does not hide the ones after it
+func clusterViolations(model *Model) violations {
+	var v violations
+
+	if len(model.ReplicationSpecs) > 0 {
+		if model.ClusterType != nil {
+			v.add("ClusterType", "ClusterType should not be set when `ReplicationSpecs` is set")
+		}
+		if model.NumShards != nil {
+			v.add("NumShards", "NumShards should not be set when `ReplicationSpecs` is set, set it on every replication spec")
+		}
+	}
+
+	validateShards(&v, model)
+
+	// the final snapshot is a cloud backup snapshot, Delete could not take it
+	if model.FinalSnapshot != nil && (model.ProviderBackupEnabled == nil || !*model.ProviderBackupEnabled) {
+		v.add("FinalSnapshot", "FinalSnapshot needs ProviderBackupEnabled set to true")
+	}
+
+	providerName := ""
+	instanceSizeName := ""
+	if ps := model.ProviderSettings; ps != nil {
+		providerName = stringValue(ps.ProviderName)
+		instanceSizeName = stringValue(ps.InstanceSizeName)
+		validateProviderSettings(&v, ps)
+		if model.AdvancedConfiguration != nil && (providerName == "TENANT" || isSharedTier(instanceSizeName)) {
+			v.add("AdvancedConfiguration", "shared tier clusters have no advanced configuration, upgrade to a dedicated tier to set it")
+		}
+	}
+
+	regionProvider := providerName
+	if providerName == "TENANT" {
+		regionProvider = stringValue(model.ProviderSettings.BackingProviderName)
+	}
+	for i, spec := range model.ReplicationSpecs {
+		for j, region := range spec.RegionsConfig {
+			path := fmt.Sprintf("ReplicationSpecs[%d].RegionsConfig[%d].RegionName", i, j)
+			if region.RegionName == nil {
+				v.add(path, "RegionName is required")
+				continue
+			}
+			validateRegion(&v, path, regionProvider, *region.RegionName)
+		}
+	}
+
+	if model.DiskSizeGB != nil && instanceSizeName != "" {
+		validateDiskSizeGB(&v, "DiskSizeGB", instanceSizeName, *model.DiskSizeGB)
+	}
+
+	return v
+}
+
+func validateShards(v *violations, model *Model) {
+	if model.NumShards != nil {
+		clusterType := stringValue(model.ClusterType)
+		switch {
+		case *model.NumShards < 1 || *model.NumShards > maxNumShards:
+			v.add("NumShards", "NumShards must be between 1 and %d, got %d", maxNumShards, *model.NumShards)
+		case clusterType == "REPLICASET" && *model.NumShards != 1:
+			v.add("NumShards", "a REPLICASET cluster has exactly one shard, got %d, use SHARDED to deploy more", *model.NumShards)
+		case clusterType == "GEOSHARDED":
+			v.add("NumShards", "a GEOSHARDED cluster sets NumShards on each zone in `ReplicationSpecs`")
+		}
+	}
+
+	if stringValue(model.ClusterType) == "GEOSHARDED" && len(model.ReplicationSpecs) == 0 {
+		v.add("ReplicationSpecs", "a GEOSHARDED cluster needs at least one zone in `ReplicationSpecs`")
+	}
+
+	for i, spec := range model.ReplicationSpecs {
+		if spec.NumShards != nil && (*spec.NumShards < 1 || *spec.NumShards > maxNumShards) {
+			v.add(fmt.Sprintf("ReplicationSpecs[%d].NumShards", i), "NumShards must be between 1 and %d, got %d", maxNumShards, *spec.NumShards)
+		}
+	}
+}
+
+func validateProviderSettings(v *violations, ps *ProviderSettings) {
+	providerName := stringValue(ps.ProviderName)
+	instanceSizeName := stringValue(ps.InstanceSizeName)
+
+	sizes, ok := instanceSizesByProvider[providerName]
+	if !ok {
+		v.add("ProviderSettings.ProviderName", "unknown provider %q, use one of AWS, GCP, AZURE or TENANT", providerName)
+	} else if instanceSizeName != "" && !contains(sizes, instanceSizeName) {
+		if isSharedTier(instanceSizeName) {
+			v.add("ProviderSettings.InstanceSizeName", "shared tier %s needs ProviderName TENANT and the cloud provider in BackingProviderName", instanceSizeName)
+		} else {
+			v.add("ProviderSettings.InstanceSizeName", "instance size %s is not available on %s", instanceSizeName, providerName)
+		}
+	}
+
+	regionProvider := providerName
+	if providerName == "TENANT" {
+		regionProvider = stringValue(ps.BackingProviderName)
+		if _, ok := regionsByProvider[regionProvider]; !ok {
+			v.add("ProviderSettings.BackingProviderName", "a TENANT cluster needs BackingProviderName AWS, GCP or AZURE, got %q", regionProvider)
+		}
+	} else if ps.BackingProviderName != nil {
+		v.add("ProviderSettings.BackingProviderName", "BackingProviderName is only used by TENANT clusters")
+	}
+	if ps.RegionName != nil {
+		validateRegion(v, "ProviderSettings.RegionName", regionProvider, *ps.RegionName)
+	}
+
+	if ps.DiskIOPS != nil {
+		validateDiskIOPS(v, providerName, instanceSizeName, *ps.DiskIOPS)
+	}
+
+	if ps.AutoScaling != nil && ps.AutoScaling.Compute != nil {
+		validateComputeAutoScaling(v, providerName, instanceSizeName, ps.AutoScaling.Compute)
+	}
+}
+
+func validateRegion(v *violations, path, providerName, regionName string) {
+	regions, ok := regionsByProvider[providerName]
+	if !ok {
+		// the provider itself is reported by validateProviderSettings
+		return
+	}
+	if !contains(regions, regionName) {
+		v.add(path, "region %s is not an Atlas region of %s", regionName, providerName)
+	}
+}
+
+func validateDiskSizeGB(v *violations, path, instanceSizeName string, diskSizeGB float64) {
+	if isSharedTier(instanceSizeName) || strings.HasSuffix(instanceSizeName, "_NVME") {
+		v.add(path, "the disk size of %s clusters is fixed and cannot be set", instanceSizeName)
+		return
+	}
+
+	tier := tierNumber(instanceSizeName)
+	limit := maxDiskSizeGB[len(maxDiskSizeGB)-1].limit
+	for _, l := range maxDiskSizeGB {
+		if tier <= l.tier {
+			limit = l.limit
+			break
+		}
+	}
+	if diskSizeGB < minDiskSizeGB || diskSizeGB > limit {
+		v.add(path, "DiskSizeGB must be between %d and %.0f for %s, got %v", minDiskSizeGB, limit, instanceSizeName, diskSizeGB)
+	}
+}
+
+func validateDiskIOPS(v *violations, providerName, instanceSizeName string, diskIOPS int) {
+	const path = "ProviderSettings.DiskIOPS"
+
+	if providerName != "AWS" {
+		v.add(path, "DiskIOPS can only be set for AWS clusters")
+		return
+	}
+	if strings.HasSuffix(instanceSizeName, "_NVME") {
+		v.add(path, "the IOPS of %s clusters are fixed and cannot be set", instanceSizeName)
+		return
+	}
+
+	tier := tierNumber(instanceSizeName)
+	limit := maxDiskIOPS[len(maxDiskIOPS)-1].limit
+	for _, l := range maxDiskIOPS {
+		if tier <= l.tier {
+			limit = l.limit
+			break
+		}
+	}
+	if diskIOPS < minDiskIOPS || diskIOPS > limit {
+		v.add(path, "DiskIOPS must be between %d and %d for %s, got %d", minDiskIOPS, limit, instanceSizeName, diskIOPS)
+	}
+}
+
+func validateComputeAutoScaling(v *violations, providerName, instanceSizeName string, compute *AutoScalingProviderCompute) {
+	sizes := instanceSizesByProvider[providerName]
+
+	bounds := []struct {
+		path string
+		size *string
+	}{
+		{"ProviderSettings.AutoScaling.Compute.MinInstanceSize", compute.MinInstanceSize},
+		{"ProviderSettings.AutoScaling.Compute.MaxInstanceSize", compute.MaxInstanceSize},
+	}
+	for _, b := range bounds {
+		if b.size == nil {
+			continue
+		}
+		if isSharedTier(*b.size) || strings.HasSuffix(*b.size, "_NVME") || !contains(sizes, *b.size) {
+			v.add(b.path, "instance size %s cannot be used for auto-scaling on %s", *b.size, providerName)
+		}
+	}
+
+	min, max := compute.MinInstanceSize, compute.MaxInstanceSize
+	if min != nil && max != nil && tierNumber(*min) > tierNumber(*max) {
+		v.add("ProviderSettings.AutoScaling.Compute.MinInstanceSize", "MinInstanceSize %s is larger than MaxInstanceSize %s", *min, *max)
+	}
+	if instanceSizeName == "" {
+		return
+	}
+	if min != nil && tierNumber(*min) > tierNumber(instanceSizeName) {
+		v.add("ProviderSettings.AutoScaling.Compute.MinInstanceSize", "MinInstanceSize %s is larger than InstanceSizeName %s", *min, instanceSizeName)
+	}
+	if max != nil && tierNumber(*max) < tierNumber(instanceSizeName) {
+		v.add("ProviderSettings.AutoScaling.Compute.MaxInstanceSize", "MaxInstanceSize %s is smaller than InstanceSizeName %s", *max, instanceSizeName)
+	}
+}
+
+// tierNumber returns the size part of an instance size name, M40, R40 and M40_NVME are all 40
+func tierNumber(instanceSizeName string) int {
+	name := strings.TrimSuffix(instanceSizeName, "_NVME")
+	if len(name) < 2 {
+		return 0
+	}
+	n, err := strconv.Atoi(name[1:])
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
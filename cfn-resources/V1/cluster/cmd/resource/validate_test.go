@@ -0,0 +1,301 @@
+package resource
+
+import (
+	"testing"
+)
+
+func TestValidateCluster(t *testing.T) {
+	testCases := []struct {
+		name     string
+		template string
+		path     string
+	}{
+		{
+			name: "valid replica set",
+			template: `{
+				"Name": "valid",
+				"ClusterType": "REPLICASET",
+				"NumShards": 1,
+				"DiskSizeGB": 40,
+				"ProviderSettings": {"ProviderName": "AWS", "InstanceSizeName": "M30", "RegionName": "US_EAST_1", "DiskIOPS": 3000}
+			}`,
+		},
+		{
+			name: "valid shared tier",
+			template: `{
+				"Name": "shared",
+				"ProviderSettings": {"ProviderName": "TENANT", "BackingProviderName": "GCP", "InstanceSizeName": "M2", "RegionName": "CENTRAL_US"}
+			}`,
+		},
+		{
+			name: "valid multi region",
+			template: `{
+				"Name": "regions",
+				"ProviderSettings": {"ProviderName": "AZURE", "InstanceSizeName": "M30"},
+				"ReplicationSpecs": [{"NumShards": 2, "RegionsConfig": [{"RegionName": "EUROPE_WEST"}, {"RegionName": "UK_SOUTH"}]}]
+			}`,
+		},
+		{
+			name: "valid auto-scaling",
+			template: `{
+				"Name": "autoscaling",
+				"ProviderSettings": {
+					"ProviderName": "AWS", "InstanceSizeName": "M30", "RegionName": "US_EAST_1",
+					"AutoScaling": {"Compute": {"MinInstanceSize": "M10", "MaxInstanceSize": "M60"}}
+				}
+			}`,
+		},
+		{
+			name:     "ClusterType with ReplicationSpecs",
+			template: `{"ClusterType": "REPLICASET", "ReplicationSpecs": [{"NumShards": 1}]}`,
+			path:     "ClusterType",
+		},
+		{
+			name:     "NumShards with ReplicationSpecs",
+			template: `{"NumShards": 1, "ReplicationSpecs": [{"NumShards": 1}]}`,
+			path:     "NumShards",
+		},
+		{
+			name:     "replica set with several shards",
+			template: `{"ClusterType": "REPLICASET", "NumShards": 3}`,
+			path:     "NumShards",
+		},
+		{
+			name:     "too many shards",
+			template: `{"ClusterType": "SHARDED", "NumShards": 51}`,
+			path:     "NumShards",
+		},
+		{
+			name:     "geosharded without zones",
+			template: `{"ClusterType": "GEOSHARDED"}`,
+			path:     "ReplicationSpecs",
+		},
+		{
+			name:     "zone without shards",
+			template: `{"ReplicationSpecs": [{"NumShards": 1}, {"NumShards": 0}]}`,
+			path:     "ReplicationSpecs[1].NumShards",
+		},
+		{
+			name:     "unknown provider",
+			template: `{"ProviderSettings": {"ProviderName": "ORACLE", "InstanceSizeName": "M10"}}`,
+			path:     "ProviderSettings.ProviderName",
+		},
+		{
+			name:     "instance size of another provider",
+			template: `{"ProviderSettings": {"ProviderName": "GCP", "InstanceSizeName": "M100", "RegionName": "CENTRAL_US"}}`,
+			path:     "ProviderSettings.InstanceSizeName",
+		},
+		{
+			name:     "shared tier without TENANT",
+			template: `{"ProviderSettings": {"ProviderName": "AWS", "InstanceSizeName": "M5", "RegionName": "US_EAST_1"}}`,
+			path:     "ProviderSettings.InstanceSizeName",
+		},
+		{
+			name:     "tenant without backing provider",
+			template: `{"ProviderSettings": {"ProviderName": "TENANT", "InstanceSizeName": "M2", "RegionName": "US_EAST_1"}}`,
+			path:     "ProviderSettings.BackingProviderName",
+		},
+		{
+			name:     "backing provider on a dedicated cluster",
+			template: `{"ProviderSettings": {"ProviderName": "AWS", "BackingProviderName": "AWS", "InstanceSizeName": "M10", "RegionName": "US_EAST_1"}}`,
+			path:     "ProviderSettings.BackingProviderName",
+		},
+		{
+			name:     "region of another provider",
+			template: `{"ProviderSettings": {"ProviderName": "AWS", "InstanceSizeName": "M10", "RegionName": "CENTRAL_US"}}`,
+			path:     "ProviderSettings.RegionName",
+		},
+		{
+			name:     "tenant region of another provider",
+			template: `{"ProviderSettings": {"ProviderName": "TENANT", "BackingProviderName": "AZURE", "InstanceSizeName": "M0", "RegionName": "US_EAST_1"}}`,
+			path:     "ProviderSettings.RegionName",
+		},
+		{
+			name:     "tenant with a dedicated tier",
+			template: `{"ProviderSettings": {"ProviderName": "TENANT", "BackingProviderName": "AZURE", "InstanceSizeName": "M10", "RegionName": "US_EAST_2"}}`,
+			path:     "ProviderSettings.InstanceSizeName",
+		},
+		{
+			name: "replication spec region of another provider",
+			template: `{
+				"ProviderSettings": {"ProviderName": "GCP", "InstanceSizeName": "M30"},
+				"ReplicationSpecs": [{"NumShards": 1, "RegionsConfig": [{"RegionName": "CENTRAL_US"}, {"RegionName": "EU_WEST_1"}]}]
+			}`,
+			path: "ReplicationSpecs[0].RegionsConfig[1].RegionName",
+		},
+		{
+			name: "replication spec region without a name",
+			template: `{
+				"ProviderSettings": {"ProviderName": "GCP", "InstanceSizeName": "M30"},
+				"ReplicationSpecs": [{"NumShards": 1, "RegionsConfig": [{"RegionName": "CENTRAL_US"}, {"Priority": 6}]}]
+			}`,
+			path: "ReplicationSpecs[0].RegionsConfig[1].RegionName",
+		},
+		{
+			name:     "advanced configuration on a shared tier",
+			template: `{"ProviderSettings": {"ProviderName": "TENANT", "BackingProviderName": "AWS", "InstanceSizeName": "M0", "RegionName": "US_EAST_1"}, "AdvancedConfiguration": {"NoTableScan": true}}`,
+			path:     "AdvancedConfiguration",
+		},
+		{
+			name:     "final snapshot with cloud backup",
+			template: `{"ProviderBackupEnabled": true, "FinalSnapshot": {"RetentionInDays": 7}}`,
+		},
+		{
+			name:     "final snapshot without cloud backup",
+			template: `{"FinalSnapshot": {"RetentionInDays": 7}}`,
+			path:     "FinalSnapshot",
+		},
+		{
+			name:     "final snapshot with cloud backup disabled",
+			template: `{"ProviderBackupEnabled": false, "FinalSnapshot": {"RetentionInDays": 7}}`,
+			path:     "FinalSnapshot",
+		},
+		{
+			name:     "disk too large for the tier",
+			template: `{"DiskSizeGB": 200, "ProviderSettings": {"ProviderName": "AWS", "InstanceSizeName": "M10", "RegionName": "US_EAST_1"}}`,
+			path:     "DiskSizeGB",
+		},
+		{
+			name:     "disk too small",
+			template: `{"DiskSizeGB": 5, "ProviderSettings": {"ProviderName": "AWS", "InstanceSizeName": "M30", "RegionName": "US_EAST_1"}}`,
+			path:     "DiskSizeGB",
+		},
+		{
+			name:     "disk of an NVMe tier",
+			template: `{"DiskSizeGB": 380, "ProviderSettings": {"ProviderName": "AWS", "InstanceSizeName": "M40_NVME", "RegionName": "US_EAST_1"}}`,
+			path:     "DiskSizeGB",
+		},
+		{
+			name:     "disk of a shared tier",
+			template: `{"DiskSizeGB": 2, "ProviderSettings": {"ProviderName": "TENANT", "BackingProviderName": "AWS", "InstanceSizeName": "M2", "RegionName": "US_EAST_1"}}`,
+			path:     "DiskSizeGB",
+		},
+		{
+			name:     "IOPS outside AWS",
+			template: `{"ProviderSettings": {"ProviderName": "GCP", "InstanceSizeName": "M30", "RegionName": "CENTRAL_US", "DiskIOPS": 1000}}`,
+			path:     "ProviderSettings.DiskIOPS",
+		},
+		{
+			name:     "IOPS above the tier maximum",
+			template: `{"ProviderSettings": {"ProviderName": "AWS", "InstanceSizeName": "M30", "RegionName": "US_EAST_1", "DiskIOPS": 5000}}`,
+			path:     "ProviderSettings.DiskIOPS",
+		},
+		{
+			name:     "IOPS below the minimum",
+			template: `{"ProviderSettings": {"ProviderName": "AWS", "InstanceSizeName": "M30", "RegionName": "US_EAST_1", "DiskIOPS": 50}}`,
+			path:     "ProviderSettings.DiskIOPS",
+		},
+		{
+			name: "auto-scaling min above max",
+			template: `{"ProviderSettings": {
+				"ProviderName": "AWS", "InstanceSizeName": "M30", "RegionName": "US_EAST_1",
+				"AutoScaling": {"Compute": {"MinInstanceSize": "M60", "MaxInstanceSize": "M40"}}
+			}}`,
+			path: "ProviderSettings.AutoScaling.Compute.MinInstanceSize",
+		},
+		{
+			name: "auto-scaling max below the instance size",
+			template: `{"ProviderSettings": {
+				"ProviderName": "AWS", "InstanceSizeName": "M50", "RegionName": "US_EAST_1",
+				"AutoScaling": {"Compute": {"MaxInstanceSize": "M40"}}
+			}}`,
+			path: "ProviderSettings.AutoScaling.Compute.MaxInstanceSize",
+		},
+		{
+			name: "auto-scaling min above the instance size",
+			template: `{"ProviderSettings": {
+				"ProviderName": "AWS", "InstanceSizeName": "M20", "RegionName": "US_EAST_1",
+				"AutoScaling": {"Compute": {"MinInstanceSize": "M30"}}
+			}}`,
+			path: "ProviderSettings.AutoScaling.Compute.MinInstanceSize",
+		},
+		{
+			name: "auto-scaling to an NVMe tier",
+			template: `{"ProviderSettings": {
+				"ProviderName": "AWS", "InstanceSizeName": "M40", "RegionName": "US_EAST_1",
+				"AutoScaling": {"Compute": {"MaxInstanceSize": "M80_NVME"}}
+			}}`,
+			path: "ProviderSettings.AutoScaling.Compute.MaxInstanceSize",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateCluster(modelFromJSON(t, tc.template))
+
+			switch {
+			case tc.path == "" && err != nil:
+				t.Errorf("want no error, got %s", err)
+			case tc.path != "" && err == nil:
+				t.Errorf("want an error on %s, got none", tc.path)
+			case tc.path != "" && err.path != tc.path:
+				t.Errorf("want an error on %s, got %s", tc.path, err)
+			}
+		})
+	}
+}
+
+func TestValidateUpdate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		previous string
+		template string
+		path     string
+	}{
+		{
+			name:     "unchanged violation Atlas accepted before",
+			previous: `{"ProviderBackupEnabled": false, "FinalSnapshot": {"RetentionInDays": 7}, "DiskSizeGB": 40}`,
+			template: `{"ProviderBackupEnabled": false, "FinalSnapshot": {"RetentionInDays": 7}, "DiskSizeGB": 80}`,
+		},
+		{
+			name:     "violation introduced by the update",
+			previous: `{"ProviderBackupEnabled": true, "FinalSnapshot": {"RetentionInDays": 7}}`,
+			template: `{"ProviderBackupEnabled": false, "FinalSnapshot": {"RetentionInDays": 7}}`,
+			path:     "FinalSnapshot",
+		},
+		{
+			name:     "violation added next to one Atlas accepted before",
+			previous: `{"ProviderBackupEnabled": false, "FinalSnapshot": {"RetentionInDays": 7}, "ProviderSettings": {"ProviderName": "AWS", "InstanceSizeName": "M10", "RegionName": "US_EAST_1"}}`,
+			template: `{"ProviderBackupEnabled": false, "FinalSnapshot": {"RetentionInDays": 7}, "ProviderSettings": {"ProviderName": "AWS", "InstanceSizeName": "M10", "RegionName": "CENTRAL_US"}}`,
+			path:     "ProviderSettings.RegionName",
+		},
+		{
+			name:     "changed violation",
+			previous: `{"ClusterType": "SHARDED", "NumShards": 51}`,
+			template: `{"ClusterType": "SHARDED", "NumShards": 60}`,
+			path:     "NumShards",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateUpdate(modelFromJSON(t, tc.previous), modelFromJSON(t, tc.template))
+
+			switch {
+			case tc.path == "" && err != nil:
+				t.Errorf("want no error, got %s", err)
+			case tc.path != "" && err == nil:
+				t.Errorf("want an error on %s, got none", tc.path)
+			case tc.path != "" && err.path != tc.path:
+				t.Errorf("want an error on %s, got %s", tc.path, err)
+			}
+		})
+	}
+}
+
+func TestTierNumber(t *testing.T) {
+	testCases := map[string]int{
+		"M0":       0,
+		"M10":      10,
+		"R40":      40,
+		"M40_NVME": 40,
+		"M200":     200,
+		"":         0,
+	}
+
+	for instanceSizeName, want := range testCases {
+		if got := tierNumber(instanceSizeName); got != want {
+			t.Errorf("tierNumber(%q): want %d, got %d", instanceSizeName, want, got)
+		}
+	}
+}
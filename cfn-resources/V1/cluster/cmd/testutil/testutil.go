@@ -81,7 +81,7 @@ func Test(t TestT, ts TestCase) {
 		log.Printf("[DEBUG] Test: Executing step %d", i)
 		if model == nil {
 			data = []byte(test.Config)
-			req := handler.NewRequest("id", map[string]interface{}{}, handler.RequestContext{}, &session.Session{}, nil, data)
+			req := handler.NewRequest("id", map[string]interface{}{}, handler.RequestContext{}, &session.Session{}, nil, data, nil)
 			h := ts.TestHandler.Create(req)
 
 			var err error
@@ -91,7 +91,7 @@ func Test(t TestT, ts TestCase) {
 				return
 			}
 
-			if h.OperationStatus != handler.Failed {
+			if h.OperationStatus == handler.Failed {
 				return
 			}
 
@@ -102,7 +102,7 @@ func Test(t TestT, ts TestCase) {
 				return
 			}
 
-			req = handler.NewRequest("id", h.CallbackContext, handler.RequestContext{}, &session.Session{}, nil, dataRead)
+			req = handler.NewRequest("id", h.CallbackContext, handler.RequestContext{}, &session.Session{}, nil, dataRead, nil)
 			hRead := ts.TestHandler.Read(req)
 			if hRead.OperationStatus != handler.Success {
 				t.Error(fmt.Sprintf("Error Performing READ Request %s: %s", err, h.Message))
@@ -130,7 +130,7 @@ func Test(t TestT, ts TestCase) {
 			t.Error(fmt.Sprintf("[ERROR] Test: Error marshaling resource %s", err))
 			return
 		}
-		req := handler.NewRequest("id", map[string]interface{}{}, handler.RequestContext{}, &session.Session{}, nil, data)
+		req := handler.NewRequest("id", map[string]interface{}{}, handler.RequestContext{}, &session.Session{}, nil, data, nil)
 		h := ts.TestHandler.Delete(req)
 		h, err = checkStatus(h, ts.TestHandler.Delete)
 		if err != nil {
@@ -181,7 +181,7 @@ func waitForSuccess(h handler.ProgressEvent, op Operation) (handler.ProgressEven
 			return h, err
 		}
 		ctx := h.CallbackContext
-		req := handler.NewRequest("id", ctx, handler.RequestContext{}, &session.Session{}, nil, data)
+		req := handler.NewRequest("id", ctx, handler.RequestContext{}, &session.Session{}, nil, data, nil)
 		h = op(req)
 		if h.OperationStatus == handler.Failed {
 			return h, fmt.Errorf("Failed performing operation: %s", h.Message)
@@ -4,17 +4,25 @@ package resource
 
 // Model is autogenerated from the json schema
 type Model struct {
-	DatabaseName      *string           `json:",omitempty"`
-	Labels            []LabelDefinition `json:",omitempty"`
-	LdapAuthType      *string           `json:",omitempty"`
-	ProjectId         *string           `json:",omitempty"`
-	AwsIAMType        *string           `json:",omitempty"`
-	Roles             []RoleDefinition  `json:",omitempty"`
-	Scopes            []ScopeDefinition `json:",omitempty"`
-	Password          *string           `json:",omitempty"`
-	Username          *string           `json:",omitempty"`
-	UserCfnIdentifier *string           `json:",omitempty"`
-	ApiKeys           *ApiKeyDefinition `json:",omitempty"`
+	DatabaseName                     *string           `json:",omitempty"`
+	Labels                           []LabelDefinition `json:",omitempty"`
+	DeleteAfterDate                  *string           `json:",omitempty"`
+	LdapAuthType                     *string           `json:",omitempty"`
+	ProjectId                        *string           `json:",omitempty"`
+	AwsIAMType                       *string           `json:",omitempty"`
+	X509Type                         *string           `json:",omitempty"`
+	CertificateMonthsUntilExpiration *int              `json:",omitempty"`
+	CertificateRotationTrigger       *string           `json:",omitempty"`
+	CertificateSecretArn             *string           `json:",omitempty"`
+	Roles                            []RoleDefinition  `json:",omitempty"`
+	Scopes                           []ScopeDefinition `json:",omitempty"`
+	Password                         *string           `json:",omitempty"`
+	GeneratePassword                 *bool             `json:",omitempty"`
+	PasswordRotationTrigger          *string           `json:",omitempty"`
+	PasswordSecretArn                *string           `json:",omitempty"`
+	Username                         *string           `json:",omitempty"`
+	UserCfnIdentifier                *string           `json:",omitempty"`
+	ApiKeys                          *ApiKeyDefinition `json:",omitempty"`
 }
 
 // LabelDefinition is autogenerated from the json schema
@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/422158/mongodbstpatlas-cloudformation-resources/cfn-resources/V1/database-user/cmd/util"
 	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ssm"
 	"github.com/davecgh/go-spew/spew"
@@ -83,17 +85,70 @@ func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler
 		user.LDAPAuthType = *currentModel.LdapAuthType
 	}
 
+	if currentModel.X509Type != nil {
+		user.X509Type = *currentModel.X509Type
+	}
+
+	if currentModel.DeleteAfterDate != nil {
+		user.DeleteAfterDate = *currentModel.DeleteAfterDate
+	}
+
+	verr := validatePasswordSettings(currentModel)
+	if verr == nil {
+		verr = validateX509Settings(currentModel)
+	}
+	if verr != nil {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          fmt.Sprintf("error creating database user (%s): %s", user.Username, verr),
+			HandlerErrorCode: "InvalidRequest",
+		}, nil
+	}
+
 	cfnid := buildUserCfnIdentifier(currentModel.ProjectId, currentModel.Username)
 	currentModel.UserCfnIdentifier = &cfnid
 
+	// the secret is created first, a user whose generated password could not be stored would be unusable
+	if isTrue(currentModel.GeneratePassword) {
+		user.Password, err = generatePassword()
+		if err != nil {
+			return handler.ProgressEvent{}, fmt.Errorf("error generating password for database user (%s): %s", user.Username, err)
+		}
+
+		currentModel.PasswordSecretArn, err = createUserSecret(buildPasswordSecretName(*currentModel.UserCfnIdentifier), fmt.Sprintf("Password of the MongoDB Atlas database user %s of project %s", user.Username, groupID), newPasswordSecret(currentModel, user.Password), req.Session)
+		if err != nil {
+			return handler.ProgressEvent{}, fmt.Errorf("error storing password of database user (%s): %s", user.Username, err)
+		}
+	}
+
 	_, _, err = client.DatabaseUsers.Create(context.Background(), groupID, &user)
 	if err != nil {
+		if currentModel.PasswordSecretArn != nil {
+			if errSecret := deleteUserSecret(buildPasswordSecretName(*currentModel.UserCfnIdentifier), req.Session); errSecret != nil {
+				return handler.ProgressEvent{}, fmt.Errorf("error creating database user: %s.\nError deleting password secret: %s", err, errSecret)
+			}
+		}
 		return handler.ProgressEvent{}, fmt.Errorf("error creating database user: %s", err)
 	}
 
+	// a user whose certificate could not be issued or stored is removed again, the stack rolls back cleanly
+	if currentModel.CertificateMonthsUntilExpiration != nil {
+		currentModel.CertificateSecretArn, err = createCertificateSecret(client, currentModel, req.Session)
+		if err != nil {
+			if errRollback := rollbackCreate(client, currentModel, req.Session); errRollback != nil {
+				return handler.ProgressEvent{}, fmt.Errorf("error issuing certificate for database user (%s): %s.\n%s", user.Username, err, errRollback)
+			}
+			return handler.ProgressEvent{}, fmt.Errorf("error issuing certificate for database user (%s): %s", user.Username, err)
+		}
+	}
+
 	// putting api keys and project name into parameter store (needed for read operation)
-	_, err = putParameterIntoParameterStore(currentModel.UserCfnIdentifier, &ParameterToBePersistedSpec{ApiKeys: currentModel.ApiKeys, ProjectId: currentModel.ProjectId, Username: currentModel.Username, DatabaseName: currentModel.DatabaseName}, req.Session)
+	_, err = putParameterIntoParameterStore(currentModel.UserCfnIdentifier, &ParameterToBePersistedSpec{ApiKeys: currentModel.ApiKeys, ProjectId: currentModel.ProjectId, Username: currentModel.Username, DatabaseName: currentModel.DatabaseName, PasswordSecretArn: currentModel.PasswordSecretArn, CertificateSecretArn: currentModel.CertificateSecretArn}, req.Session)
 	if err != nil {
+		// the secrets have fixed names, left behind they would make the retry fail to create them
+		if errRollback := rollbackCreate(client, currentModel, req.Session); errRollback != nil {
+			return handler.ProgressEvent{}, fmt.Errorf("error when putting api keys into parameter store: %s.\n%s", err, errRollback)
+		}
 		return handler.ProgressEvent{}, fmt.Errorf("error when putting api keys into parameter store: %s", err)
 	}
 
@@ -116,52 +171,24 @@ func Read(req handler.Request, prevModel *Model, currentModel *Model) (handler.P
 		return handler.ProgressEvent{}, err
 	}
 
-	databaseUser, _, err := client.DatabaseUsers.Get(context.Background(), *params.DatabaseName, *params.ProjectId, *params.Username)
+	databaseUser, resp, err := client.DatabaseUsers.Get(context.Background(), *params.DatabaseName, *params.ProjectId, *params.Username)
 	if err != nil {
-		return handler.ProgressEvent{}, fmt.Errorf("error fetching database user (%s): %s", *params.Username, err)
-	}
-
-	currentModel.LdapAuthType = &databaseUser.LDAPAuthType
-	currentModel.AwsIAMType = &databaseUser.AWSIAMType
-
-	// reading roles from remote
-	var roles []RoleDefinition
-	for _, r := range databaseUser.Roles {
-		role := RoleDefinition{
-			CollectionName: &r.CollectionName,
-			DatabaseName:   &r.DatabaseName,
-			RoleName:       &r.RoleName,
-		}
-
-		roles = append(roles, role)
-	}
-	currentModel.Roles = roles
-
-	// reading scopes from remote
-	var scopes []ScopeDefinition
-	for _, s := range databaseUser.Scopes {
-		scope := ScopeDefinition{
-			Type: &s.Type,
-			Name: &s.Name,
+		// a user past its DeleteAfterDate has been deleted by Atlas
+		if resp != nil && resp.StatusCode == 404 {
+			return handler.ProgressEvent{
+				OperationStatus:  handler.Failed,
+				Message:          fmt.Sprintf("database user (%s) not found", *params.Username),
+				HandlerErrorCode: "NotFound",
+			}, nil
 		}
-
-		scopes = append(scopes, scope)
+		return handler.ProgressEvent{}, fmt.Errorf("error fetching database user (%s): %s", *params.Username, err)
 	}
-	currentModel.Scopes = scopes
-
-	// reading labels from remote
-	var labels []LabelDefinition
-	for _, l := range databaseUser.Labels {
-		label := LabelDefinition{
-			Key:   &l.Key,
-			Value: &l.Value,
-		}
 
-		labels = append(labels, label)
-	}
-	currentModel.Labels = labels
+	flattenDatabaseUser(currentModel, databaseUser)
+	currentModel.PasswordSecretArn = params.PasswordSecretArn
+	currentModel.CertificateSecretArn = params.CertificateSecretArn
 
-	cfnid := fmt.Sprintf("%s-%s", *currentModel.ProjectId, *currentModel.Username)
+	cfnid := buildUserCfnIdentifier(params.ProjectId, params.Username)
 	currentModel.UserCfnIdentifier = &cfnid
 
 	return handler.ProgressEvent{
@@ -236,16 +263,82 @@ func Update(req handler.Request, prevModel *Model, currentModel *Model) (handler
 		user.LDAPAuthType = *currentModel.LdapAuthType
 	}
 
+	if currentModel.X509Type != nil {
+		user.X509Type = *currentModel.X509Type
+	}
+
+	if currentModel.DeleteAfterDate != nil {
+		user.DeleteAfterDate = *currentModel.DeleteAfterDate
+	}
+
+	verr := validatePasswordSettings(currentModel)
+	if verr == nil {
+		verr = validateX509Settings(currentModel)
+	}
+	if verr == nil {
+		verr = validateDeleteAfterDate(prevModel, currentModel)
+	}
+	if verr != nil {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          fmt.Sprintf("error updating database user (%s): %s", username, verr),
+			HandlerErrorCode: "InvalidRequest",
+		}, nil
+	}
+
+	currentModel.UserCfnIdentifier = prevModel.UserCfnIdentifier
+
+	// without a new password Atlas keeps the generated one, only a rotation sends a password. The new password is
+	// stored as the pending version of the secret first, so it is not lost when the update fails after Atlas took it,
+	// and only becomes the current version once Atlas has it
+	var pendingVersionID *string
+	if passwordRotationRequested(prevModel, currentModel) {
+		user.Password, err = generatePassword()
+		if err != nil {
+			return handler.ProgressEvent{}, fmt.Errorf("error generating password for database user (%s): %s", username, err)
+		}
+		pendingVersionID, err = putPendingUserSecret(buildPasswordSecretName(*currentModel.UserCfnIdentifier), newPasswordSecret(currentModel, user.Password), req.Session)
+		if err != nil {
+			return handler.ProgressEvent{}, fmt.Errorf("error storing rotated password of database user (%s): %s", username, err)
+		}
+	}
+
 	_, _, err = client.DatabaseUsers.Update(context.Background(), groupID, url.QueryEscape(username),
 		&user)
 	if err != nil {
 		return handler.ProgressEvent{}, fmt.Errorf("error updating database user (%s): %s", username, err)
 	}
 
-	currentModel.UserCfnIdentifier = prevModel.UserCfnIdentifier
+	if pendingVersionID != nil {
+		err = promoteUserSecret(buildPasswordSecretName(*currentModel.UserCfnIdentifier), pendingVersionID, req.Session)
+		if err != nil {
+			return handler.ProgressEvent{}, fmt.Errorf("error promoting rotated password of database user (%s), Atlas has the AWSPENDING version of the secret: %s", username, err)
+		}
+	}
+
+	if isTrue(currentModel.GeneratePassword) || currentModel.CertificateMonthsUntilExpiration != nil {
+		params, err := getParameterFromParameterStore(currentModel.UserCfnIdentifier, req.Session)
+		if err != nil {
+			return handler.ProgressEvent{}, err
+		}
+		currentModel.PasswordSecretArn = params.PasswordSecretArn
+		currentModel.CertificateSecretArn = params.CertificateSecretArn
+	}
+
+	// the previous certificate stays valid until it expires, Atlas cannot revoke single MANAGED certificates
+	if certificateRotationRequested(prevModel, currentModel) {
+		userCertificate, _, err := client.X509AuthDBUsers.CreateUserCertificate(context.Background(), groupID, url.PathEscape(username), *currentModel.CertificateMonthsUntilExpiration)
+		if err != nil {
+			return handler.ProgressEvent{}, fmt.Errorf("error issuing certificate for database user (%s): %s", username, err)
+		}
+		err = putUserSecret(buildCertificateSecretName(*currentModel.UserCfnIdentifier), newCertificateSecret(currentModel, userCertificate.Certificate), req.Session)
+		if err != nil {
+			return handler.ProgressEvent{}, fmt.Errorf("error storing rotated certificate of database user (%s), change CertificateRotationTrigger again to issue a new one: %s", username, err)
+		}
+	}
 
 	// putting api keys and project name into parameter store (needed for read operation)
-	_, err = putParameterIntoParameterStore(currentModel.UserCfnIdentifier, &ParameterToBePersistedSpec{ApiKeys: currentModel.ApiKeys, ProjectId: currentModel.ProjectId, Username: currentModel.Username, DatabaseName: currentModel.DatabaseName}, req.Session)
+	_, err = putParameterIntoParameterStore(currentModel.UserCfnIdentifier, &ParameterToBePersistedSpec{ApiKeys: currentModel.ApiKeys, ProjectId: currentModel.ProjectId, Username: currentModel.Username, DatabaseName: currentModel.DatabaseName, PasswordSecretArn: currentModel.PasswordSecretArn, CertificateSecretArn: currentModel.CertificateSecretArn}, req.Session)
 	if err != nil {
 		return handler.ProgressEvent{}, fmt.Errorf("error when putting api keys into parameter store: %s", err)
 	}
@@ -259,22 +352,58 @@ func Update(req handler.Request, prevModel *Model, currentModel *Model) (handler
 
 // Delete handles the Delete event from the Cloudformation service.
 func Delete(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
-	client, err := util.CreateMongoDBClient(*currentModel.ApiKeys.PublicKey, *currentModel.ApiKeys.PrivateKey)
+	// the parameter records the user and its secrets, so a Delete carrying only the primary identifier removes
+	// everything Create stored
+	params, err := getParameterFromParameterStore(currentModel.UserCfnIdentifier, req.Session)
 	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == ssm.ErrCodeParameterNotFound {
+			return handler.ProgressEvent{
+				OperationStatus:  handler.Failed,
+				Message:          fmt.Sprintf("database user (%s) not found", *currentModel.UserCfnIdentifier),
+				HandlerErrorCode: "NotFound",
+			}, nil
+		}
 		return handler.ProgressEvent{}, err
 	}
 
-	groupID := *currentModel.ProjectId
-	username := url.QueryEscape(*currentModel.Username)
-	dbName := *currentModel.DatabaseName
+	client, err := util.CreateMongoDBClient(*params.ApiKeys.PublicKey, *params.ApiKeys.PrivateKey)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	groupID := *params.ProjectId
+	username := url.QueryEscape(*params.Username)
+	dbName := *params.DatabaseName
 
 	resp, err := client.DatabaseUsers.Delete(context.Background(), dbName, groupID, username)
 	userDeletedSuccess := true
 	if err != nil || resp.StatusCode != 204 {
 		userDeletedSuccess = false
-		// if resp.StatusCode == 404 {
-		// 	userDeletedSuccess = true
-		// }
+		// a user past its DeleteAfterDate has already been deleted by Atlas
+		if resp != nil && resp.StatusCode == 404 {
+			userDeletedSuccess = true
+		}
+	}
+
+	// the secrets are only useful while the user exists
+	if userDeletedSuccess && params.PasswordSecretArn != nil {
+		if errSecret := deleteUserSecret(*params.PasswordSecretArn, req.Session); errSecret != nil {
+			return handler.ProgressEvent{
+				OperationStatus:  handler.Failed,
+				Message:          "Delete Failed",
+				HandlerErrorCode: "GeneralServiceException",
+			}, fmt.Errorf("Failed to delete password secret for user with id %s\n%s", *currentModel.UserCfnIdentifier, errSecret)
+		}
+	}
+
+	if userDeletedSuccess && params.CertificateSecretArn != nil {
+		if errSecret := deleteUserSecret(*params.CertificateSecretArn, req.Session); errSecret != nil {
+			return handler.ProgressEvent{
+				OperationStatus:  handler.Failed,
+				Message:          "Delete Failed",
+				HandlerErrorCode: "GeneralServiceException",
+			}, fmt.Errorf("Failed to delete certificate secret for user with id %s\n%s", *currentModel.UserCfnIdentifier, errSecret)
+		}
 	}
 
 	_, respErr := deleteParameterFromParameterStore(currentModel.UserCfnIdentifier, req.Session)
@@ -313,26 +442,264 @@ func Delete(req handler.Request, prevModel *Model, currentModel *Model) (handler
 	}, nil
 }
 
-// List NOOP
+// List handles the List event from the Cloudformation service.
+// Every database user of the project is listed, passwords are never returned by Atlas nor included here.
 func List(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	client, err := util.CreateMongoDBClient(*currentModel.ApiKeys.PublicKey, *currentModel.ApiKeys.PrivateKey)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	models, err := listDatabaseUsers(client, currentModel.ProjectId)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
 
 	return handler.ProgressEvent{
 		OperationStatus: handler.Success,
 		Message:         "List Complete",
-		ResourceModel:   currentModel,
+		ResourceModels:  models,
 	}, nil
 }
 
+// listDatabaseUsers pages through every database user of the project and returns a model for each
+func listDatabaseUsers(client *mongodbatlas.Client, projectID *string) ([]interface{}, error) {
+	const itemsPerPage = 100
+
+	models := make([]interface{}, 0)
+	for pageNum := 1; ; pageNum++ {
+		databaseUsers, resp, err := client.DatabaseUsers.List(context.Background(), *projectID, &mongodbatlas.ListOptions{PageNum: pageNum, ItemsPerPage: itemsPerPage})
+		if err != nil {
+			return nil, fmt.Errorf("error listing database users for project (%s): %s", *projectID, err)
+		}
+
+		for i := range databaseUsers {
+			var model Model
+			model.ProjectId = projectID
+			model.Username = &databaseUsers[i].Username
+			model.DatabaseName = &databaseUsers[i].DatabaseName
+			flattenDatabaseUser(&model, &databaseUsers[i])
+			cfnid := buildUserCfnIdentifier(model.ProjectId, model.Username)
+			model.UserCfnIdentifier = &cfnid
+			models = append(models, model)
+		}
+
+		if len(databaseUsers) < itemsPerPage || resp.IsLastPage() {
+			return models, nil
+		}
+	}
+}
+
+// flattenDatabaseUser copies the auth types, expiry, roles, scopes and labels Atlas reports onto the model. A
+// DeleteAfterDate Atlas formats differently keeps the spelling of the model.
+func flattenDatabaseUser(currentModel *Model, databaseUser *mongodbatlas.DatabaseUser) {
+	currentModel.LdapAuthType = &databaseUser.LDAPAuthType
+	currentModel.AwsIAMType = &databaseUser.AWSIAMType
+	currentModel.X509Type = &databaseUser.X509Type
+
+	if databaseUser.DeleteAfterDate == "" {
+		currentModel.DeleteAfterDate = nil
+	} else if currentModel.DeleteAfterDate == nil || !sameInstant(*currentModel.DeleteAfterDate, databaseUser.DeleteAfterDate) {
+		currentModel.DeleteAfterDate = &databaseUser.DeleteAfterDate
+	}
+
+	// reading roles from remote
+	var roles []RoleDefinition
+	for i := range databaseUser.Roles {
+		r := &databaseUser.Roles[i]
+		role := RoleDefinition{
+			CollectionName: &r.CollectionName,
+			DatabaseName:   &r.DatabaseName,
+			RoleName:       &r.RoleName,
+		}
+
+		roles = append(roles, role)
+	}
+	currentModel.Roles = roles
+
+	// reading scopes from remote
+	var scopes []ScopeDefinition
+	for i := range databaseUser.Scopes {
+		s := &databaseUser.Scopes[i]
+		scope := ScopeDefinition{
+			Type: &s.Type,
+			Name: &s.Name,
+		}
+
+		scopes = append(scopes, scope)
+	}
+	currentModel.Scopes = scopes
+
+	// reading labels from remote
+	var labels []LabelDefinition
+	for i := range databaseUser.Labels {
+		l := &databaseUser.Labels[i]
+		label := LabelDefinition{
+			Key:   &l.Key,
+			Value: &l.Value,
+		}
+
+		labels = append(labels, label)
+	}
+	currentModel.Labels = labels
+}
+
+// validatePasswordSettings checks that a generated password replaces Password, and that users authenticating
+// through AWS IAM, LDAP or X.509, which have no password, do not ask for one
+func validatePasswordSettings(model *Model) error {
+	if !isTrue(model.GeneratePassword) {
+		if model.PasswordRotationTrigger != nil {
+			return fmt.Errorf("PasswordRotationTrigger requires GeneratePassword")
+		}
+		return nil
+	}
+	if model.Password != nil {
+		return fmt.Errorf("Password cannot be set when GeneratePassword is true")
+	}
+	if model.AwsIAMType != nil && *model.AwsIAMType != "NONE" {
+		return fmt.Errorf("GeneratePassword cannot be combined with AwsIAMType %s", *model.AwsIAMType)
+	}
+	if model.LdapAuthType != nil && *model.LdapAuthType != "NONE" {
+		return fmt.Errorf("GeneratePassword cannot be combined with LdapAuthType %s", *model.LdapAuthType)
+	}
+	if isX509(model) {
+		return fmt.Errorf("GeneratePassword cannot be combined with X509Type %s", *model.X509Type)
+	}
+	return nil
+}
+
+// validateX509Settings checks that X.509 users authenticate against $external without a password, and that only
+// MANAGED users, whose certificates Atlas signs, ask for a certificate
+func validateX509Settings(model *Model) error {
+	if !isX509(model) {
+		if model.CertificateMonthsUntilExpiration != nil {
+			return fmt.Errorf("CertificateMonthsUntilExpiration requires X509Type MANAGED")
+		}
+		if model.CertificateRotationTrigger != nil {
+			return fmt.Errorf("CertificateRotationTrigger requires CertificateMonthsUntilExpiration")
+		}
+		return nil
+	}
+	if model.DatabaseName == nil || *model.DatabaseName != "$external" {
+		return fmt.Errorf("X509Type %s requires the DatabaseName $external", *model.X509Type)
+	}
+	if model.Password != nil {
+		return fmt.Errorf("Password cannot be set when X509Type is %s", *model.X509Type)
+	}
+	if model.CertificateMonthsUntilExpiration != nil && *model.X509Type != "MANAGED" {
+		return fmt.Errorf("CertificateMonthsUntilExpiration requires X509Type MANAGED, Atlas does not issue %s certificates", *model.X509Type)
+	}
+	if model.CertificateRotationTrigger != nil && model.CertificateMonthsUntilExpiration == nil {
+		return fmt.Errorf("CertificateRotationTrigger requires CertificateMonthsUntilExpiration")
+	}
+	return nil
+}
+
+// validateDeleteAfterDate checks that an update keeps the DeleteAfterDate of a user, Atlas has no way to clear it
+// and would keep deleting the user at the old date
+func validateDeleteAfterDate(prevModel *Model, currentModel *Model) error {
+	if prevModel.DeleteAfterDate != nil && currentModel.DeleteAfterDate == nil {
+		return fmt.Errorf("DeleteAfterDate cannot be removed, set a later date or replace the user")
+	}
+	return nil
+}
+
+// passwordRotationRequested reports whether PasswordRotationTrigger got a new value, removing it rotates nothing
+func passwordRotationRequested(prevModel *Model, currentModel *Model) bool {
+	if !isTrue(currentModel.GeneratePassword) || currentModel.PasswordRotationTrigger == nil {
+		return false
+	}
+	return prevModel.PasswordRotationTrigger == nil || *prevModel.PasswordRotationTrigger != *currentModel.PasswordRotationTrigger
+}
+
+// certificateRotationRequested reports whether CertificateRotationTrigger got a new value, removing it rotates nothing
+func certificateRotationRequested(prevModel *Model, currentModel *Model) bool {
+	if currentModel.CertificateMonthsUntilExpiration == nil || currentModel.CertificateRotationTrigger == nil {
+		return false
+	}
+	return prevModel.CertificateRotationTrigger == nil || *prevModel.CertificateRotationTrigger != *currentModel.CertificateRotationTrigger
+}
+
+// rollbackCreate deletes the user and the secrets Create stored for it, a retry of the stack creates all of them again
+func rollbackCreate(client *mongodbatlas.Client, currentModel *Model, session *session.Session) error {
+	_, err := client.DatabaseUsers.Delete(context.Background(), *currentModel.DatabaseName, *currentModel.ProjectId, url.QueryEscape(*currentModel.Username))
+	if err != nil {
+		return fmt.Errorf("Error deleting database user: %s", err)
+	}
+	if currentModel.PasswordSecretArn != nil {
+		if err := deleteUserSecret(buildPasswordSecretName(*currentModel.UserCfnIdentifier), session); err != nil {
+			return fmt.Errorf("Error deleting password secret: %s", err)
+		}
+	}
+	if currentModel.CertificateSecretArn != nil {
+		if err := deleteUserSecret(buildCertificateSecretName(*currentModel.UserCfnIdentifier), session); err != nil {
+			return fmt.Errorf("Error deleting certificate secret: %s", err)
+		}
+	}
+	return nil
+}
+
+// createCertificateSecret issues a certificate for the MANAGED X.509 user and stores it in a new secret
+func createCertificateSecret(client *mongodbatlas.Client, model *Model, session *session.Session) (*string, error) {
+	userCertificate, _, err := client.X509AuthDBUsers.CreateUserCertificate(context.Background(), *model.ProjectId, url.PathEscape(*model.Username), *model.CertificateMonthsUntilExpiration)
+	if err != nil {
+		return nil, err
+	}
+
+	return createUserSecret(buildCertificateSecretName(*model.UserCfnIdentifier), fmt.Sprintf("X.509 certificate of the MongoDB Atlas database user %s of project %s", *model.Username, *model.ProjectId), newCertificateSecret(model, userCertificate.Certificate), session)
+}
+
+func newCertificateSecret(model *Model, certificate string) *certificateSecret {
+	return &certificateSecret{
+		Username:     *model.Username,
+		Certificate:  certificate,
+		DatabaseName: *model.DatabaseName,
+		ProjectId:    *model.ProjectId,
+	}
+}
+
+func newPasswordSecret(model *Model, password string) *passwordSecret {
+	return &passwordSecret{
+		Username:     *model.Username,
+		Password:     password,
+		DatabaseName: *model.DatabaseName,
+		ProjectId:    *model.ProjectId,
+	}
+}
+
+// sameInstant reports whether both ISO 8601 timestamps denote the same point in time
+func sameInstant(a, b string) bool {
+	ta, err := time.Parse(time.RFC3339, a)
+	if err != nil {
+		return false
+	}
+	tb, err := time.Parse(time.RFC3339, b)
+	if err != nil {
+		return false
+	}
+	return ta.Equal(tb)
+}
+
+func isX509(model *Model) bool {
+	return model.X509Type != nil && *model.X509Type != "NONE"
+}
+
+func isTrue(b *bool) bool {
+	return b != nil && *b
+}
+
 func buildUserCfnIdentifier(projectId *string, userName *string) string {
 	cfnid := fmt.Sprintf("%s-%s-%s", "user", strings.ToLower(strings.Replace(strings.Replace(*userName, ":", "", -1), "/", "_", -1)), *projectId)
 	return cfnid
 }
 
 type ParameterToBePersistedSpec struct {
-	ApiKeys      *ApiKeyDefinition
-	ProjectId    *string
-	Username     *string
-	DatabaseName *string
+	ApiKeys              *ApiKeyDefinition
+	ProjectId            *string
+	Username             *string
+	DatabaseName         *string
+	PasswordSecretArn    *string
+	CertificateSecretArn *string
 }
 
 func putParameterIntoParameterStore(resourcePrimaryIdentifier *string, params *ParameterToBePersistedSpec, session *session.Session) (*ssm.PutParameterOutput, error) {
@@ -0,0 +1,339 @@
+package resource
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/atlas/mongodbatlas"
+)
+
+// generatedPassword returns a model with a generated password, rotated by the trigger unless it is empty
+func generatedPassword(rotationTrigger string) *Model {
+	generate := true
+	model := &Model{GeneratePassword: &generate}
+	if rotationTrigger != "" {
+		model.PasswordRotationTrigger = &rotationTrigger
+	}
+	return model
+}
+
+// managedCertificate returns a MANAGED X.509 user with a certificate, rotated by the trigger unless it is empty
+func managedCertificate(rotationTrigger string) *Model {
+	databaseName, x509Type, months := "$external", "MANAGED", 3
+	model := &Model{DatabaseName: &databaseName, X509Type: &x509Type, CertificateMonthsUntilExpiration: &months}
+	if rotationTrigger != "" {
+		model.CertificateRotationTrigger = &rotationTrigger
+	}
+	return model
+}
+
+func TestFlattenDatabaseUser(t *testing.T) {
+	var model Model
+	flattenDatabaseUser(&model, &mongodbatlas.DatabaseUser{
+		Username:     "auditor",
+		DatabaseName: "admin",
+		Password:     "not-returned-by-atlas",
+		Roles: []mongodbatlas.Role{
+			{RoleName: "read", DatabaseName: "shop"},
+			{RoleName: "readWrite", DatabaseName: "reports", CollectionName: "daily"},
+		},
+		Scopes: []mongodbatlas.Scope{{Type: "CLUSTER", Name: "production"}},
+		Labels: []mongodbatlas.Label{{Key: "team", Value: "audit"}, {Key: "env", Value: "prod"}},
+	})
+
+	if model.Password != nil {
+		t.Errorf("want the password left out, got %s", *model.Password)
+	}
+	if len(model.Roles) != 2 || *model.Roles[0].RoleName != "read" || *model.Roles[1].RoleName != "readWrite" || *model.Roles[1].CollectionName != "daily" {
+		t.Errorf("want both roles, got %v", model.Roles)
+	}
+	if len(model.Scopes) != 1 || *model.Scopes[0].Name != "production" {
+		t.Errorf("want the production scope, got %v", model.Scopes)
+	}
+	if len(model.Labels) != 2 || *model.Labels[0].Key != "team" || *model.Labels[1].Key != "env" {
+		t.Errorf("want both labels, got %v", model.Labels)
+	}
+}
+
+func TestValidatePasswordSettings(t *testing.T) {
+	password, trigger := "secret", "2021-04"
+	none, role, user, managed := "NONE", "ROLE", "USER", "MANAGED"
+
+	withExplicitAuthTypes := generatedPassword("")
+	withExplicitAuthTypes.AwsIAMType = &none
+	withExplicitAuthTypes.LdapAuthType = &none
+	withPassword := generatedPassword("")
+	withPassword.Password = &password
+	forIAMUser := generatedPassword("")
+	forIAMUser.AwsIAMType = &role
+	forLDAPUser := generatedPassword("")
+	forLDAPUser.LdapAuthType = &user
+	forX509User := generatedPassword("")
+	forX509User.X509Type = &managed
+
+	testCases := []struct {
+		name    string
+		model   *Model
+		wantErr bool
+	}{
+		{"password", &Model{Password: &password}, false},
+		{"generated", generatedPassword("2021-04"), false},
+		{"generated with explicit auth types", withExplicitAuthTypes, false},
+		{"generated with password", withPassword, true},
+		{"generated for iam user", forIAMUser, true},
+		{"generated for ldap user", forLDAPUser, true},
+		{"generated for x509 user", forX509User, true},
+		{"rotation without generated password", &Model{Password: &password, PasswordRotationTrigger: &trigger}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validatePasswordSettings(tc.model)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("want error %t, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestPasswordRotationRequested(t *testing.T) {
+	testCases := []struct {
+		name    string
+		prev    *Model
+		current *Model
+		want    bool
+	}{
+		{"first value", generatedPassword(""), generatedPassword("1"), true},
+		{"new value", generatedPassword("1"), generatedPassword("2"), true},
+		{"same value", generatedPassword("1"), generatedPassword("1"), false},
+		{"removed", generatedPassword("1"), generatedPassword(""), false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := passwordRotationRequested(tc.prev, tc.current); got != tc.want {
+				t.Errorf("want %t, got %t", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestValidateX509Settings(t *testing.T) {
+	admin, external, password := "admin", "$external", "secret"
+	none, managed, customer := "NONE", "MANAGED", "CUSTOMER"
+	months := 3
+
+	managedOnAdmin := &Model{DatabaseName: &admin, X509Type: &managed}
+	managedWithPassword := &Model{DatabaseName: &external, X509Type: &managed, Password: &password}
+	customerWithCertificate := managedCertificate("")
+	customerWithCertificate.X509Type = &customer
+	rotationWithoutCertificate := managedCertificate("2021-04")
+	rotationWithoutCertificate.CertificateMonthsUntilExpiration = nil
+
+	testCases := []struct {
+		name    string
+		model   *Model
+		wantErr bool
+	}{
+		{"password user", &Model{DatabaseName: &admin, Password: &password, X509Type: &none}, false},
+		{"managed", &Model{DatabaseName: &external, X509Type: &managed}, false},
+		{"managed with certificate", managedCertificate("2021-04"), false},
+		{"customer", &Model{DatabaseName: &external, X509Type: &customer}, false},
+		{"managed on admin", managedOnAdmin, true},
+		{"managed with password", managedWithPassword, true},
+		{"customer with certificate", customerWithCertificate, true},
+		{"certificate without x509", &Model{DatabaseName: &admin, CertificateMonthsUntilExpiration: &months}, true},
+		{"rotation without certificate", rotationWithoutCertificate, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateX509Settings(tc.model)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("want error %t, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestCertificateRotationRequested(t *testing.T) {
+	testCases := []struct {
+		name    string
+		prev    *Model
+		current *Model
+		want    bool
+	}{
+		{"first value", managedCertificate(""), managedCertificate("1"), true},
+		{"new value", managedCertificate("1"), managedCertificate("2"), true},
+		{"same value", managedCertificate("1"), managedCertificate("1"), false},
+		{"removed", managedCertificate("1"), managedCertificate(""), false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := certificateRotationRequested(tc.prev, tc.current); got != tc.want {
+				t.Errorf("want %t, got %t", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestGeneratePassword(t *testing.T) {
+	first, err := generatePassword()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := generatePassword()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(first) != passwordLength {
+		t.Errorf("want %d characters, got %d", passwordLength, len(first))
+	}
+	if strings.Trim(first, passwordAlphabet) != "" {
+		t.Errorf("want only characters of the alphabet, got %s", first)
+	}
+	if first == second {
+		t.Errorf("want different passwords, got %s twice", first)
+	}
+}
+
+func TestFlattenDatabaseUserDeleteAfterDate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		template string
+		atlas    string
+		want     string
+	}{
+		{"same spelling", "2021-04-10T12:00:00Z", "2021-04-10T12:00:00Z", "2021-04-10T12:00:00Z"},
+		{"spelling of the template", "2021-04-10T14:00:00+02:00", "2021-04-10T12:00:00Z", "2021-04-10T14:00:00+02:00"},
+		{"changed in atlas", "2021-04-10T12:00:00Z", "2021-04-11T12:00:00Z", "2021-04-11T12:00:00Z"},
+		{"not in the template", "", "2021-04-10T12:00:00Z", "2021-04-10T12:00:00Z"},
+		{"removed in atlas", "2021-04-10T12:00:00Z", "", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			model := &Model{}
+			if tc.template != "" {
+				model.DeleteAfterDate = &tc.template
+			}
+			flattenDatabaseUser(model, &mongodbatlas.DatabaseUser{DeleteAfterDate: tc.atlas})
+
+			got := ""
+			if model.DeleteAfterDate != nil {
+				got = *model.DeleteAfterDate
+			}
+			if got != tc.want {
+				t.Errorf("want %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestValidateDeleteAfterDate(t *testing.T) {
+	date, later := "2021-04-10T12:00:00Z", "2021-04-12T12:00:00Z"
+
+	testCases := []struct {
+		name    string
+		prev    *Model
+		current *Model
+		wantErr bool
+	}{
+		{"set", &Model{}, &Model{DeleteAfterDate: &date}, false},
+		{"changed", &Model{DeleteAfterDate: &date}, &Model{DeleteAfterDate: &later}, false},
+		{"never set", &Model{}, &Model{}, false},
+		{"removed", &Model{DeleteAfterDate: &date}, &Model{}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateDeleteAfterDate(tc.prev, tc.current)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("want error %t, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestVersionOfStage(t *testing.T) {
+	previous, current, pending := "AWSPREVIOUS", stageCurrent, stagePending
+	versions := map[string][]*string{
+		"v1": {&previous},
+		"v2": {&current},
+		"v3": {&pending},
+	}
+
+	if got := versionOfStage(versions, stageCurrent); got == nil || *got != "v2" {
+		t.Errorf("want the current version v2, got %v", got)
+	}
+	if got := versionOfStage(map[string][]*string{"v1": {&previous}}, stageCurrent); got != nil {
+		t.Errorf("want no version, got %s", *got)
+	}
+}
+
+func TestListDatabaseUsersPages(t *testing.T) {
+	const total = 150
+	projectID := "5f1ea7d9ab3c1c2f3e9b0a00"
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/groups/"+projectID+"/databaseUsers" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		pageNum, _ := strconv.Atoi(r.URL.Query().Get("pageNum"))
+		itemsPerPage, _ := strconv.Atoi(r.URL.Query().Get("itemsPerPage"))
+
+		page := struct {
+			Links   []*mongodbatlas.Link        `json:"links"`
+			Results []mongodbatlas.DatabaseUser `json:"results"`
+		}{}
+		for i := (pageNum - 1) * itemsPerPage; i < total && i < pageNum*itemsPerPage; i++ {
+			page.Results = append(page.Results, mongodbatlas.DatabaseUser{
+				GroupID:      projectID,
+				Username:     fmt.Sprintf("CN=App/User:%d", i),
+				DatabaseName: "admin",
+				Password:     "not-returned",
+			})
+		}
+		if pageNum*itemsPerPage < total {
+			page.Links = append(page.Links, &mongodbatlas.Link{Rel: "next", Href: fmt.Sprintf("%s?pageNum=%d", r.URL.Path, pageNum+1)})
+		}
+		if err := json.NewEncoder(w).Encode(page); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer server.Close()
+
+	client := mongodbatlas.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	models, err := listDatabaseUsers(client, &projectID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(models) != total {
+		t.Errorf("want all %d users, got %d", total, len(models))
+	}
+	if requests != 2 {
+		t.Errorf("want 2 pages requested, got %d", requests)
+	}
+	for _, m := range models {
+		model := m.(Model)
+		if model.Password != nil {
+			t.Errorf("want no password for %s, got %s", *model.Username, *model.Password)
+		}
+		want := buildUserCfnIdentifier(&projectID, model.Username)
+		if model.UserCfnIdentifier == nil || *model.UserCfnIdentifier != want {
+			t.Errorf("want identifier %s for %s, got %v", want, *model.Username, model.UserCfnIdentifier)
+		}
+	}
+}
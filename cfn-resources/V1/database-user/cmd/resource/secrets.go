@@ -0,0 +1,178 @@
+package resource
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/422158/mongodbstpatlas-cloudformation-resources/cfn-resources/V1/database-user/cmd/util"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+)
+
+// generated passwords only use characters that need no escaping in connection strings
+const (
+	passwordLength   = 32
+	passwordAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+)
+
+// staging labels Secrets Manager gives the versions of a secret, readers get the AWSCURRENT version by default
+const (
+	stageCurrent = "AWSCURRENT"
+	stagePending = "AWSPENDING"
+)
+
+// passwordSecret is what the secret of a database user with a generated password holds
+type passwordSecret struct {
+	Username     string `json:"username"`
+	Password     string `json:"password"`
+	DatabaseName string `json:"databaseName"`
+	ProjectId    string `json:"projectId"`
+}
+
+// certificateSecret is what the secret of a database user with an Atlas-managed X.509 certificate holds, the
+// certificate is PEM encoded and includes its private key
+type certificateSecret struct {
+	Username     string `json:"username"`
+	Certificate  string `json:"certificate"`
+	DatabaseName string `json:"databaseName"`
+	ProjectId    string `json:"projectId"`
+}
+
+func generatePassword() (string, error) {
+	password := make([]byte, passwordLength)
+	max := big.NewInt(int64(len(passwordAlphabet)))
+	for i := range password {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		password[i] = passwordAlphabet[n.Int64()]
+	}
+	return string(password), nil
+}
+
+func createUserSecret(secretName string, description string, secret interface{}, session *session.Session) (*string, error) {
+	secretsManagerClient, err := util.CreateSecretsManagerClient(session)
+	if err != nil {
+		return nil, err
+	}
+	secretString, err := stringifySecret(secret)
+	if err != nil {
+		return nil, err
+	}
+	createSecretOutput, err := secretsManagerClient.CreateSecret(&secretsmanager.CreateSecretInput{Name: &secretName, Description: &description, SecretString: &secretString})
+	if err != nil {
+		return nil, fmt.Errorf("Unable to create secret %s: %s", secretName, err)
+	}
+
+	return createSecretOutput.ARN, nil
+}
+
+func putUserSecret(secretName string, secret interface{}, session *session.Session) error {
+	secretsManagerClient, err := util.CreateSecretsManagerClient(session)
+	if err != nil {
+		return err
+	}
+	secretString, err := stringifySecret(secret)
+	if err != nil {
+		return err
+	}
+	_, err = secretsManagerClient.PutSecretValue(&secretsmanager.PutSecretValueInput{SecretId: &secretName, SecretString: &secretString})
+	if err != nil {
+		return fmt.Errorf("Unable to put secret value %s: %s", secretName, err)
+	}
+
+	return nil
+}
+
+// putPendingUserSecret stores the secret as the AWSPENDING version, readers of the secret keep getting the
+// AWSCURRENT version until promoteUserSecret moves that stage to it
+func putPendingUserSecret(secretName string, secret interface{}, session *session.Session) (*string, error) {
+	secretsManagerClient, err := util.CreateSecretsManagerClient(session)
+	if err != nil {
+		return nil, err
+	}
+	secretString, err := stringifySecret(secret)
+	if err != nil {
+		return nil, err
+	}
+	stage := stagePending
+	putSecretValueOutput, err := secretsManagerClient.PutSecretValue(&secretsmanager.PutSecretValueInput{SecretId: &secretName, SecretString: &secretString, VersionStages: []*string{&stage}})
+	if err != nil {
+		return nil, fmt.Errorf("Unable to put pending secret value %s: %s", secretName, err)
+	}
+
+	return putSecretValueOutput.VersionId, nil
+}
+
+// promoteUserSecret makes the version AWSCURRENT, the version it replaces keeps the AWSPREVIOUS stage
+func promoteUserSecret(secretName string, versionID *string, session *session.Session) error {
+	secretsManagerClient, err := util.CreateSecretsManagerClient(session)
+	if err != nil {
+		return err
+	}
+	describeSecretOutput, err := secretsManagerClient.DescribeSecret(&secretsmanager.DescribeSecretInput{SecretId: &secretName})
+	if err != nil {
+		return fmt.Errorf("Unable to describe secret %s: %s", secretName, err)
+	}
+	currentVersionID := versionOfStage(describeSecretOutput.VersionIdsToStages, stageCurrent)
+	stage := stageCurrent
+	_, err = secretsManagerClient.UpdateSecretVersionStage(&secretsmanager.UpdateSecretVersionStageInput{SecretId: &secretName, VersionStage: &stage, MoveToVersionId: versionID, RemoveFromVersionId: currentVersionID})
+	if err != nil {
+		return fmt.Errorf("Unable to promote version %s of secret %s: %s", *versionID, secretName, err)
+	}
+
+	return nil
+}
+
+// versionOfStage returns the version the stage is attached to, nil when no version has it
+func versionOfStage(versionIdsToStages map[string][]*string, stage string) *string {
+	for versionID, stages := range versionIdsToStages {
+		for _, s := range stages {
+			if s != nil && *s == stage {
+				versionID := versionID
+				return &versionID
+			}
+		}
+	}
+	return nil
+}
+
+// deleteUserSecret deletes the secret right away, a secret waiting out its recovery window would keep a
+// replacement user with the same name from creating its own secret. A secret that is already gone is not an error.
+func deleteUserSecret(secretName string, session *session.Session) error {
+	secretsManagerClient, err := util.CreateSecretsManagerClient(session)
+	if err != nil {
+		return err
+	}
+	forceDelete := true
+	_, err = secretsManagerClient.DeleteSecret(&secretsmanager.DeleteSecretInput{SecretId: &secretName, ForceDeleteWithoutRecovery: &forceDelete})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == secretsmanager.ErrCodeResourceNotFoundException {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Unable to delete secret %s: %s", secretName, err)
+	}
+
+	return nil
+}
+
+func stringifySecret(secret interface{}) (string, error) {
+	byteSecret, err := json.Marshal(secret)
+	if err != nil {
+		return "", err
+	}
+	return string(byteSecret), nil
+}
+
+// the secrets are named like the parameter of the user, changing this orphans the secrets of existing users
+func buildPasswordSecretName(resourcePrimaryIdentifier string) string {
+	return fmt.Sprintf("%s-%s", "mongodbstpatlasv1databaseuser", resourcePrimaryIdentifier)
+}
+
+func buildCertificateSecretName(resourcePrimaryIdentifier string) string {
+	return fmt.Sprintf("%s-%s-certificate", "mongodbstpatlasv1databaseuser", resourcePrimaryIdentifier)
+}
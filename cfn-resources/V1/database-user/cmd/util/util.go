@@ -3,6 +3,7 @@ package util
 import (
 	"github.com/Sectorbob/mlab-ns2/gae/ns/digest"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
 	"github.com/aws/aws-sdk-go/service/ssm"
 	"go.mongodb.org/atlas/mongodbatlas"
 )
@@ -31,3 +32,8 @@ func CreateSSMClient(session *session.Session) (*ssm.SSM, error) {
 	ssmCli := ssm.New(session)
 	return ssmCli, nil
 }
+
+func CreateSecretsManagerClient(session *session.Session) (*secretsmanager.SecretsManager, error) {
+	secretsManagerCli := secretsmanager.New(session)
+	return secretsManagerCli, nil
+}
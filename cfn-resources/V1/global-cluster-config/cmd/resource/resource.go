@@ -0,0 +1,575 @@
+package resource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/422158/mongodbstpatlas-cloudformation-resources/cfn-resources/V1/global-cluster-config/cmd/util"
+	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/spf13/cast"
+	"go.mongodb.org/atlas/mongodbatlas"
+)
+
+// Create handles the Create event from the Cloudformation service.
+// Namespaces and zone mappings the cluster already has are left alone, so running Create again does not fail, a
+// namespace the cluster manages with another shard key fails the creation.
+func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	client, err := util.CreateMongoDBClient(*currentModel.ApiKeys.PublicKey, *currentModel.ApiKeys.PrivateKey)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	projectID := *currentModel.ProjectId
+	clusterName := *currentModel.ClusterName
+
+	globalCluster, zoneNames, err := getGlobalCluster(client, projectID, clusterName)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	// an existing namespace is only left alone when it is the one the template asks for
+	if conflicting := conflictingNamespaces(globalCluster.ManagedNamespaces, currentModel.ManagedNamespaces); len(conflicting) > 0 {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          fmt.Sprintf("cluster (%s) already manages %s with another custom shard key, remove the namespace from the cluster or use its current shard key", clusterName, strings.Join(conflicting, ", ")),
+			HandlerErrorCode: "AlreadyExists",
+		}, nil
+	}
+
+	err = addManagedNamespaces(client, projectID, clusterName, currentModel.ManagedNamespaces, globalCluster)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	err = addCustomZoneMappings(client, projectID, clusterName, currentModel.CustomZoneMappings, flattenCustomZoneMappings(globalCluster.CustomZoneMapping, zoneNames))
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	cfnid := buildGlobalClusterCfnIdentifier(currentModel.ProjectId, currentModel.ClusterName)
+
+	currentModel.GlobalClusterCfnIdentifier = &cfnid
+
+	// putting required parameters into parameter store (needed for read operation)
+	_, err = putParameterIntoParameterStore(currentModel.GlobalClusterCfnIdentifier, &ParameterToBePersistedSpec{ApiKeys: currentModel.ApiKeys, ProjectId: currentModel.ProjectId, ClusterName: currentModel.ClusterName}, req.Session)
+	if err != nil {
+		return handler.ProgressEvent{}, fmt.Errorf("error when putting api keys into parameter store: %s", err)
+	}
+
+	return handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		Message:         "Create Complete",
+		ResourceModel:   currentModel,
+	}, nil
+}
+
+// Read handles the Read event from the Cloudformation service.
+func Read(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	params, err := getParameterFromParameterStore(currentModel.GlobalClusterCfnIdentifier, req.Session)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	client, err := util.CreateMongoDBClient(*params.ApiKeys.PublicKey, *params.ApiKeys.PrivateKey)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	globalCluster, zoneNames, err := getGlobalCluster(client, *params.ProjectId, *params.ClusterName)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	currentModel.ProjectId = params.ProjectId
+	currentModel.ClusterName = params.ClusterName
+	currentModel.ManagedNamespaces = flattenManagedNamespaces(globalCluster.ManagedNamespaces)
+	currentModel.CustomZoneMappings = flattenCustomZoneMappings(globalCluster.CustomZoneMapping, zoneNames)
+
+	return handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		Message:         "Read Complete",
+		ResourceModel:   currentModel,
+	}, nil
+}
+
+// Update handles the Update event from the Cloudformation service.
+func Update(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	client, err := util.CreateMongoDBClient(*currentModel.ApiKeys.PublicKey, *currentModel.ApiKeys.PrivateKey)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	// Atlas shards the collection on the key, it cannot be changed afterwards
+	if changed := changedShardKeys(prevModel.ManagedNamespaces, currentModel.ManagedNamespaces); len(changed) > 0 {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          fmt.Sprintf("the custom shard key of %s cannot be changed, replace the namespace with a new collection instead", strings.Join(changed, ", ")),
+			HandlerErrorCode: "NotUpdatable",
+		}, nil
+	}
+
+	projectID := *currentModel.ProjectId
+	clusterName := *currentModel.ClusterName
+
+	globalCluster, zoneNames, err := getGlobalCluster(client, projectID, clusterName)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	if conflicting := conflictingNamespaces(globalCluster.ManagedNamespaces, currentModel.ManagedNamespaces); len(conflicting) > 0 {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          fmt.Sprintf("cluster (%s) already manages %s with another custom shard key, remove the namespace from the cluster or use its current shard key", clusterName, strings.Join(conflicting, ", ")),
+			HandlerErrorCode: "AlreadyExists",
+		}, nil
+	}
+
+	err = removeManagedNamespaces(client, projectID, clusterName, removedNamespaces(prevModel.ManagedNamespaces, currentModel.ManagedNamespaces))
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	err = addManagedNamespaces(client, projectID, clusterName, currentModel.ManagedNamespaces, globalCluster)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	existingMappings := flattenCustomZoneMappings(globalCluster.CustomZoneMapping, zoneNames)
+	if !sameCustomZoneMappings(existingMappings, currentModel.CustomZoneMappings) {
+		// Atlas can only remove every mapping at once, the wanted ones are added back afterwards
+		_, _, err = client.GlobalClusters.DeleteCustomZoneMappings(context.Background(), projectID, clusterName)
+		if err != nil {
+			return handler.ProgressEvent{}, fmt.Errorf("error removing custom zone mappings of cluster (%s): %s", clusterName, err)
+		}
+
+		err = addCustomZoneMappings(client, projectID, clusterName, currentModel.CustomZoneMappings, nil)
+		if err != nil {
+			return handler.ProgressEvent{}, err
+		}
+	}
+
+	cfnid := buildGlobalClusterCfnIdentifier(currentModel.ProjectId, currentModel.ClusterName)
+
+	currentModel.GlobalClusterCfnIdentifier = &cfnid
+
+	// putting required parameter into parameter store
+	// the api keys might have been updated therefore we need to do this here
+	_, err = putParameterIntoParameterStore(currentModel.GlobalClusterCfnIdentifier, &ParameterToBePersistedSpec{ApiKeys: currentModel.ApiKeys, ProjectId: currentModel.ProjectId, ClusterName: currentModel.ClusterName}, req.Session)
+	if err != nil {
+		return handler.ProgressEvent{}, fmt.Errorf("error when putting api keys into parameter store: %s", err)
+	}
+
+	return handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		Message:         "Update Complete",
+		ResourceModel:   currentModel,
+	}, nil
+}
+
+// Delete handles the Delete event from the Cloudformation service.
+// Namespaces that are already gone, or a cluster that was deleted first, do not fail the deletion.
+func Delete(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	client, err := util.CreateMongoDBClient(*currentModel.ApiKeys.PublicKey, *currentModel.ApiKeys.PrivateKey)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	projectID := *currentModel.ProjectId
+	clusterName := *currentModel.ClusterName
+
+	err = deleteGlobalClusterConfig(client, projectID, clusterName, currentModel)
+	if err != nil {
+		// even when error occurs when deleting, we still want to delete parameter from parameter store
+		_, errParams := deleteParameterFromParameterStore(currentModel.GlobalClusterCfnIdentifier, req.Session)
+		if errParams != nil {
+			return handler.ProgressEvent{
+				OperationStatus:  handler.Failed,
+				Message:          "Delete Failed",
+				HandlerErrorCode: "GeneralServiceException",
+			}, fmt.Errorf("Error deleting global cluster configuration of cluster (%s): %s.\nError deleting api keys from parameter store: %s", clusterName, err, errParams)
+		}
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          "Delete Failed",
+			HandlerErrorCode: "GeneralServiceException",
+		}, err
+	}
+
+	_, err = deleteParameterFromParameterStore(currentModel.GlobalClusterCfnIdentifier, req.Session)
+	if err != nil {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          "Delete Failed",
+			HandlerErrorCode: "GeneralServiceException",
+		}, fmt.Errorf("error deleting parameters for global cluster configuration of cluster %s: %s", clusterName, err)
+	}
+
+	return handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		Message:         "Delete Complete",
+		ResourceModel:   currentModel,
+	}, nil
+}
+
+// List handles the List event from the Cloudformation service.
+// Every GEOSHARDED cluster of the project is reported with its configuration.
+func List(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	client, err := util.CreateMongoDBClient(*currentModel.ApiKeys.PublicKey, *currentModel.ApiKeys.PrivateKey)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	projectID := *currentModel.ProjectId
+
+	const itemsPerPage = 100
+
+	models := make([]interface{}, 0)
+	for pageNum := 1; ; pageNum++ {
+		clusters, resp, err := client.Clusters.List(context.Background(), projectID, &mongodbatlas.ListOptions{PageNum: pageNum, ItemsPerPage: itemsPerPage})
+		if err != nil {
+			return handler.ProgressEvent{}, fmt.Errorf("error listing clusters for project (%s): %s", projectID, err)
+		}
+
+		for i := range clusters {
+			if clusters[i].ClusterType != "GEOSHARDED" {
+				continue
+			}
+
+			globalCluster, _, err := client.GlobalClusters.Get(context.Background(), projectID, clusters[i].Name)
+			if err != nil {
+				return handler.ProgressEvent{}, fmt.Errorf("error fetching global cluster configuration of cluster (%s): %s", clusters[i].Name, err)
+			}
+
+			var model Model
+			model.ProjectId = currentModel.ProjectId
+			model.ClusterName = &clusters[i].Name
+			model.ManagedNamespaces = flattenManagedNamespaces(globalCluster.ManagedNamespaces)
+			model.CustomZoneMappings = flattenCustomZoneMappings(globalCluster.CustomZoneMapping, zoneNamesByID(&clusters[i]))
+			cfnid := buildGlobalClusterCfnIdentifier(model.ProjectId, model.ClusterName)
+			model.GlobalClusterCfnIdentifier = &cfnid
+			models = append(models, model)
+		}
+
+		if len(clusters) < itemsPerPage || resp.IsLastPage() {
+			break
+		}
+	}
+
+	return handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		Message:         "List Complete",
+		ResourceModels:  models,
+	}, nil
+}
+
+// getGlobalCluster returns the configuration of the cluster along with the names of its zones by id,
+// Atlas reports the zone of a custom mapping by the id of the replication spec
+func getGlobalCluster(client *mongodbatlas.Client, projectID, clusterName string) (*mongodbatlas.GlobalCluster, map[string]string, error) {
+	cluster, _, err := client.Clusters.Get(context.Background(), projectID, clusterName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error fetching cluster info (%s): %s", clusterName, err)
+	}
+
+	globalCluster, _, err := client.GlobalClusters.Get(context.Background(), projectID, clusterName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error fetching global cluster configuration of cluster (%s): %s", clusterName, err)
+	}
+
+	return globalCluster, zoneNamesByID(cluster), nil
+}
+
+func zoneNamesByID(cluster *mongodbatlas.Cluster) map[string]string {
+	zoneNames := make(map[string]string, len(cluster.ReplicationSpecs))
+	for _, spec := range cluster.ReplicationSpecs {
+		zoneNames[spec.ID] = spec.ZoneName
+	}
+	return zoneNames
+}
+
+func addManagedNamespaces(client *mongodbatlas.Client, projectID, clusterName string, namespaces []ManagedNamespace, globalCluster *mongodbatlas.GlobalCluster) error {
+	existing := make(map[string]bool, len(globalCluster.ManagedNamespaces))
+	for _, namespace := range globalCluster.ManagedNamespaces {
+		existing[namespaceKey(namespace.Db, namespace.Collection)] = true
+	}
+
+	for _, namespace := range namespaces {
+		request := expandManagedNamespace(namespace)
+		if existing[namespaceKey(request.Db, request.Collection)] {
+			continue
+		}
+
+		_, _, err := client.GlobalClusters.AddManagedNamespace(context.Background(), projectID, clusterName, request)
+		if err != nil {
+			return fmt.Errorf("error adding managed namespace (%s) to cluster (%s): %s", namespaceKey(request.Db, request.Collection), clusterName, err)
+		}
+	}
+	return nil
+}
+
+// removeManagedNamespaces removes the namespaces from the cluster, a namespace Atlas no longer knows is skipped
+func removeManagedNamespaces(client *mongodbatlas.Client, projectID, clusterName string, namespaces []ManagedNamespace) error {
+	for _, namespace := range namespaces {
+		request := expandManagedNamespace(namespace)
+
+		_, resp, err := client.GlobalClusters.DeleteManagedNamespace(context.Background(), projectID, clusterName, request)
+		if err != nil && (resp == nil || resp.StatusCode != 404) {
+			return fmt.Errorf("error removing managed namespace (%s) from cluster (%s): %s", namespaceKey(request.Db, request.Collection), clusterName, err)
+		}
+	}
+	return nil
+}
+
+// addCustomZoneMappings maps the locations that existing does not already map to the wanted zone
+func addCustomZoneMappings(client *mongodbatlas.Client, projectID, clusterName string, mappings []CustomZoneMapping, existing []CustomZoneMapping) error {
+	mapped := make(map[string]string, len(existing))
+	for _, mapping := range existing {
+		mapped[cast.ToString(mapping.Location)] = cast.ToString(mapping.Zone)
+	}
+
+	request := &mongodbatlas.CustomZoneMappingsRequest{}
+	for _, mapping := range mappings {
+		location, zone := cast.ToString(mapping.Location), cast.ToString(mapping.Zone)
+		if zoneName, ok := mapped[location]; ok && zoneName == zone {
+			continue
+		}
+		request.CustomZoneMappings = append(request.CustomZoneMappings, mongodbatlas.CustomZoneMapping{Location: location, Zone: zone})
+	}
+
+	if len(request.CustomZoneMappings) == 0 {
+		return nil
+	}
+
+	_, _, err := client.GlobalClusters.AddCustomZoneMappings(context.Background(), projectID, clusterName, request)
+	if err != nil {
+		return fmt.Errorf("error adding custom zone mappings to cluster (%s): %s", clusterName, err)
+	}
+	return nil
+}
+
+func deleteGlobalClusterConfig(client *mongodbatlas.Client, projectID, clusterName string, model *Model) error {
+	_, resp, err := client.GlobalClusters.Get(context.Background(), projectID, clusterName)
+	if err != nil {
+		if resp != nil && resp.StatusCode == 404 {
+			// the cluster is gone and its configuration with it
+			return nil
+		}
+		return fmt.Errorf("error fetching global cluster configuration of cluster (%s): %s", clusterName, err)
+	}
+
+	err = removeManagedNamespaces(client, projectID, clusterName, model.ManagedNamespaces)
+	if err != nil {
+		return err
+	}
+
+	if len(model.CustomZoneMappings) > 0 {
+		_, resp, err = client.GlobalClusters.DeleteCustomZoneMappings(context.Background(), projectID, clusterName)
+		if err != nil && (resp == nil || resp.StatusCode != 404) {
+			return fmt.Errorf("error removing custom zone mappings of cluster (%s): %s", clusterName, err)
+		}
+	}
+	return nil
+}
+
+func expandManagedNamespace(namespace ManagedNamespace) *mongodbatlas.ManagedNamespace {
+	return &mongodbatlas.ManagedNamespace{
+		Db:             cast.ToString(namespace.Db),
+		Collection:     cast.ToString(namespace.Collection),
+		CustomShardKey: cast.ToString(namespace.CustomShardKey),
+	}
+}
+
+// flattenManagedNamespaces returns the namespaces sorted by database and collection
+func flattenManagedNamespaces(managedNamespaces []mongodbatlas.ManagedNamespace) []ManagedNamespace {
+	sorted := make([]mongodbatlas.ManagedNamespace, len(managedNamespaces))
+	copy(sorted, managedNamespaces)
+	sort.Slice(sorted, func(i, j int) bool {
+		return namespaceKey(sorted[i].Db, sorted[i].Collection) < namespaceKey(sorted[j].Db, sorted[j].Collection)
+	})
+
+	var namespaces []ManagedNamespace
+	for i := range sorted {
+		namespace := ManagedNamespace{
+			Db:         &sorted[i].Db,
+			Collection: &sorted[i].Collection,
+		}
+		if sorted[i].CustomShardKey != "" {
+			namespace.CustomShardKey = &sorted[i].CustomShardKey
+		}
+		namespaces = append(namespaces, namespace)
+	}
+	return namespaces
+}
+
+// flattenCustomZoneMappings returns the mappings sorted by location, with the zone ids Atlas reports replaced by
+// the zone names the template uses
+func flattenCustomZoneMappings(customZoneMapping map[string]string, zoneNames map[string]string) []CustomZoneMapping {
+	locations := make([]string, 0, len(customZoneMapping))
+	for location := range customZoneMapping {
+		locations = append(locations, location)
+	}
+	sort.Strings(locations)
+
+	var mappings []CustomZoneMapping
+	for i := range locations {
+		zone := customZoneMapping[locations[i]]
+		if zoneName, ok := zoneNames[zone]; ok && zoneName != "" {
+			zone = zoneName
+		}
+		mappings = append(mappings, CustomZoneMapping{
+			Location: &locations[i],
+			Zone:     &zone,
+		})
+	}
+	return mappings
+}
+
+// changedShardKeys returns the namespaces present in both lists whose custom shard key differs
+func changedShardKeys(prev, current []ManagedNamespace) []string {
+	shardKeys := make(map[string]string, len(prev))
+	for _, namespace := range prev {
+		shardKeys[namespaceKey(cast.ToString(namespace.Db), cast.ToString(namespace.Collection))] = cast.ToString(namespace.CustomShardKey)
+	}
+
+	var changed []string
+	for _, namespace := range current {
+		key := namespaceKey(cast.ToString(namespace.Db), cast.ToString(namespace.Collection))
+		if shardKey, ok := shardKeys[key]; ok && shardKey != cast.ToString(namespace.CustomShardKey) {
+			changed = append(changed, key)
+		}
+	}
+	return changed
+}
+
+// conflictingNamespaces returns the namespaces of current that Atlas already manages with another custom shard key
+func conflictingNamespaces(existing []mongodbatlas.ManagedNamespace, current []ManagedNamespace) []string {
+	shardKeys := make(map[string]string, len(existing))
+	for _, namespace := range existing {
+		shardKeys[namespaceKey(namespace.Db, namespace.Collection)] = namespace.CustomShardKey
+	}
+
+	var conflicting []string
+	for _, namespace := range current {
+		key := namespaceKey(cast.ToString(namespace.Db), cast.ToString(namespace.Collection))
+		if shardKey, ok := shardKeys[key]; ok && shardKey != cast.ToString(namespace.CustomShardKey) {
+			conflicting = append(conflicting, key)
+		}
+	}
+	return conflicting
+}
+
+// removedNamespaces returns the namespaces of prev that current no longer lists
+func removedNamespaces(prev, current []ManagedNamespace) []ManagedNamespace {
+	kept := make(map[string]bool, len(current))
+	for _, namespace := range current {
+		kept[namespaceKey(cast.ToString(namespace.Db), cast.ToString(namespace.Collection))] = true
+	}
+
+	var removed []ManagedNamespace
+	for _, namespace := range prev {
+		if !kept[namespaceKey(cast.ToString(namespace.Db), cast.ToString(namespace.Collection))] {
+			removed = append(removed, namespace)
+		}
+	}
+	return removed
+}
+
+// sameCustomZoneMappings reports whether both lists map the same locations to the same zones, in any order
+func sameCustomZoneMappings(a, b []CustomZoneMapping) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	zones := make(map[string]string, len(a))
+	for _, mapping := range a {
+		zones[cast.ToString(mapping.Location)] = cast.ToString(mapping.Zone)
+	}
+	for _, mapping := range b {
+		if zone, ok := zones[cast.ToString(mapping.Location)]; !ok || zone != cast.ToString(mapping.Zone) {
+			return false
+		}
+	}
+	return true
+}
+
+func namespaceKey(db, collection string) string {
+	return fmt.Sprintf("%s.%s", db, collection)
+}
+
+type ParameterToBePersistedSpec struct {
+	ApiKeys     *ApiKeyDefinition
+	ProjectId   *string
+	ClusterName *string
+}
+
+func putParameterIntoParameterStore(resourcePrimaryIdentifier *string, params *ParameterToBePersistedSpec, session *session.Session) (*ssm.PutParameterOutput, error) {
+	ssmClient, err := util.CreateSSMClient(session)
+	if err != nil {
+		return nil, err
+	}
+	// transform api keys to json string
+	parameterName := buildApiKeyParameterName(*resourcePrimaryIdentifier)
+	byteParams, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	stringifiedParams := string(byteParams)
+	parameterType := "SecureString"
+	overwrite := true
+	putParamOutput, err := ssmClient.PutParameter(&ssm.PutParameterInput{Name: &parameterName, Value: &stringifiedParams, Type: &parameterType, Overwrite: &overwrite})
+	if err != nil {
+		return nil, err
+	}
+
+	return putParamOutput, nil
+}
+
+func deleteParameterFromParameterStore(resourcePrimaryIdentifier *string, session *session.Session) (*ssm.DeleteParameterOutput, error) {
+	ssmClient, err := util.CreateSSMClient(session)
+	if err != nil {
+		return nil, err
+	}
+	parameterName := buildApiKeyParameterName(*resourcePrimaryIdentifier)
+
+	deleteParamOutput, err := ssmClient.DeleteParameter(&ssm.DeleteParameterInput{Name: &parameterName})
+	if err != nil {
+		return nil, err
+	}
+
+	return deleteParamOutput, nil
+}
+
+func getParameterFromParameterStore(resourcePrimaryIdentifier *string, session *session.Session) (*ParameterToBePersistedSpec, error) {
+	ssmClient, err := util.CreateSSMClient(session)
+	if err != nil {
+		return nil, err
+	}
+	parameterName := buildApiKeyParameterName(*resourcePrimaryIdentifier)
+	decrypt := true
+	getParamOutput, err := ssmClient.GetParameter(&ssm.GetParameterInput{Name: &parameterName, WithDecryption: &decrypt})
+	if err != nil {
+		return nil, err
+	}
+
+	var params ParameterToBePersistedSpec
+	err = json.Unmarshal([]byte(*getParamOutput.Parameter.Value), &params)
+	if err != nil {
+		return nil, err
+	}
+	return &params, nil
+}
+
+func buildGlobalClusterCfnIdentifier(projectId *string, clusterName *string) string {
+	return fmt.Sprintf("%s-%s", *projectId, *clusterName)
+}
+
+func buildApiKeyParameterName(resourcePrimaryIdentifier string) string {
+	// this is strictly coupled with permissions for handlers, changing this means changing permissions in handler
+	// moreover changing this might cause polution in parameter store -  be sure you know what you are doing
+	parameterStorePrefix := "mongodbstpatlasv1globalclusterconfig"
+	return fmt.Sprintf("%s-%s", parameterStorePrefix, resourcePrimaryIdentifier)
+}
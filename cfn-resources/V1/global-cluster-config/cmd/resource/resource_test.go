@@ -0,0 +1,84 @@
+package resource
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/atlas/mongodbatlas"
+)
+
+func namespace(db, collection, shardKey string) ManagedNamespace {
+	return ManagedNamespace{Db: &db, Collection: &collection, CustomShardKey: &shardKey}
+}
+
+func mapping(location, zone string) CustomZoneMapping {
+	return CustomZoneMapping{Location: &location, Zone: &zone}
+}
+
+func TestFlattenCustomZoneMappings(t *testing.T) {
+	got := flattenCustomZoneMappings(
+		map[string]string{"US": "5b1f0fe7c0c6e30bb3fd2a10", "DE": "5b1f0fe7c0c6e30bb3fd2a11", "JP": "unknown"},
+		map[string]string{"5b1f0fe7c0c6e30bb3fd2a10": "Zone America", "5b1f0fe7c0c6e30bb3fd2a11": "Zone Europe"},
+	)
+
+	want := []CustomZoneMapping{
+		mapping("DE", "Zone Europe"),
+		mapping("JP", "unknown"),
+		mapping("US", "Zone America"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestChangedShardKeys(t *testing.T) {
+	prev := []ManagedNamespace{namespace("shop", "orders", "customerId"), namespace("shop", "carts", "customerId")}
+	current := []ManagedNamespace{namespace("shop", "orders", "orderId"), namespace("shop", "carts", "customerId"), namespace("shop", "users", "userId")}
+
+	if got := changedShardKeys(prev, current); !reflect.DeepEqual(got, []string{"shop.orders"}) {
+		t.Errorf("want [shop.orders], got %v", got)
+	}
+}
+
+func TestConflictingNamespaces(t *testing.T) {
+	existing := []mongodbatlas.ManagedNamespace{
+		{Db: "shop", Collection: "orders", CustomShardKey: "customerId"},
+		{Db: "shop", Collection: "carts", CustomShardKey: "customerId"},
+	}
+	current := []ManagedNamespace{namespace("shop", "orders", "orderId"), namespace("shop", "carts", "customerId"), namespace("shop", "users", "userId")}
+
+	if got := conflictingNamespaces(existing, current); !reflect.DeepEqual(got, []string{"shop.orders"}) {
+		t.Errorf("want [shop.orders], got %v", got)
+	}
+}
+
+func TestRemovedNamespaces(t *testing.T) {
+	prev := []ManagedNamespace{namespace("shop", "orders", "customerId"), namespace("shop", "carts", "customerId")}
+	current := []ManagedNamespace{namespace("shop", "orders", "customerId")}
+
+	got := removedNamespaces(prev, current)
+	if len(got) != 1 || *got[0].Collection != "carts" {
+		t.Errorf("want shop.carts removed, got %v", got)
+	}
+}
+
+func TestSameCustomZoneMappings(t *testing.T) {
+	testCases := []struct {
+		name string
+		a, b []CustomZoneMapping
+		want bool
+	}{
+		{"both empty", nil, nil, true},
+		{"other order", []CustomZoneMapping{mapping("US", "A"), mapping("DE", "B")}, []CustomZoneMapping{mapping("DE", "B"), mapping("US", "A")}, true},
+		{"other zone", []CustomZoneMapping{mapping("US", "A")}, []CustomZoneMapping{mapping("US", "B")}, false},
+		{"added location", []CustomZoneMapping{mapping("US", "A")}, []CustomZoneMapping{mapping("US", "A"), mapping("DE", "B")}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := sameCustomZoneMappings(tc.a, tc.b); got != tc.want {
+				t.Errorf("want %t, got %t", tc.want, got)
+			}
+		})
+	}
+}
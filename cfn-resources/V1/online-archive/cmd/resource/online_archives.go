@@ -0,0 +1,123 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"go.mongodb.org/atlas/mongodbatlas"
+)
+
+// the online archive type of the mongodbatlas client has neither the query of the custom criteria nor the
+// schedule, the endpoints are called directly instead, paths are resolved relative to the client base url
+// (https://cloud.mongodb.com/api/atlas/v1.0/)
+const onlineArchivesPath = "groups/%s/clusters/%s/onlineArchives"
+
+type onlineArchive struct {
+	ID              string                   `json:"_id,omitempty"`
+	ClusterName     string                   `json:"clusterName,omitempty"`
+	DBName          string                   `json:"dbName,omitempty"`
+	CollName        string                   `json:"collName,omitempty"`
+	Criteria        *onlineArchiveCriteria   `json:"criteria,omitempty"`
+	PartitionFields []onlineArchivePartition `json:"partitionFields,omitempty"`
+	Schedule        *onlineArchiveSchedule   `json:"schedule,omitempty"`
+	Paused          *bool                    `json:"paused,omitempty"`
+	State           string                   `json:"state,omitempty"`
+}
+
+type onlineArchiveCriteria struct {
+	Type            string `json:"type,omitempty"`
+	DateField       string `json:"dateField,omitempty"`
+	DateFormat      string `json:"dateFormat,omitempty"`
+	ExpireAfterDays *int64 `json:"expireAfterDays,omitempty"`
+	Query           string `json:"query,omitempty"`
+}
+
+type onlineArchivePartition struct {
+	FieldName string `json:"fieldName,omitempty"`
+	FieldType string `json:"fieldType,omitempty"`
+	Order     *int64 `json:"order,omitempty"`
+}
+
+type onlineArchiveSchedule struct {
+	Type        string `json:"type,omitempty"`
+	StartHour   *int64 `json:"startHour,omitempty"`
+	StartMinute *int64 `json:"startMinute,omitempty"`
+	EndHour     *int64 `json:"endHour,omitempty"`
+	EndMinute   *int64 `json:"endMinute,omitempty"`
+	DayOfWeek   *int64 `json:"dayOfWeek,omitempty"`
+	DayOfMonth  *int64 `json:"dayOfMonth,omitempty"`
+}
+
+type onlineArchivesResponse struct {
+	Links      []*mongodbatlas.Link `json:"links,omitempty"`
+	Results    []onlineArchive      `json:"results,omitempty"`
+	TotalCount int                  `json:"totalCount,omitempty"`
+}
+
+func onlineArchivePath(projectID, clusterName, archiveID string) string {
+	return fmt.Sprintf("%s/%s", fmt.Sprintf(onlineArchivesPath, projectID, url.PathEscape(clusterName)), url.PathEscape(archiveID))
+}
+
+func getOnlineArchive(client *mongodbatlas.Client, projectID, clusterName, archiveID string) (*onlineArchive, *mongodbatlas.Response, error) {
+	return doOnlineArchive(client, http.MethodGet, onlineArchivePath(projectID, clusterName, archiveID), nil)
+}
+
+func createOnlineArchive(client *mongodbatlas.Client, projectID, clusterName string, archive *onlineArchive) (*onlineArchive, *mongodbatlas.Response, error) {
+	return doOnlineArchive(client, http.MethodPost, fmt.Sprintf(onlineArchivesPath, projectID, url.PathEscape(clusterName)), archive)
+}
+
+func updateOnlineArchive(client *mongodbatlas.Client, projectID, clusterName, archiveID string, archive *onlineArchive) (*onlineArchive, *mongodbatlas.Response, error) {
+	return doOnlineArchive(client, http.MethodPatch, onlineArchivePath(projectID, clusterName, archiveID), archive)
+}
+
+func deleteOnlineArchive(client *mongodbatlas.Client, projectID, clusterName, archiveID string) (*mongodbatlas.Response, error) {
+	req, err := client.NewRequest(context.Background(), http.MethodDelete, onlineArchivePath(projectID, clusterName, archiveID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.Do(context.Background(), req, nil)
+}
+
+func listOnlineArchives(client *mongodbatlas.Client, projectID, clusterName string, pageNum, itemsPerPage int) (*onlineArchivesResponse, *mongodbatlas.Response, error) {
+	path := fmt.Sprintf("%s?pageNum=%d&itemsPerPage=%d", fmt.Sprintf(onlineArchivesPath, projectID, url.PathEscape(clusterName)), pageNum, itemsPerPage)
+
+	req, err := client.NewRequest(context.Background(), http.MethodGet, path, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	root := new(onlineArchivesResponse)
+	resp, err := client.Do(context.Background(), req, root)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	if l := root.Links; l != nil {
+		resp.Links = l
+	}
+
+	return root, resp, nil
+}
+
+func doOnlineArchive(client *mongodbatlas.Client, method, path string, body *onlineArchive) (*onlineArchive, *mongodbatlas.Response, error) {
+	var reqBody interface{}
+	if body != nil {
+		reqBody = body
+	}
+
+	req, err := client.NewRequest(context.Background(), method, path, reqBody)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	root := new(onlineArchive)
+	resp, err := client.Do(context.Background(), req, root)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return root, resp, nil
+}
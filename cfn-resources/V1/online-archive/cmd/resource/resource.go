@@ -0,0 +1,659 @@
+package resource
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/422158/mongodbstpatlas-cloudformation-resources/cfn-resources/V1/online-archive/cmd/util"
+	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/spf13/cast"
+	"go.mongodb.org/atlas/mongodbatlas"
+)
+
+func castNO64(i *int64) *int {
+	x := cast.ToInt(&i)
+	return &x
+}
+func cast64(i *int) *int64 {
+	x := cast.ToInt64(&i)
+	return &x
+}
+
+// Create handles the Create event from the Cloudformation service.
+func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	client, err := util.CreateMongoDBClient(*currentModel.ApiKeys.PublicKey, *currentModel.ApiKeys.PrivateKey)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	if _, ok := req.CallbackContext["state"]; ok {
+		return validateProgress(client, req, currentModel)
+	}
+
+	if err := validateArchive(currentModel); err != nil {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          err.Error(),
+			HandlerErrorCode: "InvalidRequest",
+		}, nil
+	}
+
+	projectID := *currentModel.ProjectId
+	clusterName := *currentModel.ClusterName
+
+	archiveRequest := expandOnlineArchive(currentModel)
+	// Atlas only pauses archives that are active, the archive is paused once it gets there
+	archiveRequest.Paused = nil
+
+	archive, _, err := createOnlineArchive(client, projectID, clusterName, archiveRequest)
+	if err != nil {
+		return handler.ProgressEvent{}, fmt.Errorf("error creating online archive for collection (%s.%s): %s", *currentModel.DbName, *currentModel.CollName, err)
+	}
+
+	currentModel.ArchiveId = &archive.ID
+	currentModel.State = &archive.State
+
+	cfnid := buildOnlineArchiveCfnIdentifier(currentModel.ProjectId, currentModel.ClusterName, currentModel.ArchiveId)
+
+	currentModel.OnlineArchiveCfnIdentifier = &cfnid
+
+	// putting required parameters into parameter store (needed for read operation)
+	_, err = putParameterIntoParameterStore(currentModel.OnlineArchiveCfnIdentifier, &ParameterToBePersistedSpec{ApiKeys: currentModel.ApiKeys, ProjectId: currentModel.ProjectId, ClusterName: currentModel.ClusterName, ArchiveId: currentModel.ArchiveId}, req.Session)
+	if err != nil {
+		return handler.ProgressEvent{}, fmt.Errorf("error when putting api keys into parameter store: %s", err)
+	}
+
+	return handler.ProgressEvent{
+		OperationStatus:      handler.InProgress,
+		Message:              fmt.Sprintf("Create Online Archive `%s`", archive.State),
+		ResourceModel:        currentModel,
+		CallbackDelaySeconds: 65,
+		CallbackContext: map[string]interface{}{
+			"state":            archive.State,
+			"pauseAfterCreate": isTrue(currentModel.Paused),
+		},
+	}, nil
+}
+
+// Read handles the Read event from the Cloudformation service.
+func Read(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	params, err := getParameterFromParameterStore(currentModel.OnlineArchiveCfnIdentifier, req.Session)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	client, err := util.CreateMongoDBClient(*params.ApiKeys.PublicKey, *params.ApiKeys.PrivateKey)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	archive, _, err := getOnlineArchive(client, *params.ProjectId, *params.ClusterName, *params.ArchiveId)
+	if err != nil {
+		return handler.ProgressEvent{}, fmt.Errorf("error fetching online archive info (%s): %s", *params.ArchiveId, err)
+	}
+
+	currentModel.ProjectId = params.ProjectId
+	currentModel.ClusterName = params.ClusterName
+	flattenOnlineArchive(currentModel, archive)
+
+	return handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		Message:         "Read Complete",
+		ResourceModel:   currentModel,
+	}, nil
+}
+
+// Update handles the Update event from the Cloudformation service.
+func Update(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	client, err := util.CreateMongoDBClient(*currentModel.ApiKeys.PublicKey, *currentModel.ApiKeys.PrivateKey)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	if _, ok := req.CallbackContext["state"]; ok {
+		return validateProgress(client, req, currentModel)
+	}
+
+	if err := validateArchive(currentModel); err != nil {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          err.Error(),
+			HandlerErrorCode: "InvalidRequest",
+		}, nil
+	}
+
+	projectID := *currentModel.ProjectId
+	clusterName := *currentModel.ClusterName
+	currentModel.ArchiveId = prevModel.ArchiveId
+
+	archive, _, err := updateOnlineArchive(client, projectID, clusterName, *currentModel.ArchiveId, expandOnlineArchiveUpdate(prevModel, currentModel))
+	if err != nil {
+		return handler.ProgressEvent{}, fmt.Errorf("error updating online archive (%s): %s", *currentModel.ArchiveId, err)
+	}
+
+	currentModel.State = &archive.State
+
+	cfnid := buildOnlineArchiveCfnIdentifier(currentModel.ProjectId, currentModel.ClusterName, currentModel.ArchiveId)
+
+	currentModel.OnlineArchiveCfnIdentifier = &cfnid
+
+	// putting required parameter into parameter store
+	// the api keys might have been updated therefore we need to do this here
+	_, err = putParameterIntoParameterStore(currentModel.OnlineArchiveCfnIdentifier, &ParameterToBePersistedSpec{ApiKeys: currentModel.ApiKeys, ProjectId: currentModel.ProjectId, ClusterName: currentModel.ClusterName, ArchiveId: currentModel.ArchiveId}, req.Session)
+	if err != nil {
+		return handler.ProgressEvent{}, fmt.Errorf("error when putting api keys into parameter store: %s", err)
+	}
+
+	return handler.ProgressEvent{
+		OperationStatus:      handler.InProgress,
+		Message:              fmt.Sprintf("Update Online Archive `%s`", archive.State),
+		ResourceModel:        currentModel,
+		CallbackDelaySeconds: 65,
+		CallbackContext: map[string]interface{}{
+			"state": archive.State,
+		},
+	}, nil
+}
+
+// Delete handles the Delete event from the Cloudformation service.
+func Delete(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	client, err := util.CreateMongoDBClient(*currentModel.ApiKeys.PublicKey, *currentModel.ApiKeys.PrivateKey)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	projectID := *currentModel.ProjectId
+	clusterName := *currentModel.ClusterName
+	archiveID := *currentModel.ArchiveId
+
+	resp, err := deleteOnlineArchive(client, projectID, clusterName, archiveID)
+	// an archive Atlas no longer knows, for instance because the cluster was deleted first, is already gone
+	if err != nil && (resp == nil || resp.StatusCode != 404) {
+		// even when error occurs when deleting, we still want to delete parameter from parameter store
+		_, errParams := deleteParameterFromParameterStore(currentModel.OnlineArchiveCfnIdentifier, req.Session)
+		if errParams != nil {
+			return handler.ProgressEvent{
+				OperationStatus:  handler.Failed,
+				Message:          "Delete Failed",
+				HandlerErrorCode: "GeneralServiceException",
+			}, fmt.Errorf("Error deleting online archive with id(%s): %s.\nError deleting api keys from parameter store: %s", archiveID, err, errParams)
+		}
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          "Delete Failed",
+			HandlerErrorCode: "GeneralServiceException",
+		}, fmt.Errorf("error deleting online archive with id (%s): %s", archiveID, err)
+	}
+
+	_, err = deleteParameterFromParameterStore(currentModel.OnlineArchiveCfnIdentifier, req.Session)
+	if err != nil {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          "Delete Failed",
+			HandlerErrorCode: "GeneralServiceException",
+		}, fmt.Errorf("error deleting parameters for online archive %s: %s", archiveID, err)
+	}
+
+	return handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		Message:         "Delete Complete",
+		ResourceModel:   currentModel,
+	}, nil
+}
+
+// List handles the List event from the Cloudformation service.
+func List(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	client, err := util.CreateMongoDBClient(*currentModel.ApiKeys.PublicKey, *currentModel.ApiKeys.PrivateKey)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	projectID := *currentModel.ProjectId
+	clusterName := *currentModel.ClusterName
+
+	const itemsPerPage = 100
+
+	models := make([]interface{}, 0)
+	for pageNum := 1; ; pageNum++ {
+		page, resp, err := listOnlineArchives(client, projectID, clusterName, pageNum, itemsPerPage)
+		if err != nil {
+			return handler.ProgressEvent{}, fmt.Errorf("error listing online archives for cluster (%s): %s", clusterName, err)
+		}
+
+		for i := range page.Results {
+			var model Model
+			model.ProjectId = currentModel.ProjectId
+			model.ClusterName = currentModel.ClusterName
+			flattenOnlineArchive(&model, &page.Results[i])
+			cfnid := buildOnlineArchiveCfnIdentifier(model.ProjectId, model.ClusterName, model.ArchiveId)
+			model.OnlineArchiveCfnIdentifier = &cfnid
+			models = append(models, model)
+		}
+
+		if len(page.Results) < itemsPerPage || resp.IsLastPage() {
+			break
+		}
+	}
+
+	return handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		Message:         "List Complete",
+		ResourceModels:  models,
+	}, nil
+}
+
+// validateArchive checks that the criteria carries the settings of its type
+func validateArchive(model *Model) error {
+	criteria := model.Criteria
+	if criteria == nil {
+		return fmt.Errorf("Criteria must be set")
+	}
+
+	switch cast.ToString(criteria.Type) {
+	case "DATE":
+		if criteria.DateField == nil || criteria.ExpireAfterDays == nil {
+			return fmt.Errorf("Criteria.DateField and Criteria.ExpireAfterDays must be set for the DATE criteria")
+		}
+		if criteria.Query != nil {
+			return fmt.Errorf("Criteria.Query should not be set for the DATE criteria")
+		}
+	case "CUSTOM":
+		if criteria.Query == nil {
+			return fmt.Errorf("Criteria.Query must be set for the CUSTOM criteria")
+		}
+		var query map[string]interface{}
+		if err := json.Unmarshal([]byte(*criteria.Query), &query); err != nil {
+			return fmt.Errorf("Criteria.Query is not a JSON object: %s", err)
+		}
+		if criteria.DateField != nil || criteria.DateFormat != nil || criteria.ExpireAfterDays != nil {
+			return fmt.Errorf("Criteria.DateField, Criteria.DateFormat and Criteria.ExpireAfterDays should not be set for the CUSTOM criteria")
+		}
+	}
+
+	if schedule := model.Schedule; schedule != nil {
+		scheduleType := cast.ToString(schedule.Type)
+		if scheduleType == "WEEKLY" && schedule.DayOfWeek == nil {
+			return fmt.Errorf("Schedule.DayOfWeek must be set for the WEEKLY schedule")
+		}
+		if scheduleType == "MONTHLY" && schedule.DayOfMonth == nil {
+			return fmt.Errorf("Schedule.DayOfMonth must be set for the MONTHLY schedule")
+		}
+	}
+	return nil
+}
+
+func expandOnlineArchive(model *Model) *onlineArchive {
+	archive := &onlineArchive{
+		DBName:   cast.ToString(model.DbName),
+		CollName: cast.ToString(model.CollName),
+		Paused:   model.Paused,
+	}
+
+	if criteria := model.Criteria; criteria != nil {
+		archive.Criteria = &onlineArchiveCriteria{
+			Type:       cast.ToString(criteria.Type),
+			DateField:  cast.ToString(criteria.DateField),
+			DateFormat: cast.ToString(criteria.DateFormat),
+			Query:      cast.ToString(criteria.Query),
+		}
+		if criteria.ExpireAfterDays != nil {
+			archive.Criteria.ExpireAfterDays = cast64(criteria.ExpireAfterDays)
+		}
+	}
+
+	for _, field := range model.PartitionFields {
+		archive.PartitionFields = append(archive.PartitionFields, onlineArchivePartition{
+			FieldName: cast.ToString(field.FieldName),
+			Order:     cast64(field.Order),
+		})
+	}
+
+	if schedule := model.Schedule; schedule != nil {
+		archive.Schedule = &onlineArchiveSchedule{
+			Type: cast.ToString(schedule.Type),
+		}
+		if schedule.StartHour != nil {
+			archive.Schedule.StartHour = cast64(schedule.StartHour)
+		}
+		if schedule.StartMinute != nil {
+			archive.Schedule.StartMinute = cast64(schedule.StartMinute)
+		}
+		if schedule.EndHour != nil {
+			archive.Schedule.EndHour = cast64(schedule.EndHour)
+		}
+		if schedule.EndMinute != nil {
+			archive.Schedule.EndMinute = cast64(schedule.EndMinute)
+		}
+		if schedule.DayOfWeek != nil {
+			archive.Schedule.DayOfWeek = cast64(schedule.DayOfWeek)
+		}
+		if schedule.DayOfMonth != nil {
+			archive.Schedule.DayOfMonth = cast64(schedule.DayOfMonth)
+		}
+	}
+
+	return archive
+}
+
+// expandOnlineArchiveUpdate leaves out the collection and the partition fields, they are create only and Atlas rejects
+// them on updates. The archive is only paused or resumed when the template changes Paused.
+func expandOnlineArchiveUpdate(prevModel *Model, currentModel *Model) *onlineArchive {
+	archiveRequest := expandOnlineArchive(currentModel)
+	archiveRequest.DBName = ""
+	archiveRequest.CollName = ""
+	archiveRequest.PartitionFields = nil
+	archiveRequest.Paused = nil
+	if isTrue(prevModel.Paused) != isTrue(currentModel.Paused) {
+		paused := isTrue(currentModel.Paused)
+		archiveRequest.Paused = &paused
+	}
+	return archiveRequest
+}
+
+// flattenOnlineArchive maps the archive Atlas reports onto the model. Atlas adds the date field of the DATE
+// criteria to the partition fields and reports a DEFAULT schedule when none was set, both are left out unless the
+// model lists them, and a query Atlas formats differently keeps the spelling of the model.
+func flattenOnlineArchive(currentModel *Model, archive *onlineArchive) {
+	currentModel.ArchiveId = &archive.ID
+	currentModel.DbName = &archive.DBName
+	currentModel.CollName = &archive.CollName
+	currentModel.State = &archive.State
+	currentModel.Paused = archive.Paused
+
+	priorCriteria := currentModel.Criteria
+	priorPartitionFields := currentModel.PartitionFields
+
+	currentModel.Criteria = nil
+	dateField := ""
+	if criteria := archive.Criteria; criteria != nil {
+		currentModel.Criteria = &CriteriaView{
+			Type:       stringOrNil(criteria.Type),
+			DateField:  stringOrNil(criteria.DateField),
+			DateFormat: stringOrNil(criteria.DateFormat),
+		}
+		if criteria.ExpireAfterDays != nil {
+			currentModel.Criteria.ExpireAfterDays = castNO64(criteria.ExpireAfterDays)
+		}
+		if criteria.Query != "" {
+			var priorQuery *string
+			if priorCriteria != nil {
+				priorQuery = priorCriteria.Query
+			}
+			currentModel.Criteria.Query = keepEquivalentJSON(priorQuery, criteria.Query)
+		}
+		// Atlas reports the default format even when it was not set
+		if criteria.DateFormat == "ISODATE" && (priorCriteria == nil || priorCriteria.DateFormat == nil) {
+			currentModel.Criteria.DateFormat = nil
+		}
+		if criteria.Type == "DATE" {
+			dateField = criteria.DateField
+		}
+	}
+
+	listsDateField := false
+	for _, field := range priorPartitionFields {
+		if cast.ToString(field.FieldName) == dateField {
+			listsDateField = true
+		}
+	}
+
+	currentModel.PartitionFields = nil
+	for i := range archive.PartitionFields {
+		field := archive.PartitionFields[i]
+		if field.FieldName == dateField && !listsDateField {
+			continue
+		}
+		partition := PartitionFieldView{
+			FieldName: stringOrNil(field.FieldName),
+		}
+		if field.Order != nil {
+			partition.Order = castNO64(field.Order)
+		}
+		currentModel.PartitionFields = append(currentModel.PartitionFields, partition)
+	}
+
+	currentModel.Schedule = nil
+	if schedule := archive.Schedule; schedule != nil && schedule.Type != "" && schedule.Type != "DEFAULT" {
+		currentModel.Schedule = &ScheduleView{
+			Type: stringOrNil(schedule.Type),
+		}
+		if schedule.StartHour != nil {
+			currentModel.Schedule.StartHour = castNO64(schedule.StartHour)
+		}
+		if schedule.StartMinute != nil {
+			currentModel.Schedule.StartMinute = castNO64(schedule.StartMinute)
+		}
+		if schedule.EndHour != nil {
+			currentModel.Schedule.EndHour = castNO64(schedule.EndHour)
+		}
+		if schedule.EndMinute != nil {
+			currentModel.Schedule.EndMinute = castNO64(schedule.EndMinute)
+		}
+		if schedule.DayOfWeek != nil {
+			currentModel.Schedule.DayOfWeek = castNO64(schedule.DayOfWeek)
+		}
+		if schedule.DayOfMonth != nil {
+			currentModel.Schedule.DayOfMonth = castNO64(schedule.DayOfMonth)
+		}
+	}
+}
+
+// keepEquivalentJSON returns prior when it holds the same JSON value as actual, otherwise actual in compact form
+func keepEquivalentJSON(prior *string, actual string) *string {
+	var actualValue interface{}
+	if err := json.Unmarshal([]byte(actual), &actualValue); err != nil {
+		return &actual
+	}
+
+	if prior != nil {
+		var priorValue interface{}
+		if err := json.Unmarshal([]byte(*prior), &priorValue); err == nil && reflect.DeepEqual(priorValue, actualValue) {
+			return prior
+		}
+	}
+
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, []byte(actual)); err != nil {
+		return &actual
+	}
+	s := compact.String()
+	return &s
+}
+
+// defaultStabilizationTimeoutMinutes is how long the callbacks poll an archive when StabilizationTimeoutMinutes is not set
+const defaultStabilizationTimeoutMinutes = 180
+
+// validateProgress polls the archive until it is ACTIVE, or PAUSED when the model pauses it. An archive created
+// paused is paused once Atlas reports it ACTIVE, see progressOfArchive for the rest.
+func validateProgress(client *mongodbatlas.Client, req handler.Request, currentModel *Model) (handler.ProgressEvent, error) {
+	projectID := *currentModel.ProjectId
+	clusterName := *currentModel.ClusterName
+	archiveID := *currentModel.ArchiveId
+
+	archive, _, err := getOnlineArchive(client, projectID, clusterName, archiveID)
+	if err != nil {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          fmt.Sprintf("error waiting for online archive (%s) to reach state %s: %s", archiveID, targetStateOf(currentModel), err),
+			HandlerErrorCode: "GeneralServiceException",
+		}, nil
+	}
+
+	currentModel.State = &archive.State
+	pauseAfterCreate := cast.ToBool(req.CallbackContext["pauseAfterCreate"])
+
+	if archive.State == "ACTIVE" && pauseAfterCreate {
+		paused := true
+		archive, _, err = updateOnlineArchive(client, projectID, clusterName, archiveID, &onlineArchive{Paused: &paused})
+		if err != nil {
+			return handler.ProgressEvent{
+				OperationStatus:  handler.Failed,
+				Message:          fmt.Sprintf("error pausing online archive (%s): %s", archiveID, err),
+				HandlerErrorCode: "GeneralServiceException",
+			}, nil
+		}
+		pauseAfterCreate = false
+	}
+
+	return progressOfArchive(req, currentModel, archive.State, pauseAfterCreate), nil
+}
+
+// progressOfArchive turns the polled state into the next progress event, a state an archive does not pass through
+// on its way to the target fails the operation, as does waiting longer than the stabilization timeout
+func progressOfArchive(req handler.Request, currentModel *Model, state string, pauseAfterCreate bool) handler.ProgressEvent {
+	archiveID := *currentModel.ArchiveId
+	targetState := targetStateOf(currentModel)
+
+	startTime := time.Now().UTC()
+	if started, ok := req.CallbackContext["startTime"]; ok {
+		if t, err := time.Parse(time.RFC3339, cast.ToString(started)); err == nil {
+			startTime = t
+		}
+	}
+	pollCount := cast.ToInt(req.CallbackContext["pollCount"]) + 1
+
+	if state == targetState {
+		p := handler.NewProgressEvent()
+		p.ResourceModel = currentModel
+		p.OperationStatus = handler.Success
+		p.Message = "Complete"
+		return p
+	}
+
+	switch state {
+	case "PENDING", "ACTIVE", "PAUSING", "PAUSED":
+	default:
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          fmt.Sprintf("error waiting for online archive (%s) to reach state %s: unexpected state %s", archiveID, targetState, state),
+			HandlerErrorCode: "GeneralServiceException",
+		}
+	}
+
+	timeout := stabilizationTimeout(currentModel)
+	if time.Since(startTime) > timeout {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          fmt.Sprintf("online archive (%s) did not reach state %s within %s, still %s after %d polls", archiveID, targetState, timeout, state, pollCount),
+			HandlerErrorCode: "NotStabilized",
+		}
+	}
+
+	p := handler.NewProgressEvent()
+	p.ResourceModel = currentModel
+	p.OperationStatus = handler.InProgress
+	p.CallbackDelaySeconds = 60
+	p.Message = "Pending"
+	p.CallbackContext = map[string]interface{}{
+		"state":            state,
+		"pauseAfterCreate": pauseAfterCreate,
+		"startTime":        startTime.Format(time.RFC3339),
+		"pollCount":        pollCount,
+	}
+	return p
+}
+
+func targetStateOf(currentModel *Model) string {
+	if isTrue(currentModel.Paused) {
+		return "PAUSED"
+	}
+	return "ACTIVE"
+}
+
+func stabilizationTimeout(currentModel *Model) time.Duration {
+	if currentModel.StabilizationTimeoutMinutes != nil && *currentModel.StabilizationTimeoutMinutes > 0 {
+		return time.Duration(*currentModel.StabilizationTimeoutMinutes) * time.Minute
+	}
+	return time.Duration(defaultStabilizationTimeoutMinutes) * time.Minute
+}
+
+func isTrue(b *bool) bool {
+	return b != nil && *b
+}
+
+func stringOrNil(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+type ParameterToBePersistedSpec struct {
+	ApiKeys     *ApiKeyDefinition
+	ProjectId   *string
+	ClusterName *string
+	ArchiveId   *string
+}
+
+func putParameterIntoParameterStore(resourcePrimaryIdentifier *string, params *ParameterToBePersistedSpec, session *session.Session) (*ssm.PutParameterOutput, error) {
+	ssmClient, err := util.CreateSSMClient(session)
+	if err != nil {
+		return nil, err
+	}
+	// transform api keys to json string
+	parameterName := buildApiKeyParameterName(*resourcePrimaryIdentifier)
+	byteParams, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	stringifiedParams := string(byteParams)
+	parameterType := "SecureString"
+	overwrite := true
+	putParamOutput, err := ssmClient.PutParameter(&ssm.PutParameterInput{Name: &parameterName, Value: &stringifiedParams, Type: &parameterType, Overwrite: &overwrite})
+	if err != nil {
+		return nil, err
+	}
+
+	return putParamOutput, nil
+}
+
+func deleteParameterFromParameterStore(resourcePrimaryIdentifier *string, session *session.Session) (*ssm.DeleteParameterOutput, error) {
+	ssmClient, err := util.CreateSSMClient(session)
+	if err != nil {
+		return nil, err
+	}
+	parameterName := buildApiKeyParameterName(*resourcePrimaryIdentifier)
+
+	deleteParamOutput, err := ssmClient.DeleteParameter(&ssm.DeleteParameterInput{Name: &parameterName})
+	if err != nil {
+		return nil, err
+	}
+
+	return deleteParamOutput, nil
+}
+
+func getParameterFromParameterStore(resourcePrimaryIdentifier *string, session *session.Session) (*ParameterToBePersistedSpec, error) {
+	ssmClient, err := util.CreateSSMClient(session)
+	if err != nil {
+		return nil, err
+	}
+	parameterName := buildApiKeyParameterName(*resourcePrimaryIdentifier)
+	decrypt := true
+	getParamOutput, err := ssmClient.GetParameter(&ssm.GetParameterInput{Name: &parameterName, WithDecryption: &decrypt})
+	if err != nil {
+		return nil, err
+	}
+
+	var params ParameterToBePersistedSpec
+	err = json.Unmarshal([]byte(*getParamOutput.Parameter.Value), &params)
+	if err != nil {
+		return nil, err
+	}
+	return &params, nil
+}
+
+func buildOnlineArchiveCfnIdentifier(projectId *string, clusterName *string, archiveId *string) string {
+	return fmt.Sprintf("%s-%s-%s", *projectId, *clusterName, *archiveId)
+}
+
+func buildApiKeyParameterName(resourcePrimaryIdentifier string) string {
+	// this is strictly coupled with permissions for handlers, changing this means changing permissions in handler
+	// moreover changing this might cause polution in parameter store -  be sure you know what you are doing
+	parameterStorePrefix := "mongodbstpatlasv1onlinearchive"
+	return fmt.Sprintf("%s-%s", parameterStorePrefix, resourcePrimaryIdentifier)
+}
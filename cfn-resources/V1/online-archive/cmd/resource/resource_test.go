@@ -0,0 +1,204 @@
+package resource
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
+)
+
+func dateCriteria(dateField string, expireAfterDays int) *CriteriaView {
+	criteriaType := "DATE"
+	return &CriteriaView{Type: &criteriaType, DateField: &dateField, ExpireAfterDays: &expireAfterDays}
+}
+
+func customCriteria(query string) *CriteriaView {
+	criteriaType := "CUSTOM"
+	return &CriteriaView{Type: &criteriaType, Query: &query}
+}
+
+func schedule(scheduleType string) *ScheduleView {
+	return &ScheduleView{Type: &scheduleType}
+}
+
+func TestValidateArchive(t *testing.T) {
+	sunday := 7
+	dateField := "created"
+	emptyQuery := "{}"
+
+	dateWithoutField := dateCriteria("created", 30)
+	dateWithoutField.DateField = nil
+	dateWithQuery := dateCriteria("created", 30)
+	dateWithQuery.Query = &emptyQuery
+	customWithoutQuery := customCriteria("")
+	customWithoutQuery.Query = nil
+	customWithDateField := customCriteria("{}")
+	customWithDateField.DateField = &dateField
+	weekly := schedule("WEEKLY")
+	weekly.DayOfWeek = &sunday
+
+	testCases := []struct {
+		name    string
+		model   *Model
+		wantErr bool
+	}{
+		{"date criteria", &Model{Criteria: dateCriteria("created", 30)}, false},
+		{"custom criteria", &Model{Criteria: customCriteria(`{"status": "closed"}`)}, false},
+		{"weekly schedule", &Model{Criteria: dateCriteria("created", 30), Schedule: weekly}, false},
+		{"date criteria without field", &Model{Criteria: dateWithoutField}, true},
+		{"date criteria with query", &Model{Criteria: dateWithQuery}, true},
+		{"custom criteria without query", &Model{Criteria: customWithoutQuery}, true},
+		{"custom criteria with invalid query", &Model{Criteria: customCriteria(`{"status"`)}, true},
+		{"custom criteria with date field", &Model{Criteria: customWithDateField}, true},
+		{"monthly schedule without day", &Model{Criteria: dateCriteria("created", 30), Schedule: schedule("MONTHLY")}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateArchive(tc.model)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("want error %t, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestFlattenOnlineArchiveMatchesTemplate(t *testing.T) {
+	fieldName := "customerId"
+	order := 0
+	model := &Model{
+		Criteria:        customCriteria(`{ "status": "closed" }`),
+		PartitionFields: []PartitionFieldView{{FieldName: &fieldName, Order: &order}},
+	}
+
+	flattenOnlineArchive(model, &onlineArchive{
+		ID:       "5ebad3c1fe9c0ab8d37d61e1",
+		DBName:   "shop",
+		CollName: "orders",
+		State:    "ACTIVE",
+		Criteria: &onlineArchiveCriteria{Type: "CUSTOM", Query: `{"status":"closed"}`},
+		PartitionFields: []onlineArchivePartition{
+			{FieldName: "customerId", FieldType: "string", Order: new(int64)},
+		},
+		Schedule: &onlineArchiveSchedule{Type: "DEFAULT"},
+	})
+
+	if got := *model.Criteria.Query; got != `{ "status": "closed" }` {
+		t.Errorf("want the query of the template kept, got %s", got)
+	}
+	if len(model.PartitionFields) != 1 || *model.PartitionFields[0].FieldName != "customerId" {
+		t.Errorf("want the partition fields of the template, got %v", model.PartitionFields)
+	}
+	if model.Schedule != nil {
+		t.Errorf("want the DEFAULT schedule left out, got %v", model.Schedule)
+	}
+}
+
+func TestFlattenOnlineArchiveOmitsDateField(t *testing.T) {
+	model := &Model{Criteria: dateCriteria("created", 30)}
+
+	one := int64(1)
+	flattenOnlineArchive(model, &onlineArchive{
+		Criteria: &onlineArchiveCriteria{Type: "DATE", DateField: "created", DateFormat: "ISODATE", ExpireAfterDays: new(int64)},
+		PartitionFields: []onlineArchivePartition{
+			{FieldName: "created", FieldType: "date", Order: new(int64)},
+			{FieldName: "region", FieldType: "string", Order: &one},
+		},
+	})
+
+	if model.Criteria.DateFormat != nil {
+		t.Errorf("want the default date format left out, got %s", *model.Criteria.DateFormat)
+	}
+	if len(model.PartitionFields) != 1 || *model.PartitionFields[0].FieldName != "region" {
+		t.Errorf("want only the region partition field, got %v", model.PartitionFields)
+	}
+}
+
+func TestProgressOfArchive(t *testing.T) {
+	archiveID := "5ebad3c1fe9c0ab8d37d61e1"
+	paused := true
+	timeoutMinutes := 15
+	startedLongAgo := time.Now().UTC().Add(-4 * time.Hour).Format(time.RFC3339)
+	startedRecently := time.Now().UTC().Add(-20 * time.Minute).Format(time.RFC3339)
+
+	testCases := []struct {
+		name       string
+		model      *Model
+		context    map[string]interface{}
+		state      string
+		wantStatus handler.Status
+		wantCode   string
+	}{
+		{"ready", &Model{ArchiveId: &archiveID}, map[string]interface{}{}, "ACTIVE", handler.Success, ""},
+		{"first poll", &Model{ArchiveId: &archiveID}, map[string]interface{}{}, "PENDING", handler.InProgress, ""},
+		{"unexpected state", &Model{ArchiveId: &archiveID}, map[string]interface{}{}, "DELETED", handler.Failed, "GeneralServiceException"},
+		{"paused", &Model{ArchiveId: &archiveID, Paused: &paused}, map[string]interface{}{}, "PAUSED", handler.Success, ""},
+		{"pausing", &Model{ArchiveId: &archiveID, Paused: &paused}, map[string]interface{}{}, "ACTIVE", handler.InProgress, ""},
+		{"default timeout exceeded", &Model{ArchiveId: &archiveID}, map[string]interface{}{"startTime": startedLongAgo}, "PENDING", handler.Failed, "NotStabilized"},
+		{"within the default timeout", &Model{ArchiveId: &archiveID}, map[string]interface{}{"startTime": startedRecently}, "PENDING", handler.InProgress, ""},
+		{"custom timeout exceeded", &Model{ArchiveId: &archiveID, StabilizationTimeoutMinutes: &timeoutMinutes}, map[string]interface{}{"startTime": startedRecently}, "PENDING", handler.Failed, "NotStabilized"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := progressOfArchive(handler.Request{CallbackContext: tc.context}, tc.model, tc.state, false)
+
+			if p.OperationStatus != tc.wantStatus || p.HandlerErrorCode != tc.wantCode {
+				t.Errorf("want %s %s, got %s %s: %s", tc.wantStatus, tc.wantCode, p.OperationStatus, p.HandlerErrorCode, p.Message)
+			}
+		})
+	}
+}
+
+func TestProgressOfArchiveKeepsStartTime(t *testing.T) {
+	archiveID := "5ebad3c1fe9c0ab8d37d61e1"
+	started := time.Now().UTC().Add(-10 * time.Minute).Format(time.RFC3339)
+	req := handler.Request{CallbackContext: map[string]interface{}{"startTime": started, "pollCount": 3}}
+
+	p := progressOfArchive(req, &Model{ArchiveId: &archiveID}, "PENDING", false)
+
+	if p.CallbackContext["startTime"] != started || p.CallbackContext["pollCount"] != 4 {
+		t.Errorf("want the start time kept and the poll counted, got %v", p.CallbackContext)
+	}
+}
+
+func TestExpandOnlineArchiveUpdate(t *testing.T) {
+	enabled, disabled := true, false
+	dbName, collName, fieldName := "shop", "orders", "customerId"
+
+	testCases := []struct {
+		name     string
+		previous *bool
+		current  *bool
+		want     *bool
+	}{
+		{"unchanged", &enabled, &enabled, nil},
+		{"unset stays active", nil, &disabled, nil},
+		{"paused", nil, &enabled, &enabled},
+		{"resumed", &enabled, &disabled, &disabled},
+		{"removed", &enabled, nil, &disabled},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			current := &Model{
+				DbName:          &dbName,
+				CollName:        &collName,
+				Criteria:        dateCriteria("created", 30),
+				PartitionFields: []PartitionFieldView{{FieldName: &fieldName}},
+				Paused:          tc.current,
+			}
+			got := expandOnlineArchiveUpdate(&Model{Paused: tc.previous}, current)
+
+			if got.DBName != "" || got.CollName != "" || got.PartitionFields != nil {
+				t.Errorf("want the create only fields left out, got %+v", got)
+			}
+			switch {
+			case tc.want == nil && got.Paused != nil:
+				t.Errorf("want Paused not sent, got %t", *got.Paused)
+			case tc.want != nil && (got.Paused == nil || *got.Paused != *tc.want):
+				t.Errorf("want Paused %t sent, got %v", *tc.want, got.Paused)
+			}
+		})
+	}
+}
@@ -0,0 +1,489 @@
+package resource
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/422158/mongodbstpatlas-cloudformation-resources/cfn-resources/V1/search-index/cmd/util"
+	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/spf13/cast"
+	"go.mongodb.org/atlas/mongodbatlas"
+)
+
+// Create handles the Create event from the Cloudformation service.
+func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	client, err := util.CreateMongoDBClient(*currentModel.ApiKeys.PublicKey, *currentModel.ApiKeys.PrivateKey)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	if _, ok := req.CallbackContext["status"]; ok {
+		return validateProgress(client, req, currentModel)
+	}
+
+	indexRequest, err := expandSearchIndex(currentModel)
+	if err != nil {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          err.Error(),
+			HandlerErrorCode: "InvalidRequest",
+		}, nil
+	}
+
+	projectID := *currentModel.ProjectId
+	clusterName := *currentModel.ClusterName
+
+	index, _, err := createSearchIndex(client, projectID, clusterName, indexRequest)
+	if err != nil {
+		return handler.ProgressEvent{}, fmt.Errorf("error creating search index (%s): %s", *currentModel.Name, err)
+	}
+
+	currentModel.IndexId = &index.IndexID
+	currentModel.Status = &index.Status
+
+	cfnid := buildSearchIndexCfnIdentifier(currentModel.ProjectId, currentModel.ClusterName, currentModel.IndexId)
+
+	currentModel.SearchIndexCfnIdentifier = &cfnid
+
+	// putting required parameters into parameter store (needed for read operation)
+	_, err = putParameterIntoParameterStore(currentModel.SearchIndexCfnIdentifier, &ParameterToBePersistedSpec{ApiKeys: currentModel.ApiKeys, ProjectId: currentModel.ProjectId, ClusterName: currentModel.ClusterName, IndexId: currentModel.IndexId}, req.Session)
+	if err != nil {
+		return handler.ProgressEvent{}, fmt.Errorf("error when putting api keys into parameter store: %s", err)
+	}
+
+	return handler.ProgressEvent{
+		OperationStatus:      handler.InProgress,
+		Message:              fmt.Sprintf("Create Search Index `%s`", index.Status),
+		ResourceModel:        currentModel,
+		CallbackDelaySeconds: 10,
+		CallbackContext: map[string]interface{}{
+			"status": index.Status,
+		},
+	}, nil
+}
+
+// Read handles the Read event from the Cloudformation service.
+func Read(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	params, err := getParameterFromParameterStore(currentModel.SearchIndexCfnIdentifier, req.Session)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	client, err := util.CreateMongoDBClient(*params.ApiKeys.PublicKey, *params.ApiKeys.PrivateKey)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	index, _, err := getSearchIndex(client, *params.ProjectId, *params.ClusterName, *params.IndexId)
+	if err != nil {
+		return handler.ProgressEvent{}, fmt.Errorf("error fetching search index info (%s): %s", *params.IndexId, err)
+	}
+
+	currentModel.ProjectId = params.ProjectId
+	currentModel.ClusterName = params.ClusterName
+	flattenSearchIndex(currentModel, index)
+
+	return handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		Message:         "Read Complete",
+		ResourceModel:   currentModel,
+	}, nil
+}
+
+// Update handles the Update event from the Cloudformation service.
+func Update(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	client, err := util.CreateMongoDBClient(*currentModel.ApiKeys.PublicKey, *currentModel.ApiKeys.PrivateKey)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	if _, ok := req.CallbackContext["status"]; ok {
+		return validateProgress(client, req, currentModel)
+	}
+
+	indexRequest, err := expandSearchIndex(currentModel)
+	if err != nil {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          err.Error(),
+			HandlerErrorCode: "InvalidRequest",
+		}, nil
+	}
+
+	projectID := *currentModel.ProjectId
+	clusterName := *currentModel.ClusterName
+	currentModel.IndexId = prevModel.IndexId
+
+	// Atlas replaces the whole definition, the request always carries every setting
+	index, _, err := updateSearchIndex(client, projectID, clusterName, *currentModel.IndexId, indexRequest)
+	if err != nil {
+		return handler.ProgressEvent{}, fmt.Errorf("error updating search index (%s): %s", *currentModel.Name, err)
+	}
+
+	currentModel.Status = &index.Status
+
+	cfnid := buildSearchIndexCfnIdentifier(currentModel.ProjectId, currentModel.ClusterName, currentModel.IndexId)
+
+	currentModel.SearchIndexCfnIdentifier = &cfnid
+
+	// putting required parameter into parameter store
+	// the api keys might have been updated therefore we need to do this here
+	_, err = putParameterIntoParameterStore(currentModel.SearchIndexCfnIdentifier, &ParameterToBePersistedSpec{ApiKeys: currentModel.ApiKeys, ProjectId: currentModel.ProjectId, ClusterName: currentModel.ClusterName, IndexId: currentModel.IndexId}, req.Session)
+	if err != nil {
+		return handler.ProgressEvent{}, fmt.Errorf("error when putting api keys into parameter store: %s", err)
+	}
+
+	return handler.ProgressEvent{
+		OperationStatus:      handler.InProgress,
+		Message:              fmt.Sprintf("Update Search Index `%s`", index.Status),
+		ResourceModel:        currentModel,
+		CallbackDelaySeconds: 10,
+		CallbackContext: map[string]interface{}{
+			"status": index.Status,
+		},
+	}, nil
+}
+
+// Delete handles the Delete event from the Cloudformation service.
+func Delete(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	client, err := util.CreateMongoDBClient(*currentModel.ApiKeys.PublicKey, *currentModel.ApiKeys.PrivateKey)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	projectID := *currentModel.ProjectId
+	clusterName := *currentModel.ClusterName
+	indexID := *currentModel.IndexId
+
+	resp, err := deleteSearchIndex(client, projectID, clusterName, indexID)
+	// an index Atlas no longer knows, for instance because the cluster was deleted first, is already gone
+	if err != nil && (resp == nil || resp.StatusCode != 404) {
+		// even when error occurs when deleting, we still want to delete parameter from parameter store
+		_, errParams := deleteParameterFromParameterStore(currentModel.SearchIndexCfnIdentifier, req.Session)
+		if errParams != nil {
+			return handler.ProgressEvent{
+				OperationStatus:  handler.Failed,
+				Message:          "Delete Failed",
+				HandlerErrorCode: "GeneralServiceException",
+			}, fmt.Errorf("Error deleting search index with id(%s): %s.\nError deleting api keys from parameter store: %s", indexID, err, errParams)
+		}
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          "Delete Failed",
+			HandlerErrorCode: "GeneralServiceException",
+		}, fmt.Errorf("error deleting search index with id (%s): %s", indexID, err)
+	}
+
+	_, err = deleteParameterFromParameterStore(currentModel.SearchIndexCfnIdentifier, req.Session)
+	if err != nil {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          "Delete Failed",
+			HandlerErrorCode: "GeneralServiceException",
+		}, fmt.Errorf("error deleting parameters for search index %s: %s", indexID, err)
+	}
+
+	return handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		Message:         "Delete Complete",
+		ResourceModel:   currentModel,
+	}, nil
+}
+
+// List handles the List event from the Cloudformation service.
+// Atlas lists search indexes per collection, the model names the cluster, the database and the collection.
+func List(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	client, err := util.CreateMongoDBClient(*currentModel.ApiKeys.PublicKey, *currentModel.ApiKeys.PrivateKey)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	projectID := *currentModel.ProjectId
+	clusterName := *currentModel.ClusterName
+
+	indexes, _, err := listSearchIndexes(client, projectID, clusterName, *currentModel.Database, *currentModel.CollectionName)
+	if err != nil {
+		return handler.ProgressEvent{}, fmt.Errorf("error listing search indexes for collection (%s.%s): %s", *currentModel.Database, *currentModel.CollectionName, err)
+	}
+
+	models := make([]interface{}, 0)
+	for i := range indexes {
+		var model Model
+		model.ProjectId = currentModel.ProjectId
+		model.ClusterName = currentModel.ClusterName
+		flattenSearchIndex(&model, &indexes[i])
+		cfnid := buildSearchIndexCfnIdentifier(model.ProjectId, model.ClusterName, model.IndexId)
+		model.SearchIndexCfnIdentifier = &cfnid
+		models = append(models, model)
+	}
+
+	return handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		Message:         "List Complete",
+		ResourceModels:  models,
+	}, nil
+}
+
+// expandSearchIndex builds the Atlas definition of the index, the JSON documents of the model must be valid
+func expandSearchIndex(model *Model) (*searchIndex, error) {
+	index := &searchIndex{
+		Name:           cast.ToString(model.Name),
+		Database:       cast.ToString(model.Database),
+		CollectionName: cast.ToString(model.CollectionName),
+		Analyzer:       cast.ToString(model.Analyzer),
+		SearchAnalyzer: cast.ToString(model.SearchAnalyzer),
+		Mappings:       &searchIndexMappings{},
+	}
+
+	if model.Analyzers != nil {
+		var analyzers []interface{}
+		if err := json.Unmarshal([]byte(*model.Analyzers), &analyzers); err != nil {
+			return nil, fmt.Errorf("Analyzers is not a JSON array: %s", err)
+		}
+		index.Analyzers = json.RawMessage(*model.Analyzers)
+	}
+
+	if model.Mappings != nil {
+		index.Mappings.Dynamic = model.Mappings.Dynamic != nil && *model.Mappings.Dynamic
+		if model.Mappings.Fields != nil {
+			var fields map[string]interface{}
+			if err := json.Unmarshal([]byte(*model.Mappings.Fields), &fields); err != nil {
+				return nil, fmt.Errorf("Mappings.Fields is not a JSON object: %s", err)
+			}
+			index.Mappings.Fields = json.RawMessage(*model.Mappings.Fields)
+		}
+	}
+
+	if !index.Mappings.Dynamic && len(index.Mappings.Fields) == 0 {
+		return nil, fmt.Errorf("Mappings.Fields must be set when Mappings.Dynamic is false")
+	}
+
+	return index, nil
+}
+
+// flattenSearchIndex maps the index Atlas reports onto the model. The JSON documents of the model are kept when
+// they describe the same definition, so a different spacing or key order does not show up as drift.
+func flattenSearchIndex(currentModel *Model, index *searchIndex) {
+	currentModel.IndexId = &index.IndexID
+	currentModel.Name = &index.Name
+	currentModel.Database = &index.Database
+	currentModel.CollectionName = &index.CollectionName
+	currentModel.Status = &index.Status
+
+	currentModel.Analyzer = nil
+	if index.Analyzer != "" {
+		currentModel.Analyzer = &index.Analyzer
+	}
+	currentModel.SearchAnalyzer = nil
+	if index.SearchAnalyzer != "" {
+		currentModel.SearchAnalyzer = &index.SearchAnalyzer
+	}
+
+	currentModel.Analyzers = keepEquivalentJSON(currentModel.Analyzers, index.Analyzers)
+
+	if index.Mappings == nil {
+		currentModel.Mappings = nil
+		return
+	}
+
+	var prior IndexMappings
+	if currentModel.Mappings != nil {
+		prior = *currentModel.Mappings
+	}
+
+	mappings := &IndexMappings{
+		Fields: keepEquivalentJSON(prior.Fields, index.Mappings.Fields),
+	}
+	// Atlas always reports the flag, only report false back when the template spells it out
+	if index.Mappings.Dynamic || prior.Dynamic != nil {
+		dynamic := index.Mappings.Dynamic
+		mappings.Dynamic = &dynamic
+	}
+	currentModel.Mappings = mappings
+}
+
+// keepEquivalentJSON returns prior when it holds the same JSON value as actual, otherwise actual in compact form
+func keepEquivalentJSON(prior *string, actual json.RawMessage) *string {
+	if len(actual) == 0 || string(actual) == "null" {
+		return nil
+	}
+
+	var actualValue interface{}
+	if err := json.Unmarshal(actual, &actualValue); err != nil {
+		s := string(actual)
+		return &s
+	}
+
+	if prior != nil {
+		var priorValue interface{}
+		if err := json.Unmarshal([]byte(*prior), &priorValue); err == nil && reflect.DeepEqual(priorValue, actualValue) {
+			return prior
+		}
+	}
+
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, actual); err != nil {
+		s := string(actual)
+		return &s
+	}
+	s := compact.String()
+	return &s
+}
+
+// defaultStabilizationTimeoutMinutes is how long the callbacks poll an index when StabilizationTimeoutMinutes is not set
+const defaultStabilizationTimeoutMinutes = 180
+
+// validateProgress polls the index until it is STEADY, see progressOfIndex
+func validateProgress(client *mongodbatlas.Client, req handler.Request, currentModel *Model) (handler.ProgressEvent, error) {
+	indexID := *currentModel.IndexId
+
+	index, _, err := getSearchIndex(client, *currentModel.ProjectId, *currentModel.ClusterName, indexID)
+	if err != nil {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          fmt.Sprintf("error waiting for search index (%s) to reach status STEADY: %s", indexID, err),
+			HandlerErrorCode: "GeneralServiceException",
+		}, nil
+	}
+
+	currentModel.Status = &index.Status
+
+	return progressOfIndex(req, currentModel, index.Status), nil
+}
+
+// progressOfIndex turns the polled status into the next progress event, a FAILED build fails the operation, as does
+// waiting longer than the stabilization timeout
+func progressOfIndex(req handler.Request, currentModel *Model, status string) handler.ProgressEvent {
+	indexID := *currentModel.IndexId
+
+	startTime := time.Now().UTC()
+	if started, ok := req.CallbackContext["startTime"]; ok {
+		if t, err := time.Parse(time.RFC3339, cast.ToString(started)); err == nil {
+			startTime = t
+		}
+	}
+	pollCount := cast.ToInt(req.CallbackContext["pollCount"]) + 1
+
+	switch status {
+	case "STEADY":
+		p := handler.NewProgressEvent()
+		p.ResourceModel = currentModel
+		p.OperationStatus = handler.Success
+		p.Message = "Complete"
+		return p
+	case "FAILED":
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          fmt.Sprintf("search index (%s) failed to build, check the field mappings and the analyzers", indexID),
+			HandlerErrorCode: "NotStabilized",
+		}
+	}
+
+	timeout := stabilizationTimeout(currentModel)
+	if time.Since(startTime) > timeout {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          fmt.Sprintf("search index (%s) did not reach status STEADY within %s, still %s after %d polls", indexID, timeout, status, pollCount),
+			HandlerErrorCode: "NotStabilized",
+		}
+	}
+
+	p := handler.NewProgressEvent()
+	p.ResourceModel = currentModel
+	p.OperationStatus = handler.InProgress
+	p.CallbackDelaySeconds = 10
+	p.Message = "Pending"
+	p.CallbackContext = map[string]interface{}{
+		"status":    status,
+		"startTime": startTime.Format(time.RFC3339),
+		"pollCount": pollCount,
+	}
+	return p
+}
+
+func stabilizationTimeout(currentModel *Model) time.Duration {
+	if currentModel.StabilizationTimeoutMinutes != nil && *currentModel.StabilizationTimeoutMinutes > 0 {
+		return time.Duration(*currentModel.StabilizationTimeoutMinutes) * time.Minute
+	}
+	return time.Duration(defaultStabilizationTimeoutMinutes) * time.Minute
+}
+
+type ParameterToBePersistedSpec struct {
+	ApiKeys     *ApiKeyDefinition
+	ProjectId   *string
+	ClusterName *string
+	IndexId     *string
+}
+
+func putParameterIntoParameterStore(resourcePrimaryIdentifier *string, params *ParameterToBePersistedSpec, session *session.Session) (*ssm.PutParameterOutput, error) {
+	ssmClient, err := util.CreateSSMClient(session)
+	if err != nil {
+		return nil, err
+	}
+	// transform api keys to json string
+	parameterName := buildApiKeyParameterName(*resourcePrimaryIdentifier)
+	byteParams, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	stringifiedParams := string(byteParams)
+	parameterType := "SecureString"
+	overwrite := true
+	putParamOutput, err := ssmClient.PutParameter(&ssm.PutParameterInput{Name: &parameterName, Value: &stringifiedParams, Type: &parameterType, Overwrite: &overwrite})
+	if err != nil {
+		return nil, err
+	}
+
+	return putParamOutput, nil
+}
+
+func deleteParameterFromParameterStore(resourcePrimaryIdentifier *string, session *session.Session) (*ssm.DeleteParameterOutput, error) {
+	ssmClient, err := util.CreateSSMClient(session)
+	if err != nil {
+		return nil, err
+	}
+	parameterName := buildApiKeyParameterName(*resourcePrimaryIdentifier)
+
+	deleteParamOutput, err := ssmClient.DeleteParameter(&ssm.DeleteParameterInput{Name: &parameterName})
+	if err != nil {
+		return nil, err
+	}
+
+	return deleteParamOutput, nil
+}
+
+func getParameterFromParameterStore(resourcePrimaryIdentifier *string, session *session.Session) (*ParameterToBePersistedSpec, error) {
+	ssmClient, err := util.CreateSSMClient(session)
+	if err != nil {
+		return nil, err
+	}
+	parameterName := buildApiKeyParameterName(*resourcePrimaryIdentifier)
+	decrypt := true
+	getParamOutput, err := ssmClient.GetParameter(&ssm.GetParameterInput{Name: &parameterName, WithDecryption: &decrypt})
+	if err != nil {
+		return nil, err
+	}
+
+	var params ParameterToBePersistedSpec
+	err = json.Unmarshal([]byte(*getParamOutput.Parameter.Value), &params)
+	if err != nil {
+		return nil, err
+	}
+	return &params, nil
+}
+
+func buildSearchIndexCfnIdentifier(projectId *string, clusterName *string, indexId *string) string {
+	return fmt.Sprintf("%s-%s-%s", *projectId, *clusterName, *indexId)
+}
+
+func buildApiKeyParameterName(resourcePrimaryIdentifier string) string {
+	// this is strictly coupled with permissions for handlers, changing this means changing permissions in handler
+	// moreover changing this might cause polution in parameter store -  be sure you know what you are doing
+	parameterStorePrefix := "mongodbstpatlasv1searchindex"
+	return fmt.Sprintf("%s-%s", parameterStorePrefix, resourcePrimaryIdentifier)
+}
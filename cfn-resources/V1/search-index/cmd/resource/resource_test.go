@@ -0,0 +1,112 @@
+package resource
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
+)
+
+func TestExpandSearchIndex(t *testing.T) {
+	dynamic := true
+	fields := `{"title": {"type": "string", "analyzer": "lucene.english"}}`
+	invalid := `{"title": `
+	analyzers := `[{"name": "custom", "tokenizer": {"type": "standard"}}]`
+
+	testCases := []struct {
+		name    string
+		model   *Model
+		wantErr bool
+	}{
+		{"dynamic mappings", &Model{Mappings: &IndexMappings{Dynamic: &dynamic}}, false},
+		{"explicit fields", &Model{Mappings: &IndexMappings{Fields: &fields}, Analyzers: &analyzers}, false},
+		{"static without fields", &Model{Mappings: &IndexMappings{}}, true},
+		{"invalid fields", &Model{Mappings: &IndexMappings{Fields: &invalid}}, true},
+		{"analyzers not an array", &Model{Mappings: &IndexMappings{Dynamic: &dynamic}, Analyzers: &fields}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := expandSearchIndex(tc.model)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("want error %t, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestFlattenSearchIndexKeepsEquivalentJSON(t *testing.T) {
+	fields := "{\n  \"title\": {\"type\": \"string\"},\n  \"year\": {\"type\": \"number\"}\n}"
+	model := &Model{Mappings: &IndexMappings{Fields: &fields}}
+
+	flattenSearchIndex(model, &searchIndex{
+		IndexID: "5d1268a980eef518dac0cf41",
+		Status:  "STEADY",
+		Mappings: &searchIndexMappings{
+			Fields: json.RawMessage(`{"year":{"type":"number"},"title":{"type":"string"}}`),
+		},
+	})
+
+	if model.Mappings.Fields != &fields {
+		t.Errorf("want the fields of the template kept, got %s", *model.Mappings.Fields)
+	}
+	if model.Mappings.Dynamic != nil {
+		t.Errorf("want Dynamic left unset, got %t", *model.Mappings.Dynamic)
+	}
+
+	flattenSearchIndex(model, &searchIndex{
+		Mappings: &searchIndexMappings{
+			Fields: json.RawMessage(`{"title": {"type": "autocomplete"}}`),
+		},
+	})
+
+	if got := *model.Mappings.Fields; got != `{"title":{"type":"autocomplete"}}` {
+		t.Errorf("want the fields Atlas reports, got %s", got)
+	}
+}
+
+func TestProgressOfIndex(t *testing.T) {
+	indexID := "5f1ea7d9ab3c1c2f3e9b0a30"
+	timeoutMinutes := 15
+	startedLongAgo := time.Now().UTC().Add(-4 * time.Hour).Format(time.RFC3339)
+	startedRecently := time.Now().UTC().Add(-20 * time.Minute).Format(time.RFC3339)
+
+	testCases := []struct {
+		name       string
+		model      *Model
+		context    map[string]interface{}
+		status     string
+		wantStatus handler.Status
+		wantCode   string
+	}{
+		{"steady", &Model{IndexId: &indexID}, map[string]interface{}{"status": "IN_PROGRESS"}, "STEADY", handler.Success, ""},
+		{"building", &Model{IndexId: &indexID}, map[string]interface{}{"status": "IN_PROGRESS"}, "IN_PROGRESS", handler.InProgress, ""},
+		{"failed build", &Model{IndexId: &indexID}, map[string]interface{}{"status": "IN_PROGRESS"}, "FAILED", handler.Failed, "NotStabilized"},
+		{"default timeout exceeded", &Model{IndexId: &indexID}, map[string]interface{}{"status": "IN_PROGRESS", "startTime": startedLongAgo}, "IN_PROGRESS", handler.Failed, "NotStabilized"},
+		{"within the default timeout", &Model{IndexId: &indexID}, map[string]interface{}{"status": "IN_PROGRESS", "startTime": startedRecently}, "IN_PROGRESS", handler.InProgress, ""},
+		{"custom timeout exceeded", &Model{IndexId: &indexID, StabilizationTimeoutMinutes: &timeoutMinutes}, map[string]interface{}{"status": "IN_PROGRESS", "startTime": startedRecently}, "MIGRATING", handler.Failed, "NotStabilized"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := progressOfIndex(handler.Request{CallbackContext: tc.context}, tc.model, tc.status)
+
+			if p.OperationStatus != tc.wantStatus || p.HandlerErrorCode != tc.wantCode {
+				t.Errorf("want %s %s, got %s %s: %s", tc.wantStatus, tc.wantCode, p.OperationStatus, p.HandlerErrorCode, p.Message)
+			}
+		})
+	}
+}
+
+func TestProgressOfIndexKeepsStartTime(t *testing.T) {
+	indexID := "5f1ea7d9ab3c1c2f3e9b0a30"
+	started := time.Now().UTC().Add(-10 * time.Minute).Format(time.RFC3339)
+	req := handler.Request{CallbackContext: map[string]interface{}{"status": "IN_PROGRESS", "startTime": started, "pollCount": 3}}
+
+	p := progressOfIndex(req, &Model{IndexId: &indexID}, "IN_PROGRESS")
+
+	if p.CallbackContext["startTime"] != started || p.CallbackContext["pollCount"] != 4 {
+		t.Errorf("want the start time kept and the poll counted, got %v", p.CallbackContext)
+	}
+}
@@ -0,0 +1,96 @@
+package resource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"go.mongodb.org/atlas/mongodbatlas"
+)
+
+// the search index type of the mongodbatlas client does not report the status of the index nor its custom
+// analyzers, the endpoints are called directly instead, paths are resolved relative to the client base url
+// (https://cloud.mongodb.com/api/atlas/v1.0/)
+const searchIndexesPath = "groups/%s/clusters/%s/fts/indexes"
+
+type searchIndex struct {
+	IndexID        string               `json:"indexID,omitempty"`
+	Name           string               `json:"name"`
+	Database       string               `json:"database"`
+	CollectionName string               `json:"collectionName"`
+	Analyzer       string               `json:"analyzer,omitempty"`
+	SearchAnalyzer string               `json:"searchAnalyzer,omitempty"`
+	Analyzers      json.RawMessage      `json:"analyzers,omitempty"`
+	Mappings       *searchIndexMappings `json:"mappings,omitempty"`
+	Status         string               `json:"status,omitempty"`
+}
+
+type searchIndexMappings struct {
+	Dynamic bool            `json:"dynamic"`
+	Fields  json.RawMessage `json:"fields,omitempty"`
+}
+
+func searchIndexPath(projectID, clusterName, indexID string) string {
+	return fmt.Sprintf("%s/%s", fmt.Sprintf(searchIndexesPath, projectID, url.PathEscape(clusterName)), url.PathEscape(indexID))
+}
+
+func getSearchIndex(client *mongodbatlas.Client, projectID, clusterName, indexID string) (*searchIndex, *mongodbatlas.Response, error) {
+	return doSearchIndex(client, http.MethodGet, searchIndexPath(projectID, clusterName, indexID), nil)
+}
+
+func createSearchIndex(client *mongodbatlas.Client, projectID, clusterName string, index *searchIndex) (*searchIndex, *mongodbatlas.Response, error) {
+	return doSearchIndex(client, http.MethodPost, fmt.Sprintf(searchIndexesPath, projectID, url.PathEscape(clusterName)), index)
+}
+
+func updateSearchIndex(client *mongodbatlas.Client, projectID, clusterName, indexID string, index *searchIndex) (*searchIndex, *mongodbatlas.Response, error) {
+	return doSearchIndex(client, http.MethodPatch, searchIndexPath(projectID, clusterName, indexID), index)
+}
+
+func deleteSearchIndex(client *mongodbatlas.Client, projectID, clusterName, indexID string) (*mongodbatlas.Response, error) {
+	req, err := client.NewRequest(context.Background(), http.MethodDelete, searchIndexPath(projectID, clusterName, indexID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.Do(context.Background(), req, nil)
+}
+
+// listSearchIndexes returns the indexes of a collection, Atlas does not page them
+func listSearchIndexes(client *mongodbatlas.Client, projectID, clusterName, database, collectionName string) ([]searchIndex, *mongodbatlas.Response, error) {
+	path := fmt.Sprintf("%s/%s/%s", fmt.Sprintf(searchIndexesPath, projectID, url.PathEscape(clusterName)), url.PathEscape(database), url.PathEscape(collectionName))
+
+	req, err := client.NewRequest(context.Background(), http.MethodGet, path, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var root []searchIndex
+	resp, err := client.Do(context.Background(), req, &root)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return root, resp, nil
+}
+
+func doSearchIndex(client *mongodbatlas.Client, method, path string, body *searchIndex) (*searchIndex, *mongodbatlas.Response, error) {
+	var reqBody interface{}
+	if body != nil {
+		reqBody = body
+	}
+
+	req, err := client.NewRequest(context.Background(), method, path, reqBody)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	root := new(searchIndex)
+	resp, err := client.Do(context.Background(), req, root)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return root, resp, nil
+}
@@ -0,0 +1,482 @@
+package resource
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/422158/mongodbstpatlas-cloudformation-resources/cfn-resources/V1/serverless-instance/cmd/util"
+	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/spf13/cast"
+	"go.mongodb.org/atlas/mongodbatlas"
+)
+
+// serverless instances always report SERVERLESS as their provider, the cloud they run on is the backing provider
+const serverlessProviderName = "SERVERLESS"
+
+// Create handles the Create event from the Cloudformation service.
+func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	client, err := util.CreateMongoDBClient(*currentModel.ApiKeys.PublicKey, *currentModel.ApiKeys.PrivateKey)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	if _, ok := req.CallbackContext["stateName"]; ok {
+		return validateProgress(client, req, currentModel, "IDLE", "CREATING")
+	}
+
+	projectID := *currentModel.ProjectId
+
+	instance, _, err := createServerlessInstance(client, projectID, expandServerlessInstance(currentModel))
+	if err != nil {
+		return handler.ProgressEvent{}, fmt.Errorf("error creating serverless instance: %s", err)
+	}
+
+	currentModel.Id = &instance.ID
+	currentModel.StateName = &instance.StateName
+
+	cfnid := buildInstanceCfnIdentifier(currentModel.ProjectId, currentModel.Name)
+
+	currentModel.InstanceCfnIdentifier = &cfnid
+
+	// putting required parameters into parameter store (needed for read operation)
+	_, err = putParameterIntoParameterStore(currentModel.InstanceCfnIdentifier, &ParameterToBePersistedSpec{ApiKeys: currentModel.ApiKeys, ProjectId: currentModel.ProjectId, InstanceName: currentModel.Name}, req.Session)
+	if err != nil {
+		return handler.ProgressEvent{}, fmt.Errorf("error when putting api keys into parameter store: %s", err)
+	}
+
+	return handler.ProgressEvent{
+		OperationStatus:      handler.InProgress,
+		Message:              fmt.Sprintf("Create Serverless Instance `%s`", instance.StateName),
+		ResourceModel:        currentModel,
+		CallbackDelaySeconds: 65,
+		CallbackContext: map[string]interface{}{
+			"stateName": instance.StateName,
+		},
+	}, nil
+}
+
+// Read handles the Read event from the Cloudformation service.
+func Read(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	params, err := getParameterFromParameterStore(currentModel.InstanceCfnIdentifier, req.Session)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	client, err := util.CreateMongoDBClient(*params.ApiKeys.PublicKey, *params.ApiKeys.PrivateKey)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	instance, _, err := getServerlessInstance(client, *params.ProjectId, *params.InstanceName)
+	if err != nil {
+		return handler.ProgressEvent{}, fmt.Errorf("error fetching serverless instance info (%s): %s", *params.InstanceName, err)
+	}
+
+	currentModel.ProjectId = params.ProjectId
+	flattenServerlessInstance(currentModel, instance)
+
+	return handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		Message:         "Read Complete",
+		ResourceModel:   currentModel,
+	}, nil
+}
+
+// Update handles the Update event from the Cloudformation service.
+func Update(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	client, err := util.CreateMongoDBClient(*currentModel.ApiKeys.PublicKey, *currentModel.ApiKeys.PrivateKey)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	if _, ok := req.CallbackContext["stateName"]; ok {
+		return validateProgress(client, req, currentModel, "IDLE", "UPDATING")
+	}
+
+	projectID := *currentModel.ProjectId
+	instanceName := *currentModel.Name
+
+	cfnid := buildInstanceCfnIdentifier(currentModel.ProjectId, currentModel.Name)
+
+	currentModel.InstanceCfnIdentifier = &cfnid
+
+	// putting required parameter into parameter store
+	// the api keys might have been updated therefore we need to do this here
+	_, err = putParameterIntoParameterStore(currentModel.InstanceCfnIdentifier, &ParameterToBePersistedSpec{ApiKeys: currentModel.ApiKeys, ProjectId: currentModel.ProjectId, InstanceName: currentModel.Name}, req.Session)
+	if err != nil {
+		return handler.ProgressEvent{}, fmt.Errorf("error when putting api keys into parameter store: %s", err)
+	}
+
+	changes := expandServerlessInstanceChanges(prevModel, currentModel)
+	if changes == nil {
+		return handler.ProgressEvent{
+			OperationStatus: handler.Success,
+			Message:         "Complete",
+			ResourceModel:   currentModel,
+		}, nil
+	}
+
+	instance, _, err := updateServerlessInstance(client, projectID, instanceName, changes)
+	if err != nil {
+		return handler.ProgressEvent{}, fmt.Errorf("error updating serverless instance (%s): %s", instanceName, err)
+	}
+
+	currentModel.Id = &instance.ID
+
+	return handler.ProgressEvent{
+		OperationStatus:      handler.InProgress,
+		Message:              fmt.Sprintf("Update Serverless Instance `%s`", instance.StateName),
+		ResourceModel:        currentModel,
+		CallbackDelaySeconds: 65,
+		CallbackContext: map[string]interface{}{
+			"stateName": instance.StateName,
+		},
+	}, nil
+}
+
+// Delete handles the Delete event from the Cloudformation service.
+func Delete(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	client, err := util.CreateMongoDBClient(*currentModel.ApiKeys.PublicKey, *currentModel.ApiKeys.PrivateKey)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	if _, ok := req.CallbackContext["stateName"]; ok {
+		return validateProgress(client, req, currentModel, "DELETED", "DELETING", "IDLE")
+	}
+
+	projectID := *currentModel.ProjectId
+	instanceName := *currentModel.Name
+
+	// refuse before touching anything, the parameter store entry is still needed to manage the kept instance
+	if currentModel.TerminationProtectionEnabled != nil && *currentModel.TerminationProtectionEnabled {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          fmt.Sprintf("serverless instance (%s) has TerminationProtectionEnabled set to true, set it to false before deleting or replacing the serverless instance", instanceName),
+			HandlerErrorCode: "InvalidRequest",
+		}, nil
+	}
+
+	_, err = deleteServerlessInstance(client, projectID, instanceName)
+	if err != nil {
+		// even when error occurs when deleting, we still want to delete parameter from parameter store
+		_, errParams := deleteParameterFromParameterStore(currentModel.InstanceCfnIdentifier, req.Session)
+		if errParams != nil {
+			return handler.ProgressEvent{
+				OperationStatus:  handler.Failed,
+				Message:          "Delete Failed",
+				HandlerErrorCode: "GeneralServiceException",
+			}, fmt.Errorf("Error deleting serverless instance with name(%s): %s.\nError deleting api keys from parameter store: %s", instanceName, err, errParams)
+		}
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          "Delete Failed",
+			HandlerErrorCode: "GeneralServiceException",
+		}, fmt.Errorf("error deleting serverless instance with name (%s): %s", instanceName, err)
+	}
+
+	_, err = deleteParameterFromParameterStore(currentModel.InstanceCfnIdentifier, req.Session)
+	if err != nil {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          "Delete Failed",
+			HandlerErrorCode: "GeneralServiceException",
+		}, fmt.Errorf("error deleting parameters for serverless instance %s: %s", instanceName, err)
+	}
+
+	return handler.ProgressEvent{
+		OperationStatus:      handler.InProgress,
+		Message:              "Delete In Progress",
+		ResourceModel:        currentModel,
+		CallbackDelaySeconds: 65,
+		CallbackContext: map[string]interface{}{
+			"stateName": "DELETING",
+		},
+	}, nil
+}
+
+// List handles the List event from the Cloudformation service.
+func List(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	client, err := util.CreateMongoDBClient(*currentModel.ApiKeys.PublicKey, *currentModel.ApiKeys.PrivateKey)
+	if err != nil {
+		return handler.ProgressEvent{}, err
+	}
+
+	projectID := *currentModel.ProjectId
+
+	const itemsPerPage = 100
+
+	models := make([]interface{}, 0)
+	for pageNum := 1; ; pageNum++ {
+		page, resp, err := listServerlessInstances(client, projectID, pageNum, itemsPerPage)
+		if err != nil {
+			return handler.ProgressEvent{}, fmt.Errorf("error listing serverless instances for project (%s): %s", projectID, err)
+		}
+
+		for i := range page.Results {
+			var model Model
+			model.ProjectId = currentModel.ProjectId
+			flattenServerlessInstance(&model, &page.Results[i])
+			cfnid := buildInstanceCfnIdentifier(model.ProjectId, model.Name)
+			model.InstanceCfnIdentifier = &cfnid
+			models = append(models, model)
+		}
+
+		if len(page.Results) < itemsPerPage || resp.IsLastPage() {
+			break
+		}
+	}
+
+	return handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		Message:         "List Complete",
+		ResourceModels:  models,
+	}, nil
+}
+
+func expandServerlessInstance(model *Model) *serverlessInstance {
+	instance := &serverlessInstance{
+		Name:                         cast.ToString(model.Name),
+		TerminationProtectionEnabled: model.TerminationProtectionEnabled,
+		ProviderSettings: &serverlessProviderSettings{
+			ProviderName: serverlessProviderName,
+		},
+	}
+
+	if model.ProviderSettings != nil {
+		instance.ProviderSettings.BackingProviderName = cast.ToString(model.ProviderSettings.BackingProviderName)
+		instance.ProviderSettings.RegionName = cast.ToString(model.ProviderSettings.RegionName)
+	}
+
+	return instance
+}
+
+// expandServerlessInstanceChanges returns the fields the update changes, nil when there is nothing to send. Name,
+// project and provider settings are create only, termination protection is all Atlas lets us change.
+func expandServerlessInstanceChanges(prevModel *Model, currentModel *Model) *serverlessInstance {
+	prevEnabled := prevModel.TerminationProtectionEnabled != nil && *prevModel.TerminationProtectionEnabled
+	terminationProtectionEnabled := currentModel.TerminationProtectionEnabled != nil && *currentModel.TerminationProtectionEnabled
+	if prevEnabled == terminationProtectionEnabled {
+		return nil
+	}
+
+	return &serverlessInstance{
+		TerminationProtectionEnabled: &terminationProtectionEnabled,
+	}
+}
+
+func flattenServerlessInstance(currentModel *Model, instance *serverlessInstance) {
+	currentModel.Name = &instance.Name
+	currentModel.Id = &instance.ID
+	currentModel.StateName = &instance.StateName
+	currentModel.TerminationProtectionEnabled = instance.TerminationProtectionEnabled
+
+	if instance.MongoDBVersion != "" {
+		currentModel.MongoDBVersion = &instance.MongoDBVersion
+	}
+	if instance.CreateDate != "" {
+		currentModel.CreateDate = &instance.CreateDate
+	}
+
+	if instance.ProviderSettings != nil {
+		providerSettings := &ServerlessProviderSettings{
+			BackingProviderName: &instance.ProviderSettings.BackingProviderName,
+			RegionName:          &instance.ProviderSettings.RegionName,
+		}
+		// SERVERLESS is implied, only report it back when the template spells it out
+		if currentModel.ProviderSettings != nil && currentModel.ProviderSettings.ProviderName != nil {
+			providerSettings.ProviderName = &instance.ProviderSettings.ProviderName
+		}
+		currentModel.ProviderSettings = providerSettings
+	}
+
+	currentModel.ConnectionStrings = nil
+	if instance.ConnectionStrings != nil {
+		connectionStrings := &ServerlessConnectionStrings{}
+		if instance.ConnectionStrings.StandardSrv != "" {
+			connectionStrings.StandardSrv = &instance.ConnectionStrings.StandardSrv
+		}
+		for i := range instance.ConnectionStrings.PrivateEndpoint {
+			endpoint := instance.ConnectionStrings.PrivateEndpoint[i]
+			connectionStrings.PrivateEndpoint = append(connectionStrings.PrivateEndpoint, ServerlessPrivateEndpoint{
+				SrvConnectionString: &endpoint.SrvConnectionString,
+				Type:                &endpoint.Type,
+			})
+		}
+		currentModel.ConnectionStrings = connectionStrings
+	}
+}
+
+// defaultStabilizationTimeoutMinutes is how long the callbacks poll a serverless instance when StabilizationTimeoutMinutes is not set
+const defaultStabilizationTimeoutMinutes = 180
+
+// validateProgress polls the serverless instance until it reaches targetState, see progressOfState
+func validateProgress(client *mongodbatlas.Client, req handler.Request, currentModel *Model, targetState string, pendingStates ...string) (handler.ProgressEvent, error) {
+	instanceName := *currentModel.Name
+
+	isReady, state, err := isInstanceInTargetState(client, *currentModel.ProjectId, instanceName, targetState)
+	if err != nil {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          fmt.Sprintf("error waiting for serverless instance (%s) to reach state %s: %s", instanceName, targetState, err),
+			HandlerErrorCode: "GeneralServiceException",
+		}, nil
+	}
+
+	return progressOfState(req, currentModel, isReady, state, targetState, pendingStates), nil
+}
+
+// progressOfState turns the polled state into the next progress event, any state that is neither the target nor one
+// of pendingStates fails the operation, as does waiting longer than the stabilization timeout
+func progressOfState(req handler.Request, currentModel *Model, isReady bool, state string, targetState string, pendingStates []string) handler.ProgressEvent {
+	instanceName := *currentModel.Name
+
+	startTime := time.Now().UTC()
+	if started, ok := req.CallbackContext["startTime"]; ok {
+		if t, err := time.Parse(time.RFC3339, cast.ToString(started)); err == nil {
+			startTime = t
+		}
+	}
+	pollCount := cast.ToInt(req.CallbackContext["pollCount"]) + 1
+
+	if isReady {
+		p := handler.NewProgressEvent()
+		p.ResourceModel = currentModel
+		p.OperationStatus = handler.Success
+		p.Message = "Complete"
+		return p
+	}
+
+	if !contains(pendingStates, state) {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          fmt.Sprintf("error waiting for serverless instance (%s) to reach state %s: unexpected state %s", instanceName, targetState, state),
+			HandlerErrorCode: "GeneralServiceException",
+		}
+	}
+
+	timeout := stabilizationTimeout(currentModel)
+	if time.Since(startTime) > timeout {
+		return handler.ProgressEvent{
+			OperationStatus:  handler.Failed,
+			Message:          fmt.Sprintf("serverless instance (%s) did not reach state %s within %s, still %s after %d polls", instanceName, targetState, timeout, state, pollCount),
+			HandlerErrorCode: "NotStabilized",
+		}
+	}
+
+	p := handler.NewProgressEvent()
+	p.ResourceModel = currentModel
+	p.OperationStatus = handler.InProgress
+	p.CallbackDelaySeconds = 60
+	p.Message = "Pending"
+	p.CallbackContext = map[string]interface{}{
+		"stateName": state,
+		"startTime": startTime.Format(time.RFC3339),
+		"pollCount": pollCount,
+	}
+	return p
+}
+
+func stabilizationTimeout(currentModel *Model) time.Duration {
+	if currentModel.StabilizationTimeoutMinutes != nil && *currentModel.StabilizationTimeoutMinutes > 0 {
+		return time.Duration(*currentModel.StabilizationTimeoutMinutes) * time.Minute
+	}
+	return time.Duration(defaultStabilizationTimeoutMinutes) * time.Minute
+}
+
+func isInstanceInTargetState(client *mongodbatlas.Client, projectID, instanceName, targetState string) (bool, string, error) {
+	instance, resp, err := getServerlessInstance(client, projectID, instanceName)
+	if err != nil {
+		if resp != nil && resp.StatusCode == 404 {
+			return "DELETED" == targetState, "DELETED", nil
+		}
+		return false, "ERROR", fmt.Errorf("error fetching serverless instance info (%s): %s", instanceName, err)
+	}
+	return instance.StateName == targetState, instance.StateName, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+type ParameterToBePersistedSpec struct {
+	ApiKeys      *ApiKeyDefinition
+	ProjectId    *string
+	InstanceName *string
+}
+
+func putParameterIntoParameterStore(resourcePrimaryIdentifier *string, params *ParameterToBePersistedSpec, session *session.Session) (*ssm.PutParameterOutput, error) {
+	ssmClient, err := util.CreateSSMClient(session)
+	if err != nil {
+		return nil, err
+	}
+	// transform api keys to json string
+	parameterName := buildApiKeyParameterName(*resourcePrimaryIdentifier)
+	byteParams, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	stringifiedParams := string(byteParams)
+	parameterType := "SecureString"
+	overwrite := true
+	putParamOutput, err := ssmClient.PutParameter(&ssm.PutParameterInput{Name: &parameterName, Value: &stringifiedParams, Type: &parameterType, Overwrite: &overwrite})
+	if err != nil {
+		return nil, err
+	}
+
+	return putParamOutput, nil
+}
+
+func deleteParameterFromParameterStore(resourcePrimaryIdentifier *string, session *session.Session) (*ssm.DeleteParameterOutput, error) {
+	ssmClient, err := util.CreateSSMClient(session)
+	if err != nil {
+		return nil, err
+	}
+	parameterName := buildApiKeyParameterName(*resourcePrimaryIdentifier)
+
+	deleteParamOutput, err := ssmClient.DeleteParameter(&ssm.DeleteParameterInput{Name: &parameterName})
+	if err != nil {
+		return nil, err
+	}
+
+	return deleteParamOutput, nil
+}
+
+func getParameterFromParameterStore(resourcePrimaryIdentifier *string, session *session.Session) (*ParameterToBePersistedSpec, error) {
+	ssmClient, err := util.CreateSSMClient(session)
+	if err != nil {
+		return nil, err
+	}
+	parameterName := buildApiKeyParameterName(*resourcePrimaryIdentifier)
+	decrypt := true
+	getParamOutput, err := ssmClient.GetParameter(&ssm.GetParameterInput{Name: &parameterName, WithDecryption: &decrypt})
+	if err != nil {
+		return nil, err
+	}
+
+	var params ParameterToBePersistedSpec
+	err = json.Unmarshal([]byte(*getParamOutput.Parameter.Value), &params)
+	if err != nil {
+		return nil, err
+	}
+	return &params, nil
+}
+
+func buildInstanceCfnIdentifier(projectId *string, instanceName *string) string {
+	return fmt.Sprintf("%s-%s", *projectId, *instanceName)
+}
+
+func buildApiKeyParameterName(resourcePrimaryIdentifier string) string {
+	// this is strictly coupled with permissions for handlers, changing this means changing permissions in handler
+	// moreover changing this might cause polution in parameter store -  be sure you know what you are doing
+	parameterStorePrefix := "mongodbstpatlasv1serverlessinstance"
+	return fmt.Sprintf("%s-%s", parameterStorePrefix, resourcePrimaryIdentifier)
+}
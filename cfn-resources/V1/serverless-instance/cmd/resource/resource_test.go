@@ -0,0 +1,174 @@
+package resource
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
+	"go.mongodb.org/atlas/mongodbatlas"
+)
+
+func TestExpandServerlessInstanceChanges(t *testing.T) {
+	enabled, disabled := true, false
+
+	testCases := []struct {
+		name     string
+		previous *bool
+		current  *bool
+		want     *bool
+	}{
+		{"unchanged", &enabled, &enabled, nil},
+		{"unset stays disabled", nil, &disabled, nil},
+		{"enabled", nil, &enabled, &enabled},
+		{"disabled", &enabled, &disabled, &disabled},
+		{"removed", &enabled, nil, &disabled},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := expandServerlessInstanceChanges(&Model{TerminationProtectionEnabled: tc.previous}, &Model{TerminationProtectionEnabled: tc.current})
+
+			switch {
+			case tc.want == nil && got != nil:
+				t.Errorf("want nothing sent, got %+v", got)
+			case tc.want != nil && (got == nil || !reflect.DeepEqual(tc.want, got.TerminationProtectionEnabled)):
+				t.Errorf("want TerminationProtectionEnabled %t sent, got %+v", *tc.want, got)
+			}
+		})
+	}
+}
+
+func TestFlattenServerlessInstance(t *testing.T) {
+	instance := &serverlessInstance{
+		ID:             "5f1ea7d9ab3c1c2f3e9b0a11",
+		Name:           "serverless",
+		StateName:      "IDLE",
+		MongoDBVersion: "5.0.6",
+		ProviderSettings: &serverlessProviderSettings{
+			ProviderName:        serverlessProviderName,
+			BackingProviderName: "AWS",
+			RegionName:          "US_EAST_1",
+		},
+		ConnectionStrings: &serverlessConnectionStrings{
+			StandardSrv:     "mongodb+srv://serverless.abcde.mongodb.net",
+			PrivateEndpoint: []serverlessPrivateEndpoint{{SrvConnectionString: "mongodb+srv://serverless-pe-0.abcde.mongodb.net", Type: "MONGOS"}},
+		},
+	}
+
+	model := &Model{}
+	flattenServerlessInstance(model, instance)
+
+	if model.ProviderSettings.ProviderName != nil {
+		t.Errorf("want the implied provider left out, got %s", *model.ProviderSettings.ProviderName)
+	}
+	if *model.ProviderSettings.BackingProviderName != "AWS" || *model.ProviderSettings.RegionName != "US_EAST_1" {
+		t.Errorf("want the backing provider and region, got %+v", model.ProviderSettings)
+	}
+	if *model.ConnectionStrings.StandardSrv != instance.ConnectionStrings.StandardSrv || len(model.ConnectionStrings.PrivateEndpoint) != 1 {
+		t.Errorf("want the connection strings, got %+v", model.ConnectionStrings)
+	}
+	if model.CreateDate != nil || *model.MongoDBVersion != "5.0.6" {
+		t.Errorf("want only the reported values set, got %v and %v", model.CreateDate, model.MongoDBVersion)
+	}
+
+	providerName := serverlessProviderName
+	model = &Model{ProviderSettings: &ServerlessProviderSettings{ProviderName: &providerName}}
+	instance.ConnectionStrings = nil
+	flattenServerlessInstance(model, instance)
+
+	if model.ProviderSettings.ProviderName == nil || *model.ProviderSettings.ProviderName != serverlessProviderName {
+		t.Errorf("want the provider the template spells out, got %v", model.ProviderSettings.ProviderName)
+	}
+	if model.ConnectionStrings != nil {
+		t.Errorf("want no connection strings, got %+v", model.ConnectionStrings)
+	}
+}
+
+func TestValidateProgress(t *testing.T) {
+	projectID, instanceName := "5f1ea7d9ab3c1c2f3e9b0a00", "serverless"
+
+	testCases := []struct {
+		name       string
+		status     int
+		body       string
+		target     string
+		wantStatus handler.Status
+		wantCode   string
+	}{
+		{"ready", http.StatusOK, `{"stateName": "IDLE"}`, "IDLE", handler.Success, ""},
+		{"pending", http.StatusOK, `{"stateName": "CREATING"}`, "IDLE", handler.InProgress, ""},
+		{"unexpected state", http.StatusOK, `{"stateName": "DELETING"}`, "IDLE", handler.Failed, "GeneralServiceException"},
+		{"deleted", http.StatusNotFound, `{"errorCode": "SERVERLESS_INSTANCE_NOT_FOUND"}`, "DELETED", handler.Success, ""},
+		{"gone while creating", http.StatusNotFound, `{"errorCode": "SERVERLESS_INSTANCE_NOT_FOUND"}`, "IDLE", handler.Failed, "GeneralServiceException"},
+		{"atlas error", http.StatusInternalServerError, `{"errorCode": "UNEXPECTED_ERROR"}`, "IDLE", handler.Failed, "GeneralServiceException"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+				fmt.Fprint(w, tc.body)
+			}))
+			defer server.Close()
+			client := mongodbatlas.NewClient(nil)
+			client.BaseURL, _ = url.Parse(server.URL + "/")
+
+			p, err := validateProgress(client, handler.Request{}, &Model{ProjectId: &projectID, Name: &instanceName}, tc.target, "CREATING")
+			if err != nil || p.OperationStatus != tc.wantStatus || p.HandlerErrorCode != tc.wantCode {
+				t.Errorf("want %s %s, got %s %s: %s, %v", tc.wantStatus, tc.wantCode, p.OperationStatus, p.HandlerErrorCode, p.Message, err)
+			}
+		})
+	}
+}
+
+func TestProgressOfState(t *testing.T) {
+	name := "serverless"
+	timeoutMinutes := 15
+	startedLongAgo := time.Now().UTC().Add(-4 * time.Hour).Format(time.RFC3339)
+	startedRecently := time.Now().UTC().Add(-20 * time.Minute).Format(time.RFC3339)
+
+	testCases := []struct {
+		name       string
+		model      *Model
+		context    map[string]interface{}
+		isReady    bool
+		state      string
+		wantStatus handler.Status
+		wantCode   string
+	}{
+		{"ready", &Model{Name: &name}, map[string]interface{}{"stateName": "CREATING"}, true, "IDLE", handler.Success, ""},
+		{"first poll", &Model{Name: &name}, map[string]interface{}{"stateName": "CREATING"}, false, "CREATING", handler.InProgress, ""},
+		{"unexpected state", &Model{Name: &name}, map[string]interface{}{"stateName": "CREATING"}, false, "DELETING", handler.Failed, "GeneralServiceException"},
+		{"default timeout exceeded", &Model{Name: &name}, map[string]interface{}{"stateName": "CREATING", "startTime": startedLongAgo}, false, "CREATING", handler.Failed, "NotStabilized"},
+		{"within the default timeout", &Model{Name: &name}, map[string]interface{}{"stateName": "CREATING", "startTime": startedRecently}, false, "CREATING", handler.InProgress, ""},
+		{"custom timeout exceeded", &Model{Name: &name, StabilizationTimeoutMinutes: &timeoutMinutes}, map[string]interface{}{"stateName": "CREATING", "startTime": startedRecently}, false, "CREATING", handler.Failed, "NotStabilized"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := handler.Request{CallbackContext: tc.context}
+			p := progressOfState(req, tc.model, tc.isReady, tc.state, "IDLE", []string{"CREATING"})
+
+			if p.OperationStatus != tc.wantStatus || p.HandlerErrorCode != tc.wantCode {
+				t.Errorf("want %s %s, got %s %s: %s", tc.wantStatus, tc.wantCode, p.OperationStatus, p.HandlerErrorCode, p.Message)
+			}
+		})
+	}
+}
+
+func TestProgressOfStateKeepsStartTime(t *testing.T) {
+	name := "serverless"
+	started := time.Now().UTC().Add(-10 * time.Minute).Format(time.RFC3339)
+	req := handler.Request{CallbackContext: map[string]interface{}{"stateName": "UPDATING", "startTime": started, "pollCount": 3}}
+
+	p := progressOfState(req, &Model{Name: &name}, false, "UPDATING", "IDLE", []string{"UPDATING"})
+
+	if p.CallbackContext["startTime"] != started || p.CallbackContext["pollCount"] != 4 {
+		t.Errorf("want the start time kept and the poll counted, got %v", p.CallbackContext)
+	}
+}
@@ -0,0 +1,115 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"go.mongodb.org/atlas/mongodbatlas"
+)
+
+// the mongodbatlas client does not wrap the serverless instance endpoints,
+// paths are resolved relative to the client base url (https://cloud.mongodb.com/api/atlas/v1.0/)
+const serverlessInstancesPath = "groups/%s/serverless"
+
+type serverlessInstance struct {
+	ID                           string                       `json:"id,omitempty"`
+	GroupID                      string                       `json:"groupId,omitempty"`
+	Name                         string                       `json:"name,omitempty"`
+	ProviderSettings             *serverlessProviderSettings  `json:"providerSettings,omitempty"`
+	TerminationProtectionEnabled *bool                        `json:"terminationProtectionEnabled,omitempty"`
+	MongoDBVersion               string                       `json:"mongoDBVersion,omitempty"`
+	CreateDate                   string                       `json:"createDate,omitempty"`
+	StateName                    string                       `json:"stateName,omitempty"`
+	ConnectionStrings            *serverlessConnectionStrings `json:"connectionStrings,omitempty"`
+	Links                        []*mongodbatlas.Link         `json:"links,omitempty"`
+}
+
+type serverlessProviderSettings struct {
+	ProviderName        string `json:"providerName,omitempty"`
+	BackingProviderName string `json:"backingProviderName,omitempty"`
+	RegionName          string `json:"regionName,omitempty"`
+}
+
+type serverlessConnectionStrings struct {
+	StandardSrv     string                      `json:"standardSrv,omitempty"`
+	PrivateEndpoint []serverlessPrivateEndpoint `json:"privateEndpoint,omitempty"`
+}
+
+type serverlessPrivateEndpoint struct {
+	SrvConnectionString string `json:"srvConnectionString,omitempty"`
+	Type                string `json:"type,omitempty"`
+}
+
+type serverlessInstancesResponse struct {
+	Links      []*mongodbatlas.Link `json:"links,omitempty"`
+	Results    []serverlessInstance `json:"results,omitempty"`
+	TotalCount int                  `json:"totalCount,omitempty"`
+}
+
+func serverlessInstancePath(projectID, instanceName string) string {
+	return fmt.Sprintf("%s/%s", fmt.Sprintf(serverlessInstancesPath, projectID), url.PathEscape(instanceName))
+}
+
+func getServerlessInstance(client *mongodbatlas.Client, projectID, instanceName string) (*serverlessInstance, *mongodbatlas.Response, error) {
+	return doServerlessInstance(client, http.MethodGet, serverlessInstancePath(projectID, instanceName), nil)
+}
+
+func createServerlessInstance(client *mongodbatlas.Client, projectID string, instance *serverlessInstance) (*serverlessInstance, *mongodbatlas.Response, error) {
+	return doServerlessInstance(client, http.MethodPost, fmt.Sprintf(serverlessInstancesPath, projectID), instance)
+}
+
+func updateServerlessInstance(client *mongodbatlas.Client, projectID, instanceName string, instance *serverlessInstance) (*serverlessInstance, *mongodbatlas.Response, error) {
+	return doServerlessInstance(client, http.MethodPatch, serverlessInstancePath(projectID, instanceName), instance)
+}
+
+func deleteServerlessInstance(client *mongodbatlas.Client, projectID, instanceName string) (*mongodbatlas.Response, error) {
+	req, err := client.NewRequest(context.Background(), http.MethodDelete, serverlessInstancePath(projectID, instanceName), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.Do(context.Background(), req, nil)
+}
+
+func listServerlessInstances(client *mongodbatlas.Client, projectID string, pageNum, itemsPerPage int) (*serverlessInstancesResponse, *mongodbatlas.Response, error) {
+	path := fmt.Sprintf("%s?pageNum=%d&itemsPerPage=%d", fmt.Sprintf(serverlessInstancesPath, projectID), pageNum, itemsPerPage)
+
+	req, err := client.NewRequest(context.Background(), http.MethodGet, path, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	root := new(serverlessInstancesResponse)
+	resp, err := client.Do(context.Background(), req, root)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	if l := root.Links; l != nil {
+		resp.Links = l
+	}
+
+	return root, resp, nil
+}
+
+func doServerlessInstance(client *mongodbatlas.Client, method, path string, body *serverlessInstance) (*serverlessInstance, *mongodbatlas.Response, error) {
+	var reqBody interface{}
+	if body != nil {
+		reqBody = body
+	}
+
+	req, err := client.NewRequest(context.Background(), method, path, reqBody)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	root := new(serverlessInstance)
+	resp, err := client.Do(context.Background(), req, root)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return root, resp, nil
+}
@@ -0,0 +1,11 @@
+module github.com/422158/mongodbstpatlas-cloudformation-resources/cfn-resources/V1/serverless-instance
+
+go 1.14
+
+require (
+	github.com/Sectorbob/mlab-ns2 v0.0.0-20171030222938-d3aa0c295a8a
+	github.com/aws-cloudformation/cloudformation-cli-go-plugin v1.2.0
+	github.com/aws/aws-sdk-go v1.44.197
+	github.com/spf13/cast v1.3.1
+	go.mongodb.org/atlas v0.7.2
+)